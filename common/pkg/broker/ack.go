@@ -0,0 +1,144 @@
+package broker
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	// RetryHeader counts how often a message was requeued after a transient failure
+	RetryHeader = "x-retry-count"
+
+	// DefaultPrefetch is how many unacknowledged messages a consumer holds at once
+	DefaultPrefetch = 1
+	// DefaultMaxRetries is how often a message is retried before it is given up on
+	DefaultMaxRetries = 3
+
+	// retryDelay is waited per previous retry before a message is requeued. The wait runs on a timer,
+	// so the delivery stays unacknowledged meanwhile but the handler that settled it is free again.
+	retryDelay = time.Second
+)
+
+// permanentError marks a failure that retrying cannot fix, such as a malformed payload
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent wraps err so the message that caused it is rejected instead of retried
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err or an error it wraps was marked with Permanent
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// Publisher publishes messages, as *amqp.Channel does
+type Publisher interface {
+	Publish(exchange string, key string, mandatory bool, immediate bool, msg amqp.Publishing) error
+}
+
+// RetryCount returns how often the delivery was already retried
+func RetryCount(delivery amqp.Delivery) int {
+	switch count := delivery.Headers[RetryHeader].(type) {
+	case int8:
+		return int(count)
+	case int16:
+		return int(count)
+	case int32:
+		return int(count)
+	case int64:
+		return int(count)
+	case int:
+		return count
+	}
+	return 0
+}
+
+// Settle acknowledges a delivery consumed with manual acks once it was handled with handleErr:
+//   - nil: the message is acked.
+//   - a Permanent error, or a transient one after maxRetries retries: the message is moved to the
+//     dead-letter queue of its queue with the error as its reason.
+//   - a transient error: the message is requeued at the back of its queue with RetryHeader
+//     incremented. A nack cannot change headers, so the copy is republished and the original acked;
+//     if republishing fails the original is nacked with requeue instead. Later retries are delayed
+//     by retryDelay per earlier retry; Settle returns at once and the requeue happens on a timer, whose
+//     errors are logged. If the connection closes first, RabbitMQ redelivers the unacked original.
+func Settle(publisher Publisher, delivery amqp.Delivery, handleErr error, maxRetries int) error {
+	if handleErr == nil {
+		return delivery.Ack(false)
+	}
+	if IsPermanent(handleErr) {
+		log.Printf("Dead-lettering message: %v", handleErr)
+		return deadLetter(publisher, delivery, handleErr)
+	}
+
+	retries := RetryCount(delivery)
+	if retries >= maxRetries {
+		log.Printf("Dead-lettering message after %d retries: %v", retries, handleErr)
+		return deadLetter(publisher, delivery, fmt.Errorf("failed after %d retries: %w", retries, handleErr))
+	}
+
+	log.Printf("Requeueing message for retry %d of %d: %v", retries+1, maxRetries, handleErr)
+	if retries == 0 {
+		return republish(publisher, delivery, retries+1)
+	}
+	time.AfterFunc(time.Duration(retries)*retryDelay, func() {
+		if err := republish(publisher, delivery, retries+1); err != nil {
+			log.Printf("Failed to settle requeued message: %v", err)
+		}
+	})
+	return nil
+}
+
+// republish republishes the delivery with its retry counter set to retries and acks the original
+func republish(publisher Publisher, delivery amqp.Delivery, retries int) error {
+	err := publisher.Publish(delivery.Exchange, delivery.RoutingKey, false, false, retryPublishing(delivery, retries))
+	if err != nil {
+		log.Printf("Failed to requeue message, nacking it instead: %v", err)
+		return delivery.Nack(false, true)
+	}
+	return delivery.Ack(false)
+}
+
+// retryPublishing copies the delivery with its retry counter set to retries
+func retryPublishing(delivery amqp.Delivery, retries int) amqp.Publishing {
+	headers := make(amqp.Table, len(delivery.Headers)+1)
+	for key, value := range delivery.Headers {
+		headers[key] = value
+	}
+	headers[RetryHeader] = int32(retries)
+
+	return amqp.Publishing{
+		Headers:         headers,
+		ContentType:     delivery.ContentType,
+		ContentEncoding: delivery.ContentEncoding,
+		DeliveryMode:    delivery.DeliveryMode,
+		Priority:        delivery.Priority,
+		CorrelationId:   delivery.CorrelationId,
+		ReplyTo:         delivery.ReplyTo,
+		Expiration:      delivery.Expiration,
+		MessageId:       delivery.MessageId,
+		Timestamp:       delivery.Timestamp,
+		Type:            delivery.Type,
+		AppId:           delivery.AppId,
+		Body:            delivery.Body,
+	}
+}
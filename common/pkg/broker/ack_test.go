@@ -0,0 +1,97 @@
+package broker
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// recorder records publishes and acknowledgements, standing in for a channel
+type recorder struct {
+	mu        sync.Mutex
+	published []amqp.Publishing
+	acks      int
+	nacks     int
+	settled   chan struct{}
+}
+
+func newRecorder() *recorder {
+	return &recorder{settled: make(chan struct{}, 1)}
+}
+
+func (r *recorder) Publish(exchange string, key string, mandatory bool, immediate bool, msg amqp.Publishing) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.published = append(r.published, msg)
+	return nil
+}
+
+func (r *recorder) Ack(tag uint64, multiple bool) error {
+	r.mu.Lock()
+	r.acks++
+	r.mu.Unlock()
+	r.settled <- struct{}{}
+	return nil
+}
+
+func (r *recorder) Nack(tag uint64, multiple bool, requeue bool) error {
+	r.mu.Lock()
+	r.nacks++
+	r.mu.Unlock()
+	r.settled <- struct{}{}
+	return nil
+}
+
+func (r *recorder) Reject(tag uint64, requeue bool) error {
+	return r.Nack(tag, false, requeue)
+}
+
+func TestSettleDelaysRetriesWithoutBlocking(t *testing.T) {
+	tests := []struct {
+		name      string
+		retries   int32
+		wantDelay time.Duration
+	}{
+		{name: "first retry is immediate", retries: 0},
+		{name: "later retry waits per earlier retry", retries: 1, wantDelay: retryDelay},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := newRecorder()
+			delivery := amqp.Delivery{
+				Acknowledger: rec,
+				Headers:      amqp.Table{RetryHeader: test.retries},
+				Body:         []byte("payload"),
+			}
+
+			start := time.Now()
+			if err := Settle(rec, delivery, errors.New("redis unavailable"), DefaultMaxRetries); err != nil {
+				t.Fatalf("Settle() error = %v", err)
+			}
+			if returned := time.Since(start); returned >= retryDelay/2 {
+				t.Fatalf("Settle() took %v, want it to return without waiting", returned)
+			}
+
+			select {
+			case <-rec.settled:
+			case <-time.After(test.wantDelay + time.Second):
+				t.Fatal("delivery was never settled")
+			}
+			if waited := time.Since(start); waited < test.wantDelay {
+				t.Errorf("requeued after %v, want at least %v", waited, test.wantDelay)
+			}
+
+			rec.mu.Lock()
+			defer rec.mu.Unlock()
+			if rec.acks != 1 || len(rec.published) != 1 {
+				t.Fatalf("acks = %d, publishes = %d, want 1 each", rec.acks, len(rec.published))
+			}
+			if got := rec.published[0].Headers[RetryHeader]; got != test.retries+1 {
+				t.Errorf("%s = %v, want %d", RetryHeader, got, test.retries+1)
+			}
+		})
+	}
+}
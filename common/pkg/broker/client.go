@@ -0,0 +1,618 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+var (
+	// ErrClientClosed is returned by a Client after Close
+	ErrClientClosed = errors.New("queue client is closed")
+	// ErrNotConfirmed is returned when the broker did not confirm a message in time
+	ErrNotConfirmed = errors.New("message was not confirmed by the broker")
+
+	errNotConnected = errors.New("not connected to RabbitMQ")
+)
+
+// Config tunes a Client
+type Config struct {
+	// Dial opens connections, DialAMQP unless the Client runs on a MemoryBroker
+	Dial Dialer
+	// MinBackoff and MaxBackoff bound the delay between reconnection attempts, which doubles per failure
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// PublishTimeout is how long a publish waits for a connection and the broker's confirmation
+	PublishTimeout time.Duration
+}
+
+var DefaultConfig = Config{
+	Dial:           DialAMQP,
+	MinBackoff:     500 * time.Millisecond,
+	MaxBackoff:     30 * time.Second,
+	PublishTimeout: 10 * time.Second,
+}
+
+// Client is a RabbitMQ client that survives broker restarts. It connects in the background and
+// reconnects with backoff whenever the connection closes. Consumers are subscribed again on a new
+// channel after a channel or connection closes, and publishes wait until the broker confirms them.
+//
+// Deliveries received before a reconnect can no longer be acked; RabbitMQ redelivers them. Messages
+// are delivered at least once, so a publish retried after a lost confirmation may be duplicated.
+type Client struct {
+	url    string
+	config Config
+
+	mu sync.Mutex
+	// conn is the open connection, nil while reconnecting; ready is closed while conn is set
+	conn  Connection
+	ready chan struct{}
+	// publishing is the confirm-mode channel shared by publishes, opened on the first one
+	publishing *confirmer
+	// declared holds the queues and exchanges declared on the current connection
+	declared  map[string]bool
+	consumers map[string]*consumer
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// consumer forwards the deliveries of whichever channel currently consumes its queue
+type consumer struct {
+	queue    string
+	tag      string
+	prefetch int
+	bindings []Binding
+	// transient consumers declare a queue RabbitMQ deletes once they stop consuming it
+	transient bool
+
+	deliveries chan amqp.Delivery
+	cancel     chan struct{}
+	cancelOnce sync.Once
+
+	mu      sync.Mutex
+	channel Channel
+}
+
+// NewClient connects to RabbitMQ at url in the background
+func NewClient(url string) *Client {
+	return NewClientWith(url, DefaultConfig)
+}
+
+// NewClientWith connects to the broker at url in the background, using config
+func NewClientWith(url string, config Config) *Client {
+	if config.Dial == nil {
+		config.Dial = DialAMQP
+	}
+	client := &Client{
+		url:       url,
+		config:    config,
+		ready:     make(chan struct{}),
+		declared:  make(map[string]bool),
+		consumers: make(map[string]*consumer),
+		closed:    make(chan struct{}),
+	}
+	go client.run()
+	return client
+}
+
+// run keeps the client connected until it is closed
+func (c *Client) run() {
+	backoff := c.config.MinBackoff
+	for {
+		conn, err := c.config.Dial(c.url)
+		if err != nil {
+			log.Printf("Failed to connect to RabbitMQ, retrying in %v: %v", backoff, err)
+			select {
+			case <-time.After(backoff):
+			case <-c.closed:
+				return
+			}
+			backoff = min(backoff*2, c.config.MaxBackoff)
+			continue
+		}
+		backoff = c.config.MinBackoff
+		connectionClosed := conn.NotifyClose(make(chan *amqp.Error, 1))
+		c.connected(conn)
+		log.Printf("Connected to RabbitMQ")
+
+		select {
+		case err := <-connectionClosed:
+			log.Printf("Lost connection to RabbitMQ, reconnecting: %v", err)
+			c.disconnected()
+		case <-c.closed:
+			c.disconnected()
+			conn.Close()
+			return
+		}
+	}
+}
+
+func (c *Client) connected(conn Connection) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn = conn
+	c.declared = make(map[string]bool)
+	close(c.ready)
+}
+
+func (c *Client) disconnected() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn = nil
+	c.publishing = nil
+	c.ready = make(chan struct{})
+}
+
+// connection waits until the client is connected, stop is closed or the client is closed
+func (c *Client) connection(stop <-chan struct{}) (Connection, error) {
+	for {
+		c.mu.Lock()
+		conn, ready := c.conn, c.ready
+		c.mu.Unlock()
+		if conn != nil {
+			return conn, nil
+		}
+		select {
+		case <-ready:
+		case <-stop:
+			return nil, errNotConnected
+		case <-c.closed:
+			return nil, ErrClientClosed
+		}
+	}
+}
+
+// SendMessage declares queueName with its dead-letter queue and publishes a persistent message to it
+func (c *Client) SendMessage(queueName string, message []byte) error {
+	declare := func(ch Channel) error {
+		return c.declare(ch, "queue "+queueName, func() error { return DeclareQueue(ch, queueName) })
+	}
+	return c.publish(declare, "", queueName, amqp.Publishing{
+		ContentType:  "text/plain",
+		DeliveryMode: amqp.Persistent,
+		Body:         message,
+	})
+}
+
+// PublishEvent declares exchange and publishes a persistent JSON message to it with the routing key
+// key. Queues bound to the exchange with that key receive it; without any, the message is dropped.
+func (c *Client) PublishEvent(exchange string, key string, body []byte) error {
+	declare := func(ch Channel) error {
+		return c.declare(ch, "exchange "+exchange, func() error { return DeclareExchange(ch, exchange) })
+	}
+	return c.publish(declare, exchange, key, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Body:         body,
+	})
+}
+
+// Publish publishes msg and waits until the broker confirms it, across reconnects when needed. It
+// has the signature of Publisher, so Settle retries and dead-letters through the client.
+func (c *Client) Publish(exchange string, key string, mandatory bool, immediate bool, msg amqp.Publishing) error {
+	if mandatory || immediate {
+		return fmt.Errorf("mandatory and immediate publishing are not supported")
+	}
+	return c.publish(nil, exchange, key, msg)
+}
+
+// publish publishes msg, calling declare on the channel first unless it is nil. Messages without an
+// ID get one, which dead letters are found by.
+func (c *Client) publish(declare func(Channel) error, exchange string, key string, msg amqp.Publishing) error {
+	if msg.MessageId == "" {
+		msg.MessageId = newMessageId()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.PublishTimeout)
+	defer cancel()
+	for {
+		publishing, err := c.publishChannel(ctx.Done())
+		if errors.Is(err, ErrClientClosed) || errors.Is(err, errNotConnected) {
+			return err
+		}
+		if err == nil && declare != nil {
+			err = declare(publishing.ch)
+		}
+		var tag uint64
+		var confirmed <-chan amqp.Confirmation
+		if err == nil {
+			tag, confirmed, err = publishing.publish(exchange, key, msg)
+		}
+		if err == nil {
+			select {
+			case confirm, ok := <-confirmed:
+				if !ok {
+					err = fmt.Errorf("channel closed before the message was confirmed")
+				} else if !confirm.Ack {
+					return fmt.Errorf("broker rejected the message")
+				} else {
+					return nil
+				}
+			case <-ctx.Done():
+				publishing.forget(tag)
+				return ErrNotConfirmed
+			}
+		}
+
+		log.Printf("Failed to publish message, retrying: %v", err)
+		if publishing != nil {
+			c.dropPublishChannel(publishing)
+		}
+		select {
+		case <-time.After(c.config.MinBackoff):
+		case <-ctx.Done():
+			return fmt.Errorf("failed to publish message: %v", err)
+		case <-c.closed:
+			return ErrClientClosed
+		}
+	}
+}
+
+// publishChannel returns the confirm-mode channel, opening it when there is none
+func (c *Client) publishChannel(stop <-chan struct{}) (*confirmer, error) {
+	conn, err := c.connection(stop)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.publishing != nil {
+		return c.publishing, nil
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a channel: %v", err)
+	}
+	err = ch.Confirm(false)
+	if err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("failed to enable publisher confirms: %v", err)
+	}
+	c.publishing = newConfirmer(ch)
+	return c.publishing, nil
+}
+
+func (c *Client) dropPublishChannel(publishing *confirmer) {
+	c.mu.Lock()
+	if c.publishing == publishing {
+		c.publishing = nil
+	}
+	c.mu.Unlock()
+	publishing.ch.Close()
+}
+
+// confirmer hands the broker's confirmations on a confirm-mode channel to the publishes they belong
+// to, matched by delivery tag, so concurrent publishes each wait only for their own
+type confirmer struct {
+	ch Channel
+
+	// sendMu keeps tags in the order the channel numbers publishes in
+	sendMu sync.Mutex
+	next   uint64
+
+	mu      sync.Mutex
+	pending map[uint64]chan amqp.Confirmation
+	closed  bool
+}
+
+func newConfirmer(ch Channel) *confirmer {
+	c := &confirmer{
+		ch:      ch,
+		next:    1,
+		pending: make(map[uint64]chan amqp.Confirmation),
+	}
+	go c.run(ch.NotifyPublish(make(chan amqp.Confirmation, 1)))
+	return c
+}
+
+// run forwards each confirmation to its publish until the channel closes, then closes the
+// confirmations still waiting
+func (c *confirmer) run(confirms <-chan amqp.Confirmation) {
+	for confirm := range confirms {
+		c.mu.Lock()
+		confirmed, exists := c.pending[confirm.DeliveryTag]
+		delete(c.pending, confirm.DeliveryTag)
+		c.mu.Unlock()
+		if exists {
+			confirmed <- confirm
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	for tag, confirmed := range c.pending {
+		close(confirmed)
+		delete(c.pending, tag)
+	}
+}
+
+// publish publishes msg on the channel. It returns the message's delivery tag and where its
+// confirmation arrives, which is closed when the channel closes first.
+func (c *confirmer) publish(exchange string, key string, msg amqp.Publishing) (uint64, <-chan amqp.Confirmation, error) {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	tag := c.next
+	confirmed := make(chan amqp.Confirmation, 1)
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return 0, nil, fmt.Errorf("channel closed before the message was published")
+	}
+	c.pending[tag] = confirmed
+	c.mu.Unlock()
+
+	if err := c.ch.Publish(exchange, key, false, false, msg); err != nil {
+		c.forget(tag)
+		return 0, nil, err
+	}
+	c.next++
+	return tag, confirmed, nil
+}
+
+// forget stops waiting for the confirmation of tag
+func (c *confirmer) forget(tag uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, tag)
+}
+
+// declare runs declareOnce for name once per connection
+func (c *Client) declare(ch Channel, name string, declareOnce func() error) error {
+	c.mu.Lock()
+	declared := c.declared[name]
+	c.mu.Unlock()
+	if declared {
+		return nil
+	}
+	err := declareOnce()
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.declared[name] = true
+	c.mu.Unlock()
+	return nil
+}
+
+// Consume declares queueName, binds it to every binding's exchange and consumes it with manual
+// acks, holding at most prefetch unacknowledged deliveries. The returned channel keeps delivering
+// across reconnects; it is closed once the consumer is cancelled or the client closed. An empty tag
+// is replaced by a generated one.
+func (c *Client) Consume(queueName string, tag string, prefetch int, bindings ...Binding) (<-chan amqp.Delivery, error) {
+	return c.startConsumer(&consumer{
+		queue:    queueName,
+		tag:      tag,
+		prefetch: prefetch,
+		bindings: bindings,
+	})
+}
+
+// ConsumeTransient consumes queueName like Consume, but declares it as a queue that is not durable,
+// has no dead-letter queue and is deleted once the consumer is cancelled. It suits messages only
+// worth delivering while the consumer runs; messages published while it reconnects are lost.
+func (c *Client) ConsumeTransient(queueName string, tag string, bindings ...Binding) (<-chan amqp.Delivery, error) {
+	return c.startConsumer(&consumer{
+		queue:     queueName,
+		tag:       tag,
+		prefetch:  DefaultPrefetch,
+		bindings:  bindings,
+		transient: true,
+	})
+}
+
+// startConsumer registers the consumer under its tag and starts consuming in the background
+func (c *Client) startConsumer(consumer *consumer) (<-chan amqp.Delivery, error) {
+	if consumer.tag == "" {
+		consumer.tag = newMessageId()
+	}
+	tag := consumer.tag
+	consumer.deliveries = make(chan amqp.Delivery)
+	consumer.cancel = make(chan struct{})
+
+	c.mu.Lock()
+	select {
+	case <-c.closed:
+		c.mu.Unlock()
+		return nil, ErrClientClosed
+	default:
+	}
+	if _, exists := c.consumers[tag]; exists {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("consumer %s already exists", tag)
+	}
+	c.consumers[tag] = consumer
+	c.mu.Unlock()
+
+	go c.consume(consumer)
+	return consumer.deliveries, nil
+}
+
+// consume subscribes the consumer on a new channel whenever its previous one closed
+func (c *Client) consume(consumer *consumer) {
+	defer func() {
+		c.mu.Lock()
+		delete(c.consumers, consumer.tag)
+		c.mu.Unlock()
+		close(consumer.deliveries)
+	}()
+
+	backoff := c.config.MinBackoff
+	for {
+		conn, err := c.connection(consumer.cancel)
+		if err != nil {
+			return
+		}
+		ch, deliveries, err := c.subscribe(conn, consumer)
+		if err != nil {
+			log.Printf("Failed to consume queue %s, retrying in %v: %v", consumer.queue, backoff, err)
+			select {
+			case <-time.After(backoff):
+			case <-consumer.cancel:
+				return
+			case <-c.closed:
+				return
+			}
+			backoff = min(backoff*2, c.config.MaxBackoff)
+			continue
+		}
+		backoff = c.config.MinBackoff
+
+		// Deliveries close once the channel closes, or once a cancel completed
+		for delivery := range deliveries {
+			consumer.deliveries <- delivery
+		}
+		ch.Close()
+
+		select {
+		case <-consumer.cancel:
+			return
+		case <-c.closed:
+			return
+		default:
+			log.Printf("Consumer of queue %s lost its channel, subscribing again", consumer.queue)
+		}
+	}
+}
+
+// subscribe opens a channel for the consumer and starts consuming on it
+func (c *Client) subscribe(conn Connection, consumer *consumer) (Channel, <-chan amqp.Delivery, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open a channel: %v", err)
+	}
+	if consumer.transient {
+		err = declareTransientQueue(ch, consumer.queue)
+	} else {
+		err = DeclareQueue(ch, consumer.queue)
+	}
+	for _, binding := range consumer.bindings {
+		if err == nil {
+			err = bind(ch, consumer.queue, binding)
+		}
+	}
+	if err == nil {
+		err = ch.Qos(consumer.prefetch, 0, false)
+	}
+	var deliveries <-chan amqp.Delivery
+	if err == nil {
+		deliveries, err = ch.Consume(consumer.queue, consumer.tag, false, false, false, false, nil)
+	}
+	if err != nil {
+		ch.Close()
+		return nil, nil, err
+	}
+
+	consumer.mu.Lock()
+	consumer.channel = ch
+	consumer.mu.Unlock()
+
+	// A cancel that raced the subscription must still reach the new channel
+	select {
+	case <-consumer.cancel:
+		ch.Cancel(consumer.tag, false)
+	default:
+	}
+	return ch, deliveries, nil
+}
+
+// declareTransientQueue declares a queue that RabbitMQ deletes once its last consumer is gone
+func declareTransientQueue(ch Declarer, queue string) error {
+	_, err := ch.QueueDeclare(queue, false, true, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to declare queue %s: %v", queue, err)
+	}
+	return nil
+}
+
+// Cancel stops the consumer with the given tag. Deliveries already sent by the broker are still
+// handed out until the consumer's channel is closed, so callers should drain it.
+func (c *Client) Cancel(tag string) error {
+	c.mu.Lock()
+	consumer, exists := c.consumers[tag]
+	c.mu.Unlock()
+	if !exists {
+		return fmt.Errorf("consumer %s does not exist", tag)
+	}
+
+	consumer.cancelOnce.Do(func() { close(consumer.cancel) })
+	consumer.mu.Lock()
+	ch := consumer.channel
+	consumer.mu.Unlock()
+	if ch == nil {
+		return nil
+	}
+	err := ch.Cancel(tag, false)
+	if err != nil {
+		// The channel is gone, so its deliveries end anyway
+		ch.Close()
+	}
+	return nil
+}
+
+// channel opens a channel of its own on the current connection
+func (c *Client) channel() (Channel, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.PublishTimeout)
+	defer cancel()
+	conn, err := c.connection(ctx.Done())
+	if err != nil {
+		return nil, err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a channel: %v", err)
+	}
+	return ch, nil
+}
+
+// ListDeadLetters returns up to limit dead letters of queueName, oldest first, leaving them in place
+func (c *Client) ListDeadLetters(queueName string, limit int) ([]DeadLetter, error) {
+	ch, err := c.channel()
+	if err != nil {
+		return nil, err
+	}
+	defer ch.Close()
+	return listDeadLetters(ch, queueName, limit)
+}
+
+// GetDeadLetter finds the dead letter of queueName with the given ID
+func (c *Client) GetDeadLetter(queueName string, id string) (DeadLetter, bool, error) {
+	ch, err := c.channel()
+	if err != nil {
+		return DeadLetter{}, false, err
+	}
+	defer ch.Close()
+	return getDeadLetter(ch, queueName, id)
+}
+
+// ReplayDeadLetter moves the dead letter with the given ID back to queueName with its retry counter
+// reset. The dead letter is only removed once the broker confirmed the replayed message.
+func (c *Client) ReplayDeadLetter(queueName string, id string) (bool, error) {
+	ch, err := c.channel()
+	if err != nil {
+		return false, err
+	}
+	defer ch.Close()
+	return replayDeadLetter(ch, c, queueName, id)
+}
+
+// Close stops reconnecting, ends every consumer and closes the connection
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn == nil {
+		return nil
+	}
+	return conn.Close()
+}
@@ -0,0 +1,265 @@
+package broker
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+const testQueue = "submissions"
+
+// newTestClient returns a client of a new MemoryBroker that reconnects quickly
+func newTestClient(t *testing.T) (*Client, *MemoryBroker) {
+	t.Helper()
+	memory := NewMemoryBroker()
+	client := NewClientWith("amqp://memory", Config{
+		Dial:           memory.Dial,
+		MinBackoff:     5 * time.Millisecond,
+		MaxBackoff:     20 * time.Millisecond,
+		PublishTimeout: 2 * time.Second,
+	})
+	t.Cleanup(func() { client.Close() })
+	return client, memory
+}
+
+// receive waits for the next delivery
+func receive(t *testing.T, deliveries <-chan amqp.Delivery) amqp.Delivery {
+	t.Helper()
+	select {
+	case delivery, ok := <-deliveries:
+		if !ok {
+			t.Fatal("deliveries closed")
+		}
+		return delivery
+	case <-time.After(2 * time.Second):
+		t.Fatal("no delivery arrived")
+	}
+	return amqp.Delivery{}
+}
+
+func TestClientReconnects(t *testing.T) {
+	client, memory := newTestClient(t)
+	deliveries, err := client.Consume(testQueue, "", DefaultPrefetch)
+	if err != nil {
+		t.Fatalf("Consume() error = %v", err)
+	}
+	if err := client.SendMessage(testQueue, []byte("before")); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if err := receive(t, deliveries).Ack(false); err != nil {
+		t.Fatalf("Ack() error = %v", err)
+	}
+
+	// A publish waits while the broker restarts and goes through once it is back
+	memory.SetAvailable(false)
+	memory.Drop()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		memory.SetAvailable(true)
+	}()
+	if err := client.SendMessage(testQueue, []byte("after")); err != nil {
+		t.Fatalf("SendMessage() across a restart error = %v", err)
+	}
+
+	// The consumer subscribed again and receives it on its same channel
+	delivery := receive(t, deliveries)
+	if string(delivery.Body) != "after" {
+		t.Errorf("received %q, want %q", delivery.Body, "after")
+	}
+	if delivery.MessageId == "" {
+		t.Error("published message has no message ID")
+	}
+}
+
+func TestClientRedeliversUnacked(t *testing.T) {
+	client, memory := newTestClient(t)
+	deliveries, err := client.Consume(testQueue, "", DefaultPrefetch)
+	if err != nil {
+		t.Fatalf("Consume() error = %v", err)
+	}
+	if err := client.SendMessage(testQueue, []byte("payload")); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	first := receive(t, deliveries)
+
+	// A delivery left unacked when the connection drops is delivered again
+	memory.Drop()
+	if err := first.Ack(false); err == nil {
+		t.Error("Ack() on a closed channel succeeded")
+	}
+	again := receive(t, deliveries)
+	if string(again.Body) != "payload" || !again.Redelivered {
+		t.Fatalf("received %q redelivered %v, want the payload redelivered", again.Body, again.Redelivered)
+	}
+	if again.MessageId != first.MessageId {
+		t.Errorf("message ID changed from %q to %q", first.MessageId, again.MessageId)
+	}
+	if err := again.Ack(false); err != nil {
+		t.Fatalf("Ack() error = %v", err)
+	}
+	if ready := memory.Ready(testQueue); ready != 0 {
+		t.Errorf("%d messages left in the queue, want none", ready)
+	}
+}
+
+func TestClientSettles(t *testing.T) {
+	client, memory := newTestClient(t)
+	deliveries, err := client.Consume(testQueue, "", DefaultPrefetch)
+	if err != nil {
+		t.Fatalf("Consume() error = %v", err)
+	}
+	if err := client.SendMessage(testQueue, []byte("payload")); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	// A transient failure requeues a copy with its retry counted
+	first := receive(t, deliveries)
+	if err := Settle(client, first, errors.New("redis unavailable"), DefaultMaxRetries); err != nil {
+		t.Fatalf("Settle() error = %v", err)
+	}
+	retried := receive(t, deliveries)
+	if RetryCount(retried) != 1 || retried.MessageId != first.MessageId {
+		t.Fatalf("retried message has %d retries and ID %q, want 1 and %q", RetryCount(retried), retried.MessageId, first.MessageId)
+	}
+
+	// A permanent failure moves it to the dead-letter queue, where it is found by its ID
+	if err := Settle(client, retried, Permanent(errors.New("malformed payload")), DefaultMaxRetries); err != nil {
+		t.Fatalf("Settle() error = %v", err)
+	}
+	deadLetter, found, err := client.GetDeadLetter(testQueue, first.MessageId)
+	if err != nil || !found {
+		t.Fatalf("GetDeadLetter() = %v, %v; want the dead letter", found, err)
+	}
+	if deadLetter.Reason != "malformed payload" || deadLetter.Queue != testQueue || deadLetter.Retries != 1 {
+		t.Errorf("dead letter = %+v", deadLetter)
+	}
+
+	// Replaying returns it to the queue with its retries reset
+	replayed, err := client.ReplayDeadLetter(testQueue, first.MessageId)
+	if err != nil || !replayed {
+		t.Fatalf("ReplayDeadLetter() = %v, %v; want it replayed", replayed, err)
+	}
+	delivery := receive(t, deliveries)
+	if RetryCount(delivery) != 0 || string(delivery.Body) != "payload" {
+		t.Errorf("replayed message has %d retries and body %q", RetryCount(delivery), delivery.Body)
+	}
+	if ready := memory.Ready(DeadLetterQueue(testQueue)); ready != 0 {
+		t.Errorf("%d dead letters left, want none", ready)
+	}
+}
+
+func TestClientDeadLettersNackedMessagesByPolicy(t *testing.T) {
+	client, memory := newTestClient(t)
+	memory.SetDeadLetterPolicy(DeadLetterExchange)
+	deliveries, err := client.Consume(testQueue, "", DefaultPrefetch)
+	if err != nil {
+		t.Fatalf("Consume() error = %v", err)
+	}
+	if err := client.SendMessage(testQueue, []byte("payload")); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	delivery := receive(t, deliveries)
+	if err := delivery.Nack(false, false); err != nil {
+		t.Fatalf("Nack() error = %v", err)
+	}
+
+	deadLetters, err := client.ListDeadLetters(testQueue, 10)
+	if err != nil {
+		t.Fatalf("ListDeadLetters() error = %v", err)
+	}
+	if len(deadLetters) != 1 || deadLetters[0].Id != delivery.MessageId || deadLetters[0].Reason != "rejected" {
+		t.Fatalf("dead letters = %+v, want the rejected message", deadLetters)
+	}
+}
+
+func TestClientConfirmsConcurrentPublishes(t *testing.T) {
+	client, memory := newTestClient(t)
+	if err := client.SendMessage(testQueue, []byte("declare")); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	const publishes = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, publishes)
+	for i := 0; i < publishes; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- client.Publish("", testQueue, false, false, amqp.Publishing{Body: []byte(fmt.Sprint(i))})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+	if ready := memory.Ready(testQueue); ready != publishes+1 {
+		t.Errorf("%d messages in the queue, want %d", ready, publishes+1)
+	}
+}
+
+// confirmChannel publishes nowhere and confirms only when told to
+type confirmChannel struct {
+	Channel
+	confirms chan amqp.Confirmation
+}
+
+func (ch *confirmChannel) Publish(exchange string, key string, mandatory bool, immediate bool, msg amqp.Publishing) error {
+	return nil
+}
+
+func (ch *confirmChannel) NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation {
+	go func() {
+		for confirmation := range ch.confirms {
+			confirm <- confirmation
+		}
+		close(confirm)
+	}()
+	return confirm
+}
+
+func TestConfirmerMatchesConfirmationsByTag(t *testing.T) {
+	ch := &confirmChannel{confirms: make(chan amqp.Confirmation)}
+	publishing := newConfirmer(ch)
+
+	firstTag, first, err := publishing.publish("", testQueue, amqp.Publishing{})
+	if err != nil {
+		t.Fatalf("publish() error = %v", err)
+	}
+	secondTag, second, err := publishing.publish("", testQueue, amqp.Publishing{})
+	if err != nil {
+		t.Fatalf("publish() error = %v", err)
+	}
+	if firstTag != 1 || secondTag != 2 {
+		t.Fatalf("tags = %d, %d; want 1, 2", firstTag, secondTag)
+	}
+
+	// The second is confirmed first and the first rejected; each publish sees its own
+	ch.confirms <- amqp.Confirmation{DeliveryTag: 2, Ack: true}
+	ch.confirms <- amqp.Confirmation{DeliveryTag: 1, Ack: false}
+	if confirm := <-second; !confirm.Ack || confirm.DeliveryTag != 2 {
+		t.Errorf("second publish got %+v", confirm)
+	}
+	if confirm := <-first; confirm.Ack || confirm.DeliveryTag != 1 {
+		t.Errorf("first publish got %+v", confirm)
+	}
+
+	// Publishes still waiting when the channel closes see their confirmation closed
+	_, third, err := publishing.publish("", testQueue, amqp.Publishing{})
+	if err != nil {
+		t.Fatalf("publish() error = %v", err)
+	}
+	close(ch.confirms)
+	if _, ok := <-third; ok {
+		t.Error("confirmation arrived for a publish on a closed channel")
+	}
+	if _, _, err := publishing.publish("", testQueue, amqp.Publishing{}); err == nil {
+		t.Error("publish() on a closed channel succeeded")
+	}
+}
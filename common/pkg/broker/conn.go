@@ -0,0 +1,54 @@
+package broker
+
+import (
+	"github.com/streadway/amqp"
+)
+
+// Connection is the part of *amqp.Connection the Client uses, so it can also run on MemoryBroker
+type Connection interface {
+	Channel() (Channel, error)
+	NotifyClose(receiver chan *amqp.Error) chan *amqp.Error
+	Close() error
+}
+
+// Channel is the part of *amqp.Channel the Client uses
+type Channel interface {
+	Declarer
+	Publisher
+	Confirm(noWait bool) error
+	NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation
+	NotifyClose(receiver chan *amqp.Error) chan *amqp.Error
+	Qos(prefetchCount int, prefetchSize int, global bool) error
+	Consume(queue string, consumer string, autoAck bool, exclusive bool, noLocal bool, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error)
+	Cancel(consumer string, noWait bool) error
+	Get(queue string, autoAck bool) (amqp.Delivery, bool, error)
+	Close() error
+}
+
+// Declarer declares queues and exchanges, as *amqp.Channel does
+type Declarer interface {
+	ExchangeDeclare(name string, kind string, durable bool, autoDelete bool, internal bool, noWait bool, args amqp.Table) error
+	QueueDeclare(name string, durable bool, autoDelete bool, exclusive bool, noWait bool, args amqp.Table) (amqp.Queue, error)
+	QueueBind(name string, key string, exchange string, noWait bool, args amqp.Table) error
+}
+
+// Dialer opens a connection to the broker at url
+type Dialer func(url string) (Connection, error)
+
+// DialAMQP connects to RabbitMQ
+func DialAMQP(url string) (Connection, error) {
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+	return amqpConnection{conn}, nil
+}
+
+// amqpConnection adapts *amqp.Connection, whose Channel returns the concrete *amqp.Channel
+type amqpConnection struct {
+	*amqp.Connection
+}
+
+func (c amqpConnection) Channel() (Channel, error) {
+	return c.Connection.Channel()
+}
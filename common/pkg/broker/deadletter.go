@@ -0,0 +1,187 @@
+package broker
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	// DeadLetterExchange receives the messages consumers give up on, routed by their original queue
+	DeadLetterExchange = "dead-letters"
+	// DeadLetterSuffix names a queue's dead-letter queue, such as submissions.dead
+	DeadLetterSuffix = ".dead"
+
+	// Headers Settle adds to dead-lettered messages
+	ReasonHeader   = "x-failure-reason"
+	FailedAtHeader = "x-failed-at"
+	QueueHeader    = "x-original-queue"
+
+	// maxDeadLetterScan bounds how many dead letters are read to find one by ID
+	maxDeadLetterScan = 1000
+)
+
+// DeadLetter is a message that was rejected, with why
+type DeadLetter struct {
+	Id       string    `json:"id"`
+	Queue    string    `json:"queue"`
+	Reason   string    `json:"reason"`
+	FailedAt time.Time `json:"failed_at"`
+	Retries  int       `json:"retries"`
+	Body     []byte    `json:"-"`
+}
+
+// DeadLetterQueue returns the name of the queue holding queue's dead letters
+func DeadLetterQueue(queue string) string {
+	return queue + DeadLetterSuffix
+}
+
+// DeclareQueue declares a durable queue and its dead-letter queue. The queue itself is declared
+// without arguments, as earlier versions declared it, since RabbitMQ refuses to redeclare an existing
+// queue with other arguments. Messages nacked without requeue reach the dead-letter queue only through
+// a RabbitMQ policy setting dead-letter-exchange, which also applies to queues that already exist.
+func DeclareQueue(ch Declarer, queue string) error {
+	err := ch.ExchangeDeclare(DeadLetterExchange, "direct", true, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to declare exchange %s: %v", DeadLetterExchange, err)
+	}
+	deadLetterQueue := DeadLetterQueue(queue)
+	_, err = ch.QueueDeclare(deadLetterQueue, true, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to declare queue %s: %v", deadLetterQueue, err)
+	}
+	err = ch.QueueBind(deadLetterQueue, queue, DeadLetterExchange, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to bind queue %s: %v", deadLetterQueue, err)
+	}
+	_, err = ch.QueueDeclare(queue, true, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to declare queue %s: %v", queue, err)
+	}
+	return nil
+}
+
+// deadLetter publishes the delivery to the dead-letter exchange with reason and acks it. When that
+// fails the delivery is rejected, which the queue's dead-letter exchange still catches.
+func deadLetter(publisher Publisher, delivery amqp.Delivery, reason error) error {
+	publishing := retryPublishing(delivery, RetryCount(delivery))
+	publishing.Headers[ReasonHeader] = reason.Error()
+	publishing.Headers[FailedAtHeader] = time.Now().UTC().Format(time.RFC3339)
+	publishing.Headers[QueueHeader] = delivery.RoutingKey
+	publishing.MessageId = deadLetterId(delivery)
+
+	err := publisher.Publish(DeadLetterExchange, delivery.RoutingKey, false, false, publishing)
+	if err != nil {
+		return delivery.Nack(false, false)
+	}
+	return delivery.Ack(false)
+}
+
+// listDeadLetters returns up to limit dead letters of queue, oldest first, leaving them in place
+func listDeadLetters(ch Channel, queue string, limit int) ([]DeadLetter, error) {
+	var deadLetters []DeadLetter
+	err := scanDeadLetters(ch, queue, limit, func(delivery amqp.Delivery) (bool, error) {
+		deadLetters = append(deadLetters, toDeadLetter(delivery))
+		return false, nil
+	})
+	return deadLetters, err
+}
+
+// getDeadLetter finds the dead letter of queue with the given ID
+func getDeadLetter(ch Channel, queue string, id string) (DeadLetter, bool, error) {
+	var found DeadLetter
+	var exists bool
+	err := scanDeadLetters(ch, queue, maxDeadLetterScan, func(delivery amqp.Delivery) (bool, error) {
+		if deadLetterId(delivery) != id {
+			return false, nil
+		}
+		found, exists = toDeadLetter(delivery), true
+		return true, nil
+	})
+	return found, exists, err
+}
+
+// replayDeadLetter publishes the dead letter with the given ID to queue with its retry counter reset,
+// then acks it
+func replayDeadLetter(ch Channel, publisher Publisher, queue string, id string) (bool, error) {
+	var replayed bool
+	err := scanDeadLetters(ch, queue, maxDeadLetterScan, func(delivery amqp.Delivery) (bool, error) {
+		if deadLetterId(delivery) != id {
+			return false, nil
+		}
+		publishing := retryPublishing(delivery, 0)
+		delete(publishing.Headers, RetryHeader)
+		delete(publishing.Headers, ReasonHeader)
+		delete(publishing.Headers, FailedAtHeader)
+		delete(publishing.Headers, QueueHeader)
+		if err := publisher.Publish("", queue, false, false, publishing); err != nil {
+			return true, fmt.Errorf("failed to replay dead letter %s: %v", id, err)
+		}
+		replayed = true
+		return true, delivery.Ack(false)
+	})
+	return replayed, err
+}
+
+// scanDeadLetters reads up to limit dead letters of queue on ch, which the caller opened for the
+// scan, handing each to visit until it returns true. Closing ch afterwards returns every unacked
+// dead letter to its place.
+func scanDeadLetters(ch Channel, queue string, limit int, visit func(amqp.Delivery) (bool, error)) error {
+	deadLetterQueue := DeadLetterQueue(queue)
+	for i := 0; i < limit; i++ {
+		delivery, ok, err := ch.Get(deadLetterQueue, false)
+		if err != nil {
+			return fmt.Errorf("failed to read queue %s: %v", deadLetterQueue, err)
+		}
+		if !ok {
+			return nil
+		}
+		done, err := visit(delivery)
+		if done || err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toDeadLetter(delivery amqp.Delivery) DeadLetter {
+	deadLetter := DeadLetter{
+		Id:      deadLetterId(delivery),
+		Queue:   delivery.RoutingKey,
+		Retries: RetryCount(delivery),
+		Body:    delivery.Body,
+	}
+	if queue, ok := delivery.Headers[QueueHeader].(string); ok {
+		deadLetter.Queue = queue
+	}
+	if reason, ok := delivery.Headers[ReasonHeader].(string); ok {
+		deadLetter.Reason = reason
+	} else {
+		// Rejected by a nack, so RabbitMQ's own x-death header is all there is
+		deadLetter.Reason = "rejected"
+	}
+	if failedAt, ok := delivery.Headers[FailedAtHeader].(string); ok {
+		deadLetter.FailedAt, _ = time.Parse(time.RFC3339, failedAt)
+	}
+	return deadLetter
+}
+
+// deadLetterId returns the ID a dead letter is found by: its message ID, or for messages published
+// without one, such as by earlier versions, a digest of its body
+func deadLetterId(delivery amqp.Delivery) string {
+	if delivery.MessageId != "" {
+		return delivery.MessageId
+	}
+	digest := sha256.Sum256(delivery.Body)
+	return hex.EncodeToString(digest[:16])
+}
+
+func newMessageId() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,32 @@
+package broker
+
+import "fmt"
+
+// Binding routes the messages published to Exchange with the routing key Key to a consumed queue
+type Binding struct {
+	Exchange string
+	Key      string
+}
+
+// DeclareExchange declares a durable direct exchange. Publishing to an exchange nobody declared
+// closes the channel, so publishers and consumers both declare it.
+func DeclareExchange(ch Declarer, exchange string) error {
+	err := ch.ExchangeDeclare(exchange, "direct", true, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to declare exchange %s: %v", exchange, err)
+	}
+	return nil
+}
+
+// bind declares the binding's exchange and binds queue to it
+func bind(ch Declarer, queue string, binding Binding) error {
+	err := DeclareExchange(ch, binding.Exchange)
+	if err != nil {
+		return err
+	}
+	err = ch.QueueBind(queue, binding.Key, binding.Exchange, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to bind queue %s to exchange %s: %v", queue, binding.Exchange, err)
+	}
+	return nil
+}
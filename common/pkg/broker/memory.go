@@ -0,0 +1,673 @@
+package broker
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/streadway/amqp"
+)
+
+// memoryBuffer bounds the deliveries a MemoryBroker consumer holds without prefetch limit
+const memoryBuffer = 1024
+
+var errUnavailable = errors.New("broker is unavailable")
+
+// MemoryBroker is an in-memory stand-in for RabbitMQ, to exercise a Client without a broker. It keeps
+// the semantics the services rely on: durable queues with a dead-letter exchange set by their
+// arguments or by SetDeadLetterPolicy, queues deleted with their last consumer, the default and
+// direct exchanges, prefetch, manual acks with requeue, publisher confirms and close notifications.
+// Its Dial is a Dialer, and Drop and SetAvailable simulate broker restarts.
+type MemoryBroker struct {
+	mu          sync.Mutex
+	available   bool
+	queues      map[string]*memoryQueue
+	exchanges   map[string]string
+	bindings    map[string]map[string][]string
+	connections map[*memoryConnection]bool
+
+	deadLetterPolicy string
+}
+
+type memoryQueue struct {
+	name       string
+	args       amqp.Table
+	autoDelete bool
+	ready      []amqp.Delivery
+	consumers  []*memoryConsumer
+	next       int
+}
+
+type memoryConnection struct {
+	broker   *MemoryBroker
+	closed   bool
+	notify   []chan *amqp.Error
+	channels map[*memoryChannel]bool
+}
+
+type memoryChannel struct {
+	conn     *memoryConnection
+	closed   bool
+	notify   []chan *amqp.Error
+	prefetch int
+
+	confirming  bool
+	confirms    []chan amqp.Confirmation
+	publishTag  uint64
+	deliveryTag uint64
+	unacked     map[uint64]unackedDelivery
+	consumers   map[string]*memoryConsumer
+}
+
+type unackedDelivery struct {
+	queue    *memoryQueue
+	delivery amqp.Delivery
+}
+
+type memoryConsumer struct {
+	tag        string
+	queue      *memoryQueue
+	channel    *memoryChannel
+	deliveries chan amqp.Delivery
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		available:   true,
+		queues:      make(map[string]*memoryQueue),
+		exchanges:   make(map[string]string),
+		bindings:    make(map[string]map[string][]string),
+		connections: make(map[*memoryConnection]bool),
+	}
+}
+
+// Dial opens a connection to the broker; url is ignored
+func (b *MemoryBroker) Dial(url string) (Connection, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.available {
+		return nil, errUnavailable
+	}
+	conn := &memoryConnection{broker: b, channels: make(map[*memoryChannel]bool)}
+	b.connections[conn] = true
+	return conn, nil
+}
+
+// SetAvailable makes Dial fail while available is false
+func (b *MemoryBroker) SetAvailable(available bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.available = available
+}
+
+// SetDeadLetterPolicy sets the dead-letter exchange of every queue declared without one, as a
+// RabbitMQ policy matching all queues would
+func (b *MemoryBroker) SetDeadLetterPolicy(exchange string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.deadLetterPolicy = exchange
+}
+
+// Drop closes every connection as a broker restart would. Unacked deliveries return to their queues.
+func (b *MemoryBroker) Drop() {
+	b.mu.Lock()
+	var notify []chan *amqp.Error
+	for conn := range b.connections {
+		notify = append(notify, conn.close(amqp.ErrClosed)...)
+	}
+	b.mu.Unlock()
+	closeNotify(notify, amqp.ErrClosed)
+}
+
+// Consumers returns how many consumers queue has, so a test can wait until a Client subscribed
+func (b *MemoryBroker) Consumers(queue string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if q, exists := b.queues[queue]; exists {
+		return len(q.consumers)
+	}
+	return 0
+}
+
+// Bound reports whether a queue is bound to exchange with the routing key key
+func (b *MemoryBroker) Bound(exchange string, key string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.bindings[exchange][key]) > 0
+}
+
+// Ready returns how many messages wait in queue for a consumer
+func (b *MemoryBroker) Ready(queue string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if q, exists := b.queues[queue]; exists {
+		return len(q.ready)
+	}
+	return 0
+}
+
+// Messages returns the bodies of the messages waiting in queue, in order
+func (b *MemoryBroker) Messages(queue string) [][]byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var bodies [][]byte
+	if q, exists := b.queues[queue]; exists {
+		for _, delivery := range q.ready {
+			bodies = append(bodies, delivery.Body)
+		}
+	}
+	return bodies
+}
+
+// route delivers a published message to the queues bound to exchange with key
+func (b *MemoryBroker) route(exchange string, key string, msg amqp.Publishing) error {
+	var targets []string
+	if exchange == "" {
+		targets = []string{key}
+	} else {
+		if _, exists := b.exchanges[exchange]; !exists {
+			return &amqp.Error{Code: amqp.NotFound, Reason: fmt.Sprintf("NOT_FOUND - no exchange '%s'", exchange)}
+		}
+		targets = b.bindings[exchange][key]
+	}
+	for _, name := range targets {
+		q, exists := b.queues[name]
+		if !exists {
+			continue
+		}
+		q.ready = append(q.ready, amqp.Delivery{
+			Headers:         msg.Headers,
+			ContentType:     msg.ContentType,
+			ContentEncoding: msg.ContentEncoding,
+			DeliveryMode:    msg.DeliveryMode,
+			Priority:        msg.Priority,
+			CorrelationId:   msg.CorrelationId,
+			ReplyTo:         msg.ReplyTo,
+			Expiration:      msg.Expiration,
+			MessageId:       msg.MessageId,
+			Timestamp:       msg.Timestamp,
+			Type:            msg.Type,
+			AppId:           msg.AppId,
+			Exchange:        exchange,
+			RoutingKey:      key,
+			Body:            msg.Body,
+		})
+	}
+	return nil
+}
+
+// deadLetter routes a rejected delivery through its queue's dead-letter exchange, if it has one
+func (b *MemoryBroker) deadLetter(q *memoryQueue, delivery amqp.Delivery) {
+	exchange, ok := q.args["x-dead-letter-exchange"].(string)
+	if !ok {
+		exchange = b.deadLetterPolicy
+	}
+	if exchange == "" {
+		return
+	}
+	publishing := retryPublishing(delivery, RetryCount(delivery))
+	if RetryCount(delivery) == 0 {
+		delete(publishing.Headers, RetryHeader)
+	}
+	_ = b.route(exchange, delivery.RoutingKey, publishing)
+}
+
+// dispatch hands ready messages to consumers with room under their channel's prefetch
+func (b *MemoryBroker) dispatch() {
+	for _, q := range b.queues {
+		for len(q.ready) > 0 {
+			consumer := q.nextConsumer()
+			if consumer == nil {
+				break
+			}
+			delivery := q.ready[0]
+			q.ready = q.ready[1:]
+			delivery.ConsumerTag = consumer.tag
+			consumer.channel.track(q, &delivery)
+			consumer.deliveries <- delivery
+		}
+	}
+}
+
+// nextConsumer picks the next consumer in turn that can take a delivery
+func (q *memoryQueue) nextConsumer() *memoryConsumer {
+	for i := 0; i < len(q.consumers); i++ {
+		consumer := q.consumers[(q.next+i)%len(q.consumers)]
+		ch := consumer.channel
+		if ch.prefetch > 0 && len(ch.unacked) >= ch.prefetch {
+			continue
+		}
+		if len(consumer.deliveries) == cap(consumer.deliveries) {
+			continue
+		}
+		q.next = (q.next + i + 1) % len(q.consumers)
+		return consumer
+	}
+	return nil
+}
+
+// requeue returns deliveries to the front of their queue, marked as redelivered
+func requeue(unacked []unackedDelivery) {
+	for i := len(unacked) - 1; i >= 0; i-- {
+		delivery := unacked[i].delivery
+		delivery.Redelivered = true
+		delivery.Acknowledger = nil
+		delivery.DeliveryTag = 0
+		delivery.ConsumerTag = ""
+		q := unacked[i].queue
+		q.ready = append([]amqp.Delivery{delivery}, q.ready...)
+	}
+}
+
+func closeNotify(receivers []chan *amqp.Error, err *amqp.Error) {
+	for _, receiver := range receivers {
+		if err != nil {
+			receiver <- err
+		}
+		close(receiver)
+	}
+}
+
+func (c *memoryConnection) Channel() (Channel, error) {
+	b := c.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c.closed {
+		return nil, amqp.ErrClosed
+	}
+	ch := &memoryChannel{
+		conn:      c,
+		unacked:   make(map[uint64]unackedDelivery),
+		consumers: make(map[string]*memoryConsumer),
+	}
+	c.channels[ch] = true
+	return ch, nil
+}
+
+func (c *memoryConnection) NotifyClose(receiver chan *amqp.Error) chan *amqp.Error {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	if c.closed {
+		close(receiver)
+		return receiver
+	}
+	c.notify = append(c.notify, receiver)
+	return receiver
+}
+
+func (c *memoryConnection) Close() error {
+	c.broker.mu.Lock()
+	if c.closed {
+		c.broker.mu.Unlock()
+		return amqp.ErrClosed
+	}
+	notify := c.close(nil)
+	c.broker.mu.Unlock()
+	closeNotify(notify, nil)
+	return nil
+}
+
+// close closes the connection and its channels with the broker locked, returning the receivers to notify
+func (c *memoryConnection) close(err *amqp.Error) []chan *amqp.Error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	delete(c.broker.connections, c)
+	var notify []chan *amqp.Error
+	for ch := range c.channels {
+		notify = append(notify, ch.close()...)
+	}
+	notify = append(notify, c.notify...)
+	c.notify = nil
+	c.broker.dispatch()
+	return notify
+}
+
+// fail closes the channel with err, as RabbitMQ does on a channel exception
+func (ch *memoryChannel) fail(err *amqp.Error) error {
+	notify := ch.close()
+	ch.conn.broker.dispatch()
+	ch.conn.broker.mu.Unlock()
+	closeNotify(notify, err)
+	ch.conn.broker.mu.Lock()
+	return err
+}
+
+// close closes the channel with the broker locked: its consumers end and unacked deliveries are
+// requeued. It returns the receivers to notify.
+func (ch *memoryChannel) close() []chan *amqp.Error {
+	if ch.closed {
+		return nil
+	}
+	ch.closed = true
+	delete(ch.conn.channels, ch)
+	for _, consumer := range ch.consumers {
+		consumer.remove()
+	}
+	ch.consumers = nil
+
+	pending := make([]unackedDelivery, 0, len(ch.unacked))
+	for tag := uint64(1); tag <= ch.deliveryTag; tag++ {
+		if unacked, exists := ch.unacked[tag]; exists {
+			pending = append(pending, unacked)
+		}
+	}
+	requeue(pending)
+	ch.unacked = nil
+
+	for _, confirm := range ch.confirms {
+		close(confirm)
+	}
+	ch.confirms = nil
+	notify := ch.notify
+	ch.notify = nil
+	return notify
+}
+
+// remove ends the consumer with the broker locked. An auto-deleted queue goes away with its last
+// consumer, and so do its bindings.
+func (consumer *memoryConsumer) remove() {
+	q := consumer.queue
+	for i, other := range q.consumers {
+		if other == consumer {
+			q.consumers = append(q.consumers[:i], q.consumers[i+1:]...)
+			break
+		}
+	}
+	close(consumer.deliveries)
+	if q.autoDelete && len(q.consumers) == 0 {
+		consumer.channel.conn.broker.deleteQueue(q)
+	}
+}
+
+// deleteQueue removes q and its bindings with the broker locked
+func (b *MemoryBroker) deleteQueue(q *memoryQueue) {
+	if b.queues[q.name] != q {
+		return
+	}
+	delete(b.queues, q.name)
+	for _, keys := range b.bindings {
+		for key, queues := range keys {
+			kept := queues[:0]
+			for _, bound := range queues {
+				if bound != q.name {
+					kept = append(kept, bound)
+				}
+			}
+			keys[key] = kept
+		}
+	}
+}
+
+// track assigns the delivery a tag on the channel and records it as unacked
+func (ch *memoryChannel) track(q *memoryQueue, delivery *amqp.Delivery) {
+	ch.deliveryTag++
+	delivery.DeliveryTag = ch.deliveryTag
+	delivery.Acknowledger = ch
+	ch.unacked[ch.deliveryTag] = unackedDelivery{queue: q, delivery: *delivery}
+}
+
+// lock locks the broker and reports whether the channel is still open
+func (ch *memoryChannel) lock() bool {
+	ch.conn.broker.mu.Lock()
+	if ch.closed {
+		ch.conn.broker.mu.Unlock()
+		return false
+	}
+	return true
+}
+
+func (ch *memoryChannel) unlock() {
+	ch.conn.broker.mu.Unlock()
+}
+
+func (ch *memoryChannel) ExchangeDeclare(name string, kind string, durable bool, autoDelete bool, internal bool, noWait bool, args amqp.Table) error {
+	if !ch.lock() {
+		return amqp.ErrClosed
+	}
+	defer ch.unlock()
+	b := ch.conn.broker
+	if existing, exists := b.exchanges[name]; exists && existing != kind {
+		return ch.fail(&amqp.Error{Code: amqp.PreconditionFailed, Reason: fmt.Sprintf("PRECONDITION_FAILED - inequivalent arg 'type' for exchange '%s'", name)})
+	}
+	if kind != "direct" {
+		return ch.fail(&amqp.Error{Code: amqp.NotImplemented, Reason: fmt.Sprintf("exchange type %s is not supported", kind)})
+	}
+	b.exchanges[name] = kind
+	return nil
+}
+
+func (ch *memoryChannel) QueueDeclare(name string, durable bool, autoDelete bool, exclusive bool, noWait bool, args amqp.Table) (amqp.Queue, error) {
+	if !ch.lock() {
+		return amqp.Queue{}, amqp.ErrClosed
+	}
+	defer ch.unlock()
+	b := ch.conn.broker
+	q, exists := b.queues[name]
+	if !exists {
+		q = &memoryQueue{name: name, args: args, autoDelete: autoDelete}
+		b.queues[name] = q
+	} else if !reflect.DeepEqual(normalizeArgs(q.args), normalizeArgs(args)) {
+		// RabbitMQ refuses to redeclare a queue with other arguments and closes the channel
+		return amqp.Queue{}, ch.fail(&amqp.Error{Code: amqp.PreconditionFailed, Reason: fmt.Sprintf("PRECONDITION_FAILED - inequivalent args for queue '%s'", name)})
+	}
+	return amqp.Queue{Name: name, Messages: len(q.ready), Consumers: len(q.consumers)}, nil
+}
+
+func normalizeArgs(args amqp.Table) amqp.Table {
+	if len(args) == 0 {
+		return nil
+	}
+	return args
+}
+
+func (ch *memoryChannel) QueueBind(name string, key string, exchange string, noWait bool, args amqp.Table) error {
+	if !ch.lock() {
+		return amqp.ErrClosed
+	}
+	defer ch.unlock()
+	b := ch.conn.broker
+	if _, exists := b.exchanges[exchange]; !exists {
+		return ch.fail(&amqp.Error{Code: amqp.NotFound, Reason: fmt.Sprintf("NOT_FOUND - no exchange '%s'", exchange)})
+	}
+	if _, exists := b.queues[name]; !exists {
+		return ch.fail(&amqp.Error{Code: amqp.NotFound, Reason: fmt.Sprintf("NOT_FOUND - no queue '%s'", name)})
+	}
+	if b.bindings[exchange] == nil {
+		b.bindings[exchange] = make(map[string][]string)
+	}
+	for _, bound := range b.bindings[exchange][key] {
+		if bound == name {
+			return nil
+		}
+	}
+	b.bindings[exchange][key] = append(b.bindings[exchange][key], name)
+	return nil
+}
+
+func (ch *memoryChannel) Publish(exchange string, key string, mandatory bool, immediate bool, msg amqp.Publishing) error {
+	if !ch.lock() {
+		return amqp.ErrClosed
+	}
+	b := ch.conn.broker
+	if err := b.route(exchange, key, msg); err != nil {
+		err = ch.fail(err.(*amqp.Error))
+		ch.unlock()
+		return err
+	}
+	b.dispatch()
+	defer ch.unlock()
+	if ch.confirming {
+		// Confirmations are sent locked, so the channel cannot close them meanwhile; like the amqp
+		// library's, their receivers must not wait for the broker
+		ch.publishTag++
+		for _, confirm := range ch.confirms {
+			confirm <- amqp.Confirmation{DeliveryTag: ch.publishTag, Ack: true}
+		}
+	}
+	return nil
+}
+
+func (ch *memoryChannel) Confirm(noWait bool) error {
+	if !ch.lock() {
+		return amqp.ErrClosed
+	}
+	defer ch.unlock()
+	ch.confirming = true
+	return nil
+}
+
+func (ch *memoryChannel) NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation {
+	if !ch.lock() {
+		close(confirm)
+		return confirm
+	}
+	defer ch.unlock()
+	ch.confirms = append(ch.confirms, confirm)
+	return confirm
+}
+
+func (ch *memoryChannel) NotifyClose(receiver chan *amqp.Error) chan *amqp.Error {
+	if !ch.lock() {
+		close(receiver)
+		return receiver
+	}
+	defer ch.unlock()
+	ch.notify = append(ch.notify, receiver)
+	return receiver
+}
+
+func (ch *memoryChannel) Qos(prefetchCount int, prefetchSize int, global bool) error {
+	if !ch.lock() {
+		return amqp.ErrClosed
+	}
+	defer ch.unlock()
+	ch.prefetch = prefetchCount
+	return nil
+}
+
+func (ch *memoryChannel) Consume(queue string, consumer string, autoAck bool, exclusive bool, noLocal bool, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	if autoAck {
+		return nil, fmt.Errorf("the memory broker only supports manual acks")
+	}
+	if !ch.lock() {
+		return nil, amqp.ErrClosed
+	}
+	defer ch.unlock()
+	b := ch.conn.broker
+	q, exists := b.queues[queue]
+	if !exists {
+		return nil, ch.fail(&amqp.Error{Code: amqp.NotFound, Reason: fmt.Sprintf("NOT_FOUND - no queue '%s'", queue)})
+	}
+	if consumer == "" {
+		consumer = newMessageId()
+	}
+	if _, exists := ch.consumers[consumer]; exists {
+		return nil, ch.fail(&amqp.Error{Code: amqp.NotAllowed, Reason: fmt.Sprintf("NOT_ALLOWED - attempt to reuse consumer tag '%s'", consumer)})
+	}
+	memoryConsumer := &memoryConsumer{
+		tag:        consumer,
+		queue:      q,
+		channel:    ch,
+		deliveries: make(chan amqp.Delivery, memoryBuffer),
+	}
+	ch.consumers[consumer] = memoryConsumer
+	q.consumers = append(q.consumers, memoryConsumer)
+	b.dispatch()
+	return memoryConsumer.deliveries, nil
+}
+
+func (ch *memoryChannel) Cancel(consumer string, noWait bool) error {
+	if !ch.lock() {
+		return amqp.ErrClosed
+	}
+	defer ch.unlock()
+	memoryConsumer, exists := ch.consumers[consumer]
+	if !exists {
+		return nil
+	}
+	delete(ch.consumers, consumer)
+	memoryConsumer.remove()
+	return nil
+}
+
+func (ch *memoryChannel) Get(queue string, autoAck bool) (amqp.Delivery, bool, error) {
+	if !ch.lock() {
+		return amqp.Delivery{}, false, amqp.ErrClosed
+	}
+	defer ch.unlock()
+	q, exists := ch.conn.broker.queues[queue]
+	if !exists {
+		return amqp.Delivery{}, false, ch.fail(&amqp.Error{Code: amqp.NotFound, Reason: fmt.Sprintf("NOT_FOUND - no queue '%s'", queue)})
+	}
+	if len(q.ready) == 0 {
+		return amqp.Delivery{}, false, nil
+	}
+	delivery := q.ready[0]
+	q.ready = q.ready[1:]
+	if !autoAck {
+		ch.track(q, &delivery)
+	}
+	return delivery, true, nil
+}
+
+func (ch *memoryChannel) Close() error {
+	b := ch.conn.broker
+	b.mu.Lock()
+	if ch.closed {
+		b.mu.Unlock()
+		return amqp.ErrClosed
+	}
+	notify := ch.close()
+	b.dispatch()
+	b.mu.Unlock()
+	closeNotify(notify, nil)
+	return nil
+}
+
+// Ack, Nack and Reject make the channel the Acknowledger of its deliveries
+
+func (ch *memoryChannel) Ack(tag uint64, multiple bool) error {
+	return ch.settle(tag, multiple, func(unacked unackedDelivery) {})
+}
+
+func (ch *memoryChannel) Nack(tag uint64, multiple bool, requeueing bool) error {
+	return ch.settle(tag, multiple, func(unacked unackedDelivery) {
+		if requeueing {
+			requeue([]unackedDelivery{unacked})
+			return
+		}
+		ch.conn.broker.deadLetter(unacked.queue, unacked.delivery)
+	})
+}
+
+func (ch *memoryChannel) Reject(tag uint64, requeueing bool) error {
+	return ch.Nack(tag, false, requeueing)
+}
+
+// settle removes the deliveries up to tag from the unacked ones and hands each to done
+func (ch *memoryChannel) settle(tag uint64, multiple bool, done func(unackedDelivery)) error {
+	if !ch.lock() {
+		return amqp.ErrClosed
+	}
+	defer ch.unlock()
+	if _, exists := ch.unacked[tag]; !exists {
+		return ch.fail(&amqp.Error{Code: amqp.PreconditionFailed, Reason: fmt.Sprintf("PRECONDITION_FAILED - unknown delivery tag %d", tag)})
+	}
+	first := tag
+	if multiple {
+		first = 1
+	}
+	for current := first; current <= tag; current++ {
+		unacked, exists := ch.unacked[current]
+		if !exists {
+			continue
+		}
+		delete(ch.unacked, current)
+		done(unacked)
+	}
+	ch.conn.broker.dispatch()
+	return nil
+}
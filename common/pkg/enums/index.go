@@ -17,6 +17,7 @@ const (
 	RuntimeErrorOther
 	InternalError
 	ExecFormatError
+	MemoryLimitExceeded
 )
 
 type StatusInfo struct {
@@ -39,6 +40,7 @@ var statusMap = map[Status]StatusInfo{
 	RuntimeErrorOther:   {12, "Runtime Error (Other)"},
 	InternalError:       {13, "Internal Error"},
 	ExecFormatError:     {14, "Exec Format Error"},
+	MemoryLimitExceeded: {15, "Memory Limit Exceeded"},
 }
 
 func FindRuntimeErrorByStatusCode(statusCode int) Status {
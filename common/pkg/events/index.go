@@ -0,0 +1,100 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-compiler/common/pkg/broker"
+	"go-compiler/common/pkg/utils"
+	"go-compiler/models/results"
+	"log"
+	"time"
+)
+
+const (
+	// Exchange carries the events executors publish about submissions
+	Exchange = "execution-events"
+	// CompletedKey routes completion events
+	CompletedKey = "execution.completed"
+	// StatusKey routes status events
+	StatusKey = "execution.status"
+	// ResultsQueue is the queue notification-service receives events on
+	ResultsQueue = "executions"
+
+	// ResultTTL is how long results and statuses stay in storage for polling clients
+	ResultTTL = time.Hour
+)
+
+var (
+	// Completions binds a queue to the completion events
+	Completions = broker.Binding{Exchange: Exchange, Key: CompletedKey}
+	// Statuses binds a queue to the status events
+	Statuses = broker.Binding{Exchange: Exchange, Key: StatusKey}
+)
+
+// Publisher publishes to an exchange, as *broker.Client does
+type Publisher interface {
+	PublishEvent(exchange string, key string, body []byte) error
+}
+
+// Reporter reports the stages and results of submissions the same way whichever service handles
+// them: they are stored under the request ID for polling clients, then published as events for
+// WebSocket clients.
+type Reporter struct {
+	publisher Publisher
+	store     utils.ICacheClient
+}
+
+func NewReporter(publisher Publisher, store utils.ICacheClient) *Reporter {
+	return &Reporter{
+		publisher: publisher,
+		store:     store,
+	}
+}
+
+// Completed stores the event's result, records the finished stage and publishes the event. An error
+// means the result was not fully reported and should be retried; a retried submission may be
+// reported twice.
+func (r *Reporter) Completed(event results.CompletionEvent) error {
+	event.Event = results.CompletedEventName
+	event.Result.RequestId = event.RequestId
+	body, err := json.Marshal(event)
+	if err != nil {
+		return broker.Permanent(fmt.Errorf("failed to encode completion event: %v", err))
+	}
+	result, err := json.Marshal(event.Result)
+	if err != nil {
+		return broker.Permanent(fmt.Errorf("failed to encode result: %v", err))
+	}
+
+	// Results are stored first, so a client told about a result can always fetch it
+	if event.RequestId != "" {
+		err = r.store.Set(event.RequestId, string(result), ResultTTL)
+		if err != nil {
+			return fmt.Errorf("failed to store result: %v", err)
+		}
+		// The result is what polling clients need, so a lost status is only logged
+		err = r.record(event.RequestId, results.Finished, event.Result.Status, time.Now().UTC())
+		if err != nil {
+			log.Printf("Failed to record submission %s as finished: %v", event.RequestId, err)
+		}
+	}
+	err = r.publisher.PublishEvent(Exchange, CompletedKey, body)
+	if err != nil {
+		return fmt.Errorf("failed to publish completion event: %v", err)
+	}
+	return nil
+}
+
+// Result returns the stored result of requestId
+func (r *Reporter) Result(requestId string) (results.ExecutionResult, error) {
+	var result results.ExecutionResult
+	stored, err := r.store.Get(requestId)
+	if err != nil {
+		return result, err
+	}
+	err = json.Unmarshal([]byte(stored), &result)
+	if err != nil {
+		return result, fmt.Errorf("failed to decode result: %v", err)
+	}
+	return result, nil
+}
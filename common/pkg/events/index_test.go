@@ -0,0 +1,184 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"go-compiler/common/pkg/broker"
+	"go-compiler/common/pkg/enums"
+	"go-compiler/models/results"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// memoryCache is an in-memory stand-in for Redis
+type memoryCache struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+var errMissing = errors.New("redis: nil")
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{values: make(map[string]string)}
+}
+
+func (c *memoryCache) Set(key string, value interface{}, expiration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] = value.(string)
+	return nil
+}
+
+func (c *memoryCache) Get(key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, exists := c.values[key]
+	if !exists {
+		return "", errMissing
+	}
+	return value, nil
+}
+
+func (c *memoryCache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.values, key)
+	return nil
+}
+
+func (c *memoryCache) Exists(key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, exists := c.values[key]
+	return exists, nil
+}
+
+func (c *memoryCache) Expire(key string, expiration time.Duration) error {
+	return nil
+}
+
+func (c *memoryCache) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values = make(map[string]string)
+	return nil
+}
+
+// harness runs a Reporter against a client of a MemoryBroker and an in-memory cache
+type harness struct {
+	client   *broker.Client
+	memory   *broker.MemoryBroker
+	cache    *memoryCache
+	reporter *Reporter
+}
+
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+	memory := broker.NewMemoryBroker()
+	client := broker.NewClientWith("amqp://memory", broker.Config{
+		Dial:           memory.Dial,
+		MinBackoff:     5 * time.Millisecond,
+		MaxBackoff:     20 * time.Millisecond,
+		PublishTimeout: 2 * time.Second,
+	})
+	t.Cleanup(func() { client.Close() })
+	cache := newMemoryCache()
+	return &harness{
+		client:   client,
+		memory:   memory,
+		cache:    cache,
+		reporter: NewReporter(client, cache),
+	}
+}
+
+// listen consumes the events routed with keys, as notification-service does
+func (h *harness) listen(t *testing.T, keys ...string) <-chan amqp.Delivery {
+	t.Helper()
+	bindings := make([]broker.Binding, 0, len(keys))
+	for _, key := range keys {
+		bindings = append(bindings, broker.Binding{Exchange: Exchange, Key: key})
+	}
+	deliveries, err := h.client.Consume(ResultsQueue, "", 100, bindings...)
+	if err != nil {
+		t.Fatalf("Consume() error = %v", err)
+	}
+	// The queue is bound once the consumer subscribed, which the first publish must not race
+	deadline := time.Now().Add(2 * time.Second)
+	for h.memory.Consumers(ResultsQueue) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("consumer did not subscribe")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return deliveries
+}
+
+// next acks and returns the next event, decoded into event
+func next(t *testing.T, deliveries <-chan amqp.Delivery, event interface{}) string {
+	t.Helper()
+	select {
+	case delivery, ok := <-deliveries:
+		if !ok {
+			t.Fatal("deliveries closed")
+		}
+		if err := delivery.Ack(false); err != nil {
+			t.Fatalf("Ack() error = %v", err)
+		}
+		if err := json.Unmarshal(delivery.Body, event); err != nil {
+			t.Fatalf("failed to decode event %s: %v", delivery.Body, err)
+		}
+		return delivery.RoutingKey
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event arrived")
+	}
+	return ""
+}
+
+// none checks that no further event arrives
+func none(t *testing.T, deliveries <-chan amqp.Delivery) {
+	t.Helper()
+	select {
+	case delivery := <-deliveries:
+		t.Errorf("unexpected event %s: %s", delivery.RoutingKey, delivery.Body)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestReporterCompleted(t *testing.T) {
+	h := newHarness(t)
+	events := h.listen(t, CompletedKey)
+
+	err := h.reporter.Completed(results.CompletionEvent{
+		RequestId:    "request",
+		ConnectionId: "connection",
+		Result:       results.ExecutionResult{Status: results.NewStatus(enums.Accepted), Stdout: "hello"},
+	})
+	if err != nil {
+		t.Fatalf("Completed() error = %v", err)
+	}
+
+	// The result is stored for polling clients under the request ID
+	stored, err := h.reporter.Result("request")
+	if err != nil {
+		t.Fatalf("Result() error = %v", err)
+	}
+	if stored.RequestId != "request" || stored.Stdout != "hello" || stored.Status != results.NewStatus(enums.Accepted) {
+		t.Errorf("stored result = %+v", stored)
+	}
+	status, found, err := h.reporter.status("request")
+	if err != nil || !found || status.Stage != results.Finished {
+		t.Errorf("stored status = %+v, %v, %v; want it finished", status, found, err)
+	}
+
+	// Then published for WebSocket clients
+	var event results.CompletionEvent
+	if key := next(t, events, &event); key != CompletedKey {
+		t.Errorf("routing key = %s, want %s", key, CompletedKey)
+	}
+	if event.Event != results.CompletedEventName || event.ConnectionId != "connection" || event.Result.Stdout != "hello" {
+		t.Errorf("completion event = %+v", event)
+	}
+}
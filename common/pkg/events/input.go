@@ -0,0 +1,186 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"go-compiler/common/pkg/broker"
+	"go-compiler/common/pkg/config"
+	"go-compiler/common/pkg/runner"
+	"go-compiler/models/results"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	// inputKeyPrefix routes input events to the submission whose request ID follows it
+	inputKeyPrefix = "execution.input."
+	// inputQueuePrefix names the queue an executor receives one submission's input on
+	inputQueuePrefix = "execution-input."
+)
+
+// InputKey routes input events to the submission requestId
+func InputKey(requestId string) string {
+	return inputKeyPrefix + requestId
+}
+
+// Subscriber consumes queues that only exist while they are consumed, as *broker.Client does
+type Subscriber interface {
+	ConsumeTransient(queue string, tag string, bindings ...broker.Binding) (<-chan amqp.Delivery, error)
+	Cancel(tag string) error
+}
+
+// InputStream is the stdin of an interactive submission: what its WebSocket client types, read
+// as it arrives. Input from other connections is dropped. The stream ends when the client sends
+// input_end, when the program waited the idle timeout for input, or once the stream is closed.
+type InputStream struct {
+	subscriber   Subscriber
+	tag          string
+	requestId    string
+	connectionId string
+	idleTimeout  time.Duration
+	sessionLimit float64
+
+	mu      sync.Mutex
+	pending []byte
+	ended   bool
+	arrived chan struct{}
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// OpenInput starts receiving the input of the submission requestId from the WebSocket client
+// connectionId. Input sent before the subscription reached the broker is lost. The stream must be
+// closed once the program ended.
+func OpenInput(subscriber Subscriber, requestId string, connectionId string, interactive config.Interactive) (*InputStream, error) {
+	s := &InputStream{
+		subscriber:   subscriber,
+		tag:          inputQueuePrefix + requestId,
+		requestId:    requestId,
+		connectionId: connectionId,
+		idleTimeout:  time.Duration(interactive.IdleTimeout * float64(time.Second)),
+		sessionLimit: interactive.SessionLimit,
+		arrived:      make(chan struct{}, 1),
+		closed:       make(chan struct{}),
+	}
+	deliveries, err := subscriber.ConsumeTransient(inputQueuePrefix+requestId, s.tag, broker.Binding{Exchange: Exchange, Key: InputKey(requestId)})
+	if err != nil {
+		return nil, err
+	}
+	go s.receive(deliveries)
+	return s, nil
+}
+
+// receive buffers the input events of the submission's client until the consumer is cancelled
+func (s *InputStream) receive(deliveries <-chan amqp.Delivery) {
+	for delivery := range deliveries {
+		var event results.InputEvent
+		err := json.Unmarshal(delivery.Body, &event)
+		switch {
+		case err != nil:
+			log.Printf("Dropping undecodable input of submission %s: %v", s.requestId, err)
+		case event.RequestId != s.requestId || event.ConnectionId != s.connectionId:
+			log.Printf("Dropping input of submission %s sent from connection %s", s.requestId, event.ConnectionId)
+		case event.Event == results.InputEndEventName:
+			s.push(nil, true)
+		default:
+			s.push([]byte(event.Data), false)
+		}
+		if err := delivery.Ack(false); err != nil {
+			log.Printf("Failed to acknowledge input of submission %s: %v", s.requestId, err)
+		}
+	}
+}
+
+func (s *InputStream) push(data []byte, end bool) {
+	s.mu.Lock()
+	if !s.ended {
+		s.pending = append(s.pending, data...)
+		s.ended = end
+	}
+	s.mu.Unlock()
+	select {
+	case s.arrived <- struct{}{}:
+	default:
+	}
+}
+
+// Read returns the input received so far, waiting for more when there is none. It reports io.EOF
+// once the stream ended, or once it waited the idle timeout without input.
+func (s *InputStream) Read(p []byte) (int, error) {
+	idle := time.NewTimer(s.idleTimeout)
+	defer idle.Stop()
+	for {
+		s.mu.Lock()
+		if len(s.pending) > 0 {
+			n := copy(p, s.pending)
+			s.pending = s.pending[n:]
+			s.mu.Unlock()
+			return n, nil
+		}
+		ended := s.ended
+		s.mu.Unlock()
+		if ended {
+			return 0, io.EOF
+		}
+
+		select {
+		case <-s.arrived:
+		case <-s.closed:
+			return 0, io.EOF
+		case <-idle.C:
+			log.Printf("No input for submission %s in %v, closing its stdin", s.requestId, s.idleTimeout)
+			s.push(nil, true)
+		}
+	}
+}
+
+// Close stops receiving input and ends the stream
+func (s *InputStream) Close() {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		if err := s.subscriber.Cancel(s.tag); err != nil {
+			log.Printf("Failed to stop receiving input of submission %s: %v", s.requestId, err)
+		}
+	})
+}
+
+// Executor wraps executor to feed the stream to the programs it runs. Someone typing needs more
+// than the wall time limit, so runs fed the stream are bounded by the session limit instead; runs of
+// executors that cannot feed it, such as Kubernetes Jobs, keep the wall time limit.
+func (s *InputStream) Executor(executor runner.Executor) runner.Executor {
+	interactive := interactiveExecutor{Executor: executor, input: s}
+	if batch, ok := executor.(runner.BatchExecutor); ok {
+		return interactiveBatchExecutor{interactiveExecutor: interactive, batch: batch}
+	}
+	return interactive
+}
+
+type interactiveExecutor struct {
+	runner.Executor
+	input *InputStream
+}
+
+func (e interactiveExecutor) Execute(ctx context.Context, submission runner.Submission, stdin string) results.ExecutionResult {
+	return e.Executor.Execute(ctx, e.interactive(submission), stdin)
+}
+
+// interactive feeds the stream to the submission's runs
+func (e interactiveExecutor) interactive(submission runner.Submission) runner.Submission {
+	submission.Input = e.input
+	submission.SessionLimit = e.input.sessionLimit
+	return submission
+}
+
+type interactiveBatchExecutor struct {
+	interactiveExecutor
+	batch runner.BatchExecutor
+}
+
+func (e interactiveBatchExecutor) ExecuteAll(ctx context.Context, submission runner.Submission, stdins []string) ([]results.ExecutionResult, results.ExecutionResult, bool) {
+	return e.batch.ExecuteAll(ctx, e.interactive(submission), stdins)
+}
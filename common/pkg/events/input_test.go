@@ -0,0 +1,111 @@
+package events
+
+import (
+	"encoding/json"
+	"go-compiler/common/pkg/config"
+	"go-compiler/models/results"
+	"io"
+	"testing"
+	"time"
+)
+
+// openInput opens the input of submission "request" for connection "connection" and waits until
+// it is subscribed, since input sent before that is lost
+func (h *harness) openInput(t *testing.T, interactive config.Interactive) *InputStream {
+	t.Helper()
+	input, err := OpenInput(h.client, "request", "connection", interactive)
+	if err != nil {
+		t.Fatalf("OpenInput() error = %v", err)
+	}
+	t.Cleanup(input.Close)
+	deadline := time.Now().Add(2 * time.Second)
+	for h.memory.Consumers(inputQueuePrefix+"request") == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("input was not subscribed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return input
+}
+
+// send publishes an input event as notification-service forwards it
+func (h *harness) send(t *testing.T, event results.InputEvent) {
+	t.Helper()
+	body, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("failed to encode input: %v", err)
+	}
+	if err := h.client.PublishEvent(Exchange, InputKey(event.RequestId), body); err != nil {
+		t.Fatalf("PublishEvent() error = %v", err)
+	}
+}
+
+// readAll reads the stream to its end, failing if it does not end in time
+func readAll(t *testing.T, input io.Reader) string {
+	t.Helper()
+	read := make(chan string, 1)
+	go func() {
+		data, _ := io.ReadAll(input)
+		read <- string(data)
+	}()
+	select {
+	case data := <-read:
+		return data
+	case <-time.After(2 * time.Second):
+		t.Fatal("stdin did not end")
+	}
+	return ""
+}
+
+func TestInputStreamReadsOwnConnection(t *testing.T) {
+	h := newHarness(t)
+	input := h.openInput(t, config.Interactive{IdleTimeout: 5, SessionLimit: 300})
+
+	// Only input from the connection the submission names reaches the program
+	h.send(t, results.InputEvent{Event: results.InputEventName, RequestId: "request", ConnectionId: "other", Data: "intruder\n"})
+	h.send(t, results.InputEvent{Event: results.InputEventName, RequestId: "request", ConnectionId: "connection", Data: "1 2\n"})
+	h.send(t, results.InputEvent{Event: results.InputEventName, RequestId: "request", ConnectionId: "connection", Data: "3\n"})
+	h.send(t, results.InputEvent{Event: results.InputEndEventName, RequestId: "request", ConnectionId: "other"})
+	h.send(t, results.InputEvent{Event: results.InputEndEventName, RequestId: "request", ConnectionId: "connection"})
+	h.send(t, results.InputEvent{Event: results.InputEventName, RequestId: "request", ConnectionId: "connection", Data: "late\n"})
+
+	if data := readAll(t, input); data != "1 2\n3\n" {
+		t.Errorf("stdin = %q, want the owning connection's input up to input_end", data)
+	}
+}
+
+func TestInputStreamIdleTimeout(t *testing.T) {
+	h := newHarness(t)
+	input := h.openInput(t, config.Interactive{IdleTimeout: 0.05, SessionLimit: 300})
+	h.send(t, results.InputEvent{Event: results.InputEventName, RequestId: "request", ConnectionId: "connection", Data: "only\n"})
+
+	// Without input_end, stdin ends once the program waited the idle timeout
+	started := time.Now()
+	if data := readAll(t, input); data != "only\n" {
+		t.Errorf("stdin = %q, want the input sent before the client went idle", data)
+	}
+	if waited := time.Since(started); waited < 50*time.Millisecond {
+		t.Errorf("stdin ended after %v, before the idle timeout", waited)
+	}
+}
+
+func TestInputStreamCloseUnbinds(t *testing.T) {
+	h := newHarness(t)
+	input := h.openInput(t, config.Interactive{IdleTimeout: 5, SessionLimit: 300})
+	if !h.memory.Bound(Exchange, InputKey("request")) {
+		t.Fatalf("input queue is not bound to %s", InputKey("request"))
+	}
+
+	// Closing ends stdin and removes the submission's queue with its binding
+	input.Close()
+	if data := readAll(t, input); data != "" {
+		t.Errorf("stdin = %q after Close, want it ended", data)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for h.memory.Consumers(inputQueuePrefix+"request") > 0 || h.memory.Bound(Exchange, InputKey("request")) {
+		if time.Now().After(deadline) {
+			t.Fatal("input queue is still consumed or bound after Close")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
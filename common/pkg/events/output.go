@@ -0,0 +1,210 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"go-compiler/common/pkg/broker"
+	"go-compiler/common/pkg/runner"
+	"go-compiler/models/results"
+	"log"
+	"sync"
+	"time"
+)
+
+// OutputKey routes output events
+const OutputKey = "execution.output"
+
+// Outputs binds a queue to the output events
+var Outputs = broker.Binding{Exchange: Exchange, Key: OutputKey}
+
+const (
+	// outputFlushInterval batches output written in quick succession into one event
+	outputFlushInterval = 100 * time.Millisecond
+	// maxOutputChunk flushes pending output early once a chunk grows this large
+	maxOutputChunk = 16 * 1024
+)
+
+// OutputStream publishes one submission's output as the program writes it. Writes are batched
+// into chunks per stream and published in order, numbered from 1. Publishing is best effort:
+// failures are logged and the output is still part of the submission's result.
+type OutputStream struct {
+	reporter     *Reporter
+	requestId    string
+	connectionId string
+
+	mu          sync.Mutex
+	pending     []results.OutputEvent
+	size        int
+	seq         int
+	streamed    bool
+	stdoutBytes int64
+	stderrBytes int64
+
+	wake chan struct{}
+	done chan struct{}
+	stop sync.Once
+	wg   sync.WaitGroup
+}
+
+// Output starts publishing the output of the submission requestId to the WebSocket client
+// connectionId. The stream must be closed once the program ended.
+func (r *Reporter) Output(requestId string, connectionId string) *OutputStream {
+	s := &OutputStream{
+		reporter:     r,
+		requestId:    requestId,
+		connectionId: connectionId,
+		wake:         make(chan struct{}, 1),
+		done:         make(chan struct{}),
+	}
+	s.wg.Add(1)
+	go s.flushEvery(outputFlushInterval)
+	return s
+}
+
+// Write queues data, written by the program to stream, for publishing. It matches
+// runner.OutputFunc and copies data, which the runner reuses.
+func (s *OutputStream) Write(stream results.Stream, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	s.mu.Lock()
+	s.streamed = true
+	if stream == results.StderrStream {
+		s.stderrBytes += int64(len(data))
+	} else {
+		s.stdoutBytes += int64(len(data))
+	}
+	last := len(s.pending) - 1
+	if last >= 0 && s.pending[last].Stream == stream && len(s.pending[last].Data)+len(data) <= maxOutputChunk {
+		s.pending[last].Data += string(data)
+	} else {
+		s.pending = append(s.pending, results.OutputEvent{Stream: stream, Data: string(data)})
+	}
+	s.size += len(data)
+	full := s.size >= maxOutputChunk
+	s.mu.Unlock()
+
+	if full {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Close publishes the output still pending, then the output_end summary
+func (s *OutputStream) Close() {
+	s.stop.Do(func() {
+		close(s.done)
+		s.wg.Wait()
+		s.flush()
+
+		s.mu.Lock()
+		s.seq++
+		summary := results.OutputEvent{
+			Event:       results.OutputEndEventName,
+			Seq:         s.seq,
+			Chunks:      s.seq - 1,
+			StdoutBytes: s.stdoutBytes,
+			StderrBytes: s.stderrBytes,
+		}
+		s.mu.Unlock()
+		s.publish(summary)
+	})
+}
+
+// flushEvery publishes pending output every interval, and early when woken by a large write
+func (s *OutputStream) flushEvery(interval time.Duration) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+		s.flush()
+	}
+}
+
+// flush publishes the pending chunks in the order they were written. Only the flusher and Close,
+// after the flusher stopped, call it, so sequence numbers are published in order.
+func (s *OutputStream) flush() {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = nil
+	s.size = 0
+	for i := range pending {
+		s.seq++
+		pending[i].Event = results.OutputEventName
+		pending[i].Seq = s.seq
+	}
+	s.mu.Unlock()
+
+	for _, event := range pending {
+		s.publish(event)
+	}
+}
+
+func (s *OutputStream) publish(event results.OutputEvent) {
+	event.RequestId = s.requestId
+	event.ConnectionId = s.connectionId
+	body, err := json.Marshal(event)
+	if err == nil {
+		err = s.reporter.publisher.PublishEvent(Exchange, OutputKey, body)
+	}
+	if err != nil {
+		log.Printf("Failed to publish output %d of submission %s: %v", event.Seq, s.requestId, err)
+	}
+}
+
+// Executor wraps executor to stream the output of the programs it runs. Executors that only
+// return output once the program ended, such as Kubernetes Jobs, have it sent as it is returned. A
+// runner.BatchExecutor stays one, so its batches still run in one go.
+func (s *OutputStream) Executor(executor runner.Executor) runner.Executor {
+	streamed := streamedExecutor{Executor: executor, output: s}
+	if batch, ok := executor.(runner.BatchExecutor); ok {
+		return streamedBatchExecutor{streamedExecutor: streamed, batch: batch}
+	}
+	return streamed
+}
+
+type streamedExecutor struct {
+	runner.Executor
+	output *OutputStream
+}
+
+func (e streamedExecutor) Execute(ctx context.Context, submission runner.Submission, stdin string) results.ExecutionResult {
+	submission.Output = e.output.Write
+	result := e.Executor.Execute(ctx, submission, stdin)
+	e.sendReturned([]results.ExecutionResult{result})
+	return result
+}
+
+// sendReturned sends the output of runs when the executor did not stream it as it was written
+func (e streamedExecutor) sendReturned(runs []results.ExecutionResult) {
+	e.output.mu.Lock()
+	streamed := e.output.streamed
+	e.output.mu.Unlock()
+	if streamed {
+		return
+	}
+	for _, run := range runs {
+		e.output.Write(results.StdoutStream, []byte(run.Stdout))
+		e.output.Write(results.StderrStream, []byte(run.Stderr))
+	}
+}
+
+type streamedBatchExecutor struct {
+	streamedExecutor
+	batch runner.BatchExecutor
+}
+
+func (e streamedBatchExecutor) ExecuteAll(ctx context.Context, submission runner.Submission, stdins []string) ([]results.ExecutionResult, results.ExecutionResult, bool) {
+	submission.Output = e.output.Write
+	runs, failed, ok := e.batch.ExecuteAll(ctx, submission, stdins)
+	e.sendReturned(runs)
+	return runs, failed, ok
+}
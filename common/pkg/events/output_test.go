@@ -0,0 +1,172 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"go-compiler/common/pkg/enums"
+	"go-compiler/common/pkg/runner"
+	"go-compiler/models/results"
+	"strings"
+	"testing"
+)
+
+// recordingPublisher records what is published instead of sending it to RabbitMQ
+type recordingPublisher struct {
+	events []results.OutputEvent
+}
+
+func (p *recordingPublisher) PublishEvent(exchange string, key string, body []byte) error {
+	var event results.OutputEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return err
+	}
+	p.events = append(p.events, event)
+	return nil
+}
+
+// unflushedOutput returns a stream without its flusher, so only Close publishes and chunk
+// boundaries only depend on the writes
+func unflushedOutput(publisher Publisher) *OutputStream {
+	return &OutputStream{
+		reporter:  NewReporter(publisher, newMemoryCache()),
+		requestId: "request",
+		wake:      make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+}
+
+func TestOutputStreamChunks(t *testing.T) {
+	publisher := &recordingPublisher{}
+	output := unflushedOutput(publisher)
+
+	// Writes to the same stream join one chunk until it would outgrow maxOutputChunk
+	output.Write(results.StdoutStream, []byte("a"))
+	output.Write(results.StdoutStream, []byte("b"))
+	output.Write(results.StderrStream, []byte("e"))
+	output.Write(results.StdoutStream, []byte(strings.Repeat("x", maxOutputChunk-1)))
+	output.Write(results.StdoutStream, []byte("yz"))
+	output.Write(results.StdoutStream, nil)
+	output.Close()
+	output.Close()
+
+	want := []results.OutputEvent{
+		{Event: results.OutputEventName, Seq: 1, Stream: results.StdoutStream, Data: "ab"},
+		{Event: results.OutputEventName, Seq: 2, Stream: results.StderrStream, Data: "e"},
+		{Event: results.OutputEventName, Seq: 3, Stream: results.StdoutStream, Data: strings.Repeat("x", maxOutputChunk-1)},
+		{Event: results.OutputEventName, Seq: 4, Stream: results.StdoutStream, Data: "yz"},
+		{Event: results.OutputEndEventName, Seq: 5, Chunks: 4, StdoutBytes: maxOutputChunk + 3, StderrBytes: 1},
+	}
+	if len(publisher.events) != len(want) {
+		t.Fatalf("published %d events, want %d: %+v", len(publisher.events), len(want), publisher.events)
+	}
+	for i, event := range publisher.events {
+		event.RequestId = ""
+		if event != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i+1, shorten(event), shorten(want[i]))
+		}
+	}
+}
+
+// shorten cuts an event's data for error messages
+func shorten(event results.OutputEvent) results.OutputEvent {
+	if len(event.Data) > 16 {
+		event.Data = event.Data[:16] + "..."
+	}
+	return event
+}
+
+func TestOutputEndsOnceBeforeCompletion(t *testing.T) {
+	h := newHarness(t)
+	events := h.listen(t, OutputKey, CompletedKey)
+
+	output := h.reporter.Output("request", "connection")
+	output.Write(results.StdoutStream, []byte("hello"))
+	output.Close()
+	output.Close()
+	err := h.reporter.Completed(results.CompletionEvent{
+		RequestId:    "request",
+		ConnectionId: "connection",
+		Result:       results.ExecutionResult{Status: results.NewStatus(enums.Accepted), Stdout: "hello"},
+	})
+	if err != nil {
+		t.Fatalf("Completed() error = %v", err)
+	}
+
+	var chunk results.OutputEvent
+	if key := next(t, events, &chunk); key != OutputKey || chunk.Seq != 1 || chunk.Data != "hello" || chunk.ConnectionId != "connection" {
+		t.Errorf("first event = %s %+v, want the output", key, chunk)
+	}
+	var end results.OutputEvent
+	if key := next(t, events, &end); key != OutputKey || end.Event != results.OutputEndEventName || end.Seq != 2 || end.Chunks != 1 {
+		t.Errorf("second event = %s %+v, want output_end", key, end)
+	}
+	var completed results.CompletionEvent
+	if key := next(t, events, &completed); key != CompletedKey || completed.Event != results.CompletedEventName {
+		t.Errorf("third event = %s %+v, want the completion", key, completed)
+	}
+	none(t, events)
+}
+
+// batchExecutor runs every batch in one go, as Kubernetes Jobs do, and returns each stdin as output
+type batchExecutor struct {
+	batches int
+}
+
+func (e *batchExecutor) Compile(ctx context.Context, submission runner.Submission) (results.ExecutionResult, bool) {
+	return results.ExecutionResult{}, true
+}
+
+func (e *batchExecutor) Execute(ctx context.Context, submission runner.Submission, stdin string) results.ExecutionResult {
+	runs, _, _ := e.ExecuteAll(ctx, submission, []string{stdin})
+	return runs[0]
+}
+
+func (e *batchExecutor) ExecuteAll(ctx context.Context, submission runner.Submission, stdins []string) ([]results.ExecutionResult, results.ExecutionResult, bool) {
+	e.batches++
+	runs := make([]results.ExecutionResult, 0, len(stdins))
+	for _, stdin := range stdins {
+		runs = append(runs, results.ExecutionResult{Status: results.NewStatus(enums.Accepted), Stdout: stdin})
+	}
+	return runs, results.ExecutionResult{}, true
+}
+
+// singleExecutor only runs one stdin at a time
+type singleExecutor struct{}
+
+func (singleExecutor) Compile(ctx context.Context, submission runner.Submission) (results.ExecutionResult, bool) {
+	return results.ExecutionResult{}, true
+}
+
+func (singleExecutor) Execute(ctx context.Context, submission runner.Submission, stdin string) results.ExecutionResult {
+	return results.ExecutionResult{Status: results.NewStatus(enums.Accepted), Stdout: stdin}
+}
+
+func TestOutputExecutorKeepsExecuteAll(t *testing.T) {
+	publisher := &recordingPublisher{}
+	output := unflushedOutput(publisher)
+	if _, ok := output.Executor(singleExecutor{}).(runner.BatchExecutor); ok {
+		t.Error("an executor without ExecuteAll gained one")
+	}
+
+	backend := &batchExecutor{}
+	executor := output.Executor(backend)
+	if _, ok := executor.(runner.BatchExecutor); !ok {
+		t.Fatal("wrapping dropped ExecuteAll")
+	}
+	runs, _, ok := runner.ExecuteAll(context.Background(), executor, runner.Submission{}, []string{"a", "b"})
+	if !ok || len(runs) != 2 || backend.batches != 1 {
+		t.Fatalf("ExecuteAll = %d runs, ok %v in %d batches; want 2 runs in one batch", len(runs), ok, backend.batches)
+	}
+
+	// Output the backend only returned once the runs ended is sent as it is returned
+	output.Close()
+	var data []string
+	for _, event := range publisher.events {
+		if event.Event == results.OutputEventName {
+			data = append(data, event.Data)
+		}
+	}
+	if strings.Join(data, "") != "ab" {
+		t.Errorf("streamed output = %q, want the runs' output", data)
+	}
+}
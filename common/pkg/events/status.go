@@ -0,0 +1,194 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-compiler/common/pkg/runner"
+	"go-compiler/models/results"
+	"log"
+	"sync"
+	"time"
+)
+
+// statusKeyPrefix keeps a submission's status apart from its result, which is stored under the bare
+// request ID
+const statusKeyPrefix = "status:"
+
+// Status records that the submission requestId entered stage and publishes a status event when the
+// submission has a WebSocket client to push it to. Stages are reported by one service at a time, in
+// order, so the stored status is updated without locking.
+func (r *Reporter) Status(requestId string, connectionId string, stage results.Stage) error {
+	if requestId == "" {
+		return nil
+	}
+	at := time.Now().UTC()
+	status := results.NewStageStatus(stage)
+	err := r.record(requestId, stage, status, at)
+	if err != nil {
+		return err
+	}
+	if connectionId == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(results.StatusEvent{
+		Event:        results.StatusEventName,
+		RequestId:    requestId,
+		ConnectionId: connectionId,
+		Stage:        stage,
+		Status:       status,
+		At:           at,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode status event: %v", err)
+	}
+	err = r.publisher.PublishEvent(Exchange, StatusKey, body)
+	if err != nil {
+		return fmt.Errorf("failed to publish status event: %v", err)
+	}
+	return nil
+}
+
+// record appends the transition to stage to the stored status of requestId
+func (r *Reporter) record(requestId string, stage results.Stage, status results.StatusModel, at time.Time) error {
+	current, _, err := r.status(requestId)
+	if err != nil {
+		return err
+	}
+	current.RequestId = requestId
+	current.Stage = stage
+	current.Status = status
+	current.UpdatedAt = at
+	current.History = append(current.History, results.Transition{Stage: stage, At: at})
+
+	body, err := json.Marshal(current)
+	if err != nil {
+		return fmt.Errorf("failed to encode status: %v", err)
+	}
+	err = r.store.Set(statusKeyPrefix+requestId, string(body), ResultTTL)
+	if err != nil {
+		return fmt.Errorf("failed to store status: %v", err)
+	}
+	return nil
+}
+
+// status returns the stored status of requestId, and false when there is none
+func (r *Reporter) status(requestId string) (results.SubmissionStatus, bool, error) {
+	var status results.SubmissionStatus
+	exists, err := r.store.Exists(statusKeyPrefix + requestId)
+	if err != nil || !exists {
+		return status, false, err
+	}
+	stored, err := r.store.Get(statusKeyPrefix + requestId)
+	if err != nil {
+		return status, false, err
+	}
+	err = json.Unmarshal([]byte(stored), &status)
+	if err != nil {
+		return status, false, fmt.Errorf("failed to decode status: %v", err)
+	}
+	return status, true, nil
+}
+
+// Submission returns the submission requestId as clients poll it: its result once finished, and
+// otherwise the status of the stage it is in
+func (r *Reporter) Submission(requestId string) (results.Submission, error) {
+	status, found, err := r.status(requestId)
+	if err != nil {
+		return results.Submission{}, err
+	}
+	result, resultErr := r.Result(requestId)
+	if resultErr == nil {
+		submission := results.Submission{
+			ExecutionResult: result,
+			Stage:           results.Finished,
+			UpdatedAt:       status.UpdatedAt,
+			History:         status.History,
+		}
+		return submission, nil
+	}
+	if !found {
+		return results.Submission{}, resultErr
+	}
+	return results.Submission{
+		ExecutionResult: results.ExecutionResult{
+			RequestId: requestId,
+			Status:    status.Status,
+		},
+		Stage:     status.Stage,
+		UpdatedAt: status.UpdatedAt,
+		History:   status.History,
+	}, nil
+}
+
+// Progress reports the stages of one submission while an executor runs it, each stage once.
+// Reporting is best effort: failures are logged and the submission carries on.
+type Progress struct {
+	reporter     *Reporter
+	requestId    string
+	connectionId string
+
+	mu    sync.Mutex
+	stage results.Stage
+}
+
+// Progress starts reporting the stages of the submission requestId
+func (r *Reporter) Progress(requestId string, connectionId string) *Progress {
+	return &Progress{
+		reporter:     r,
+		requestId:    requestId,
+		connectionId: connectionId,
+	}
+}
+
+// Enter reports that the submission entered stage, unless it already is in it
+func (p *Progress) Enter(stage results.Stage) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stage == stage {
+		return
+	}
+	p.stage = stage
+	err := p.reporter.Status(p.requestId, p.connectionId, stage)
+	if err != nil {
+		log.Printf("Failed to report stage %s of submission %s: %v", stage, p.requestId, err)
+	}
+}
+
+// Executor wraps executor to report the compiling and running stages as it enters them. A
+// runner.BatchExecutor stays one, so its batches still run in one go.
+func (p *Progress) Executor(executor runner.Executor) runner.Executor {
+	staged := stagedExecutor{Executor: executor, progress: p}
+	if batch, ok := executor.(runner.BatchExecutor); ok {
+		return stagedBatchExecutor{stagedExecutor: staged, batch: batch}
+	}
+	return staged
+}
+
+type stagedExecutor struct {
+	runner.Executor
+	progress *Progress
+}
+
+func (e stagedExecutor) Compile(ctx context.Context, submission runner.Submission) (results.ExecutionResult, bool) {
+	if submission.Language.IsCompiled() {
+		e.progress.Enter(results.Compiling)
+	}
+	return e.Executor.Compile(ctx, submission)
+}
+
+func (e stagedExecutor) Execute(ctx context.Context, submission runner.Submission, stdin string) results.ExecutionResult {
+	e.progress.Enter(results.Running)
+	return e.Executor.Execute(ctx, submission, stdin)
+}
+
+type stagedBatchExecutor struct {
+	stagedExecutor
+	batch runner.BatchExecutor
+}
+
+func (e stagedBatchExecutor) ExecuteAll(ctx context.Context, submission runner.Submission, stdins []string) ([]results.ExecutionResult, results.ExecutionResult, bool) {
+	e.progress.Enter(results.Running)
+	return e.batch.ExecuteAll(ctx, submission, stdins)
+}
@@ -0,0 +1,152 @@
+package events
+
+import (
+	"encoding/json"
+	"go-compiler/common/pkg/enums"
+	"go-compiler/models/results"
+	"testing"
+)
+
+// stages returns the stages of a status history
+func stages(history []results.Transition) []results.Stage {
+	entered := make([]results.Stage, 0, len(history))
+	for _, transition := range history {
+		entered = append(entered, transition.Stage)
+	}
+	return entered
+}
+
+func TestProgressEnter(t *testing.T) {
+	h := newHarness(t)
+	events := h.listen(t, StatusKey)
+
+	progress := h.reporter.Progress("request", "connection")
+	progress.Enter(results.Processing)
+	progress.Enter(results.Processing)
+	progress.Enter(results.Running)
+
+	// Each stage is recorded once under status:<id>
+	stored, err := h.cache.Get(statusKeyPrefix + "request")
+	if err != nil {
+		t.Fatalf("no status stored: %v", err)
+	}
+	var status results.SubmissionStatus
+	if err := json.Unmarshal([]byte(stored), &status); err != nil {
+		t.Fatalf("failed to decode status: %v", err)
+	}
+	if status.RequestId != "request" || status.Stage != results.Running || status.Status != results.NewStatus(enums.Process) {
+		t.Errorf("status = %+v, want it running", status)
+	}
+	if got := stages(status.History); len(got) != 2 || got[0] != results.Processing || got[1] != results.Running {
+		t.Errorf("history = %v, want processing then running", got)
+	}
+
+	// And pushed to the WebSocket client once per stage
+	for _, want := range []results.Stage{results.Processing, results.Running} {
+		var event results.StatusEvent
+		next(t, events, &event)
+		if event.Stage != want || event.ConnectionId != "connection" {
+			t.Errorf("status event = %+v, want stage %s", event, want)
+		}
+	}
+	none(t, events)
+}
+
+func TestSubmissionMergesStatusAndResult(t *testing.T) {
+	h := newHarness(t)
+	if err := h.reporter.Status("request", "", results.Queued); err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	h.reporter.Progress("request", "").Enter(results.Running)
+	err := h.reporter.Completed(results.CompletionEvent{
+		RequestId: "request",
+		Result:    results.ExecutionResult{Status: results.NewStatus(enums.Accepted), Stdout: "hello"},
+	})
+	if err != nil {
+		t.Fatalf("Completed() error = %v", err)
+	}
+
+	submission, err := h.reporter.Submission("request")
+	if err != nil {
+		t.Fatalf("Submission() error = %v", err)
+	}
+	if submission.Stdout != "hello" || submission.Status != results.NewStatus(enums.Accepted) || submission.Stage != results.Finished {
+		t.Errorf("submission = %+v, want the finished result", submission)
+	}
+	want := []results.Stage{results.Queued, results.Running, results.Finished}
+	if got := stages(submission.History); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("history = %v, want %v", got, want)
+	}
+	if submission.UpdatedAt.IsZero() {
+		t.Error("submission has no update time")
+	}
+}
+
+func TestSubmissionStages(t *testing.T) {
+	tests := []struct {
+		name       string
+		report     func(r *Reporter)
+		wantStage  results.Stage
+		wantStatus enums.Status
+		wantStdout string
+	}{
+		{
+			name:       "queued",
+			report:     func(r *Reporter) { r.Status("request", "", results.Queued) },
+			wantStage:  results.Queued,
+			wantStatus: enums.Queue,
+		},
+		{
+			name: "processing",
+			report: func(r *Reporter) {
+				r.Status("request", "", results.Queued)
+				r.Progress("request", "").Enter(results.Processing)
+			},
+			wantStage:  results.Processing,
+			wantStatus: enums.Process,
+		},
+		{
+			name: "finished",
+			report: func(r *Reporter) {
+				r.Status("request", "", results.Queued)
+				r.Progress("request", "").Enter(results.Processing)
+				r.Completed(results.CompletionEvent{
+					RequestId: "request",
+					Result:    results.ExecutionResult{Status: results.NewStatus(enums.WrongAnswer), Stdout: "42"},
+				})
+			},
+			wantStage:  results.Finished,
+			wantStatus: enums.WrongAnswer,
+			wantStdout: "42",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reporter := NewReporter(discard{}, newMemoryCache())
+			test.report(reporter)
+
+			// Clients poll until the stage is finished; before that the status only says how far it got
+			submission, err := reporter.Submission("request")
+			if err != nil {
+				t.Fatalf("Submission() error = %v", err)
+			}
+			if submission.Stage != test.wantStage || submission.Status != results.NewStatus(test.wantStatus) || submission.Stdout != test.wantStdout {
+				t.Errorf("submission = %+v, want stage %s with status %v", submission, test.wantStage, test.wantStatus)
+			}
+			if submission.RequestId != "request" {
+				t.Errorf("request ID = %q", submission.RequestId)
+			}
+		})
+	}
+
+	if _, err := NewReporter(discard{}, newMemoryCache()).Submission("unknown"); err == nil {
+		t.Error("Submission() of an unknown request succeeded")
+	}
+}
+
+// discard publishes events nowhere
+type discard struct{}
+
+func (discard) PublishEvent(exchange string, key string, body []byte) error {
+	return nil
+}
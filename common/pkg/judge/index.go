@@ -0,0 +1,189 @@
+package judge
+
+import (
+	"context"
+	"fmt"
+	"go-compiler/common/pkg/enums"
+	"go-compiler/common/pkg/runner"
+	"go-compiler/models/results"
+	"go-compiler/models/submissions"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Mode selects how a program's stdout is compared with the expected output
+type Mode string
+
+const (
+	Exact                  Mode = "exact"
+	TrimTrailingWhitespace Mode = "trim_trailing_whitespace"
+	IgnoreBlankLines       Mode = "ignore_blank_lines"
+	FloatTolerance         Mode = "float_tolerance"
+)
+
+// DefaultTolerance is used by FloatTolerance when the submission does not set one
+const DefaultTolerance = 1e-6
+
+// ParseMode validates a compare mode, defaulting to TrimTrailingWhitespace when empty
+func ParseMode(mode string) (Mode, error) {
+	switch Mode(mode) {
+	case "":
+		return TrimTrailingWhitespace, nil
+	case Exact, TrimTrailingWhitespace, IgnoreBlankLines, FloatTolerance:
+		return Mode(mode), nil
+	default:
+		return "", fmt.Errorf("unknown compare mode %q", mode)
+	}
+}
+
+// CheckTolerance rejects tolerances FloatTolerance cannot compare with. 0 selects DefaultTolerance.
+func CheckTolerance(tolerance float64) error {
+	if tolerance < 0 || !isFinite(tolerance) {
+		return fmt.Errorf("float_tolerance must be a non-negative number, got %v", tolerance)
+	}
+	return nil
+}
+
+// Compare reports whether actual matches expected under the given mode
+func Compare(mode Mode, expected string, actual string, tolerance float64) bool {
+	switch mode {
+	case Exact:
+		return expected == actual
+	case IgnoreBlankLines:
+		return equalLines(dropBlank(trimLines(expected)), dropBlank(trimLines(actual)))
+	case FloatTolerance:
+		return equalTokens(strings.Fields(expected), strings.Fields(actual), tolerance)
+	default:
+		return equalLines(trimLines(expected), trimLines(actual))
+	}
+}
+
+// Apply turns an Accepted result into WrongAnswer when its stdout does not match expected.
+// Results with any other verdict are left untouched, and nothing is judged when expected is nil;
+// an empty expected output is judged like any other.
+func Apply(result *results.ExecutionResult, expected *string, mode Mode, tolerance float64) {
+	accepted := results.NewStatus(enums.Accepted)
+	if expected == nil || result.Status != accepted {
+		return
+	}
+	if !Compare(mode, *expected, result.Stdout, tolerance) {
+		result.Status = results.NewStatus(enums.WrongAnswer)
+	}
+}
+
+// RunTestCases compiles the submission once, runs it against every test case and judges each run.
+// The aggregate status is Accepted when every case passes, otherwise the status of the first failing case.
+func RunTestCases(ctx context.Context, executor runner.Executor, submission runner.Submission, testCases []submissions.TestCase, mode Mode, tolerance float64) results.ExecutionResult {
+	compiled, ok := executor.Compile(ctx, submission)
+	if !ok {
+		return compiled
+	}
+
+	stdins := make([]string, len(testCases))
+	for i, testCase := range testCases {
+		stdins[i] = testCase.StdIn
+	}
+	runs, failed, ok := runner.ExecuteAll(ctx, executor, submission, stdins)
+	if !ok {
+		return failed
+	}
+
+	result := results.ExecutionResult{
+		Status:        results.NewStatus(enums.Accepted),
+		CompileOutput: compiled.CompileOutput,
+		TestCases:     make([]results.TestCaseResult, 0, len(testCases)),
+	}
+	// Executors that compile as part of running report the compiler output with the runs
+	if result.CompileOutput == "" && len(runs) > 0 {
+		result.CompileOutput = runs[0].CompileOutput
+	}
+	accepted := result.Status
+	for i, run := range runs {
+		Apply(&run, testCases[i].ExpectedOutput, mode, tolerance)
+		result.TestCases = append(result.TestCases, results.NewTestCaseResult(i, run))
+
+		if result.Status == accepted && run.Status != accepted {
+			result.Status = run.Status
+		}
+		result.Time += run.Time
+		result.CpuTime += run.CpuTime
+		if run.Memory > result.Memory {
+			result.Memory = run.Memory
+		}
+	}
+	return result
+}
+
+// trimLines splits output into lines without trailing whitespace or trailing empty lines
+func trimLines(output string) []string {
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func dropBlank(lines []string) []string {
+	kept := lines[:0]
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			kept = append(kept, line)
+		}
+	}
+	return kept
+}
+
+func equalLines(expected []string, actual []string) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	for i := range expected {
+		if expected[i] != actual[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// equalTokens compares whitespace separated tokens, allowing numeric tokens to differ by
+// at most tolerance in absolute or relative terms. NaN and infinities only match themselves.
+func equalTokens(expected []string, actual []string, tolerance float64) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+	for i := range expected {
+		if expected[i] == actual[i] {
+			continue
+		}
+		want, err := strconv.ParseFloat(expected[i], 64)
+		if err != nil {
+			return false
+		}
+		got, err := strconv.ParseFloat(actual[i], 64)
+		if err != nil {
+			return false
+		}
+		if !isFinite(want) || !isFinite(got) {
+			if want != got && !(math.IsNaN(want) && math.IsNaN(got)) {
+				return false
+			}
+			continue
+		}
+		diff := math.Abs(want - got)
+		if diff > tolerance && diff > tolerance*math.Abs(want) {
+			return false
+		}
+	}
+	return true
+}
+
+func isFinite(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
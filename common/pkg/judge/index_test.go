@@ -0,0 +1,185 @@
+package judge
+
+import (
+	"context"
+	"go-compiler/common/pkg/enums"
+	"go-compiler/common/pkg/runner"
+	"go-compiler/models/results"
+	"go-compiler/models/submissions"
+	"math"
+	"testing"
+)
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		mode    string
+		want    Mode
+		wantErr bool
+	}{
+		{"", TrimTrailingWhitespace, false},
+		{"exact", Exact, false},
+		{"trim_trailing_whitespace", TrimTrailingWhitespace, false},
+		{"ignore_blank_lines", IgnoreBlankLines, false},
+		{"float_tolerance", FloatTolerance, false},
+		{"EXACT", "", true},
+		{"fuzzy", "", true},
+	}
+	for _, test := range tests {
+		got, err := ParseMode(test.mode)
+		if (err != nil) != test.wantErr || got != test.want {
+			t.Errorf("ParseMode(%q) = %q, %v; want %q, error %v", test.mode, got, err, test.want, test.wantErr)
+		}
+	}
+}
+
+func TestCheckTolerance(t *testing.T) {
+	tests := []struct {
+		tolerance float64
+		wantErr   bool
+	}{
+		{0, false},
+		{1e-9, false},
+		{0.5, false},
+		{-1e-6, true},
+		{math.NaN(), true},
+		{math.Inf(1), true},
+	}
+	for _, test := range tests {
+		if err := CheckTolerance(test.tolerance); (err != nil) != test.wantErr {
+			t.Errorf("CheckTolerance(%v) = %v, want error %v", test.tolerance, err, test.wantErr)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      Mode
+		expected  string
+		actual    string
+		tolerance float64
+		want      bool
+	}{
+		{"exact match", Exact, "1 2\n", "1 2\n", 0, true},
+		{"exact trailing newline", Exact, "1 2\n", "1 2", 0, false},
+		{"exact trailing space", Exact, "1 2", "1 2 ", 0, false},
+
+		{"trim trailing spaces", TrimTrailingWhitespace, "a\nb\n", "a  \nb\t\n", 0, true},
+		{"trim trailing empty lines", TrimTrailingWhitespace, "a\n", "a\n\n\n", 0, true},
+		{"trim crlf", TrimTrailingWhitespace, "a\nb", "a\r\nb\r\n", 0, true},
+		{"trim keeps leading spaces", TrimTrailingWhitespace, "a", " a", 0, false},
+		{"trim keeps blank lines inside", TrimTrailingWhitespace, "a\nb", "a\n\nb", 0, false},
+		{"trim empty expected", TrimTrailingWhitespace, "", "\n", 0, true},
+		{"trim empty expected with output", TrimTrailingWhitespace, "", "x", 0, false},
+
+		{"ignore blank lines", IgnoreBlankLines, "a\nb\n", "\na\n\n  \nb\n", 0, true},
+		{"ignore blank lines different", IgnoreBlankLines, "a\nb", "a\nc", 0, false},
+
+		{"float equal tokens", FloatTolerance, "1 2 3", "1\n2  3\n", 0, true},
+		{"float within default", FloatTolerance, "0.1", "0.1000000001", 0, true},
+		{"float outside default", FloatTolerance, "0.1", "0.1001", 0, false},
+		{"float within given", FloatTolerance, "0.1", "0.1001", 1e-3, true},
+		{"float relative", FloatTolerance, "1000000", "1000000.5", 1e-6, true},
+		{"float words must match", FloatTolerance, "yes 1.0", "no 1.0", 0, false},
+		{"float word against number", FloatTolerance, "1.0", "one", 0, false},
+		{"float token count", FloatTolerance, "1 2", "1 2 3", 0, false},
+		{"float nan matches nan", FloatTolerance, "nan", "NaN", 0, true},
+		{"float nan against number", FloatTolerance, "nan", "0", 1, false},
+		{"float inf matches inf", FloatTolerance, "inf", "+Inf", 0, true},
+		{"float inf against large", FloatTolerance, "inf", "1e308", 1, false},
+		{"float negative tolerance uses default", FloatTolerance, "0.1", "0.1000000001", -1, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Compare(test.mode, test.expected, test.actual, test.tolerance); got != test.want {
+				t.Errorf("Compare(%s, %q, %q, %v) = %v, want %v", test.mode, test.expected, test.actual, test.tolerance, got, test.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	empty, hello := "", "hello"
+	tests := []struct {
+		name     string
+		status   enums.Status
+		stdout   string
+		expected *string
+		want     enums.Status
+	}{
+		{"nothing expected", enums.Accepted, "anything", nil, enums.Accepted},
+		{"matching output", enums.Accepted, "hello\n", &hello, enums.Accepted},
+		{"wrong output", enums.Accepted, "bye\n", &hello, enums.WrongAnswer},
+		{"empty expected and no output", enums.Accepted, "", &empty, enums.Accepted},
+		{"empty expected with output", enums.Accepted, "x", &empty, enums.WrongAnswer},
+		{"other verdicts are kept", enums.TimeLimitExceeded, "bye", &hello, enums.TimeLimitExceeded},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := results.ExecutionResult{Status: results.NewStatus(test.status), Stdout: test.stdout}
+			Apply(&result, test.expected, TrimTrailingWhitespace, 0)
+			if result.Status != results.NewStatus(test.want) {
+				t.Errorf("status = %s, want %s", result.Status.Name, results.NewStatus(test.want).Name)
+			}
+		})
+	}
+}
+
+// echoExecutor compiles once and echoes each stdin, or fails to compile
+type echoExecutor struct {
+	compiles       int
+	executes       int
+	compileFailure bool
+}
+
+func (e *echoExecutor) Compile(ctx context.Context, submission runner.Submission) (results.ExecutionResult, bool) {
+	e.compiles++
+	if e.compileFailure {
+		return results.ExecutionResult{Status: results.NewStatus(enums.CompilationError), CompileOutput: "error"}, false
+	}
+	return results.ExecutionResult{Status: results.NewStatus(enums.Accepted), CompileOutput: "warning"}, true
+}
+
+func (e *echoExecutor) Execute(ctx context.Context, submission runner.Submission, stdin string) results.ExecutionResult {
+	e.executes++
+	return results.ExecutionResult{Status: results.NewStatus(enums.Accepted), Stdout: stdin, Time: 1, CpuTime: 0.5, Memory: int64(len(stdin))}
+}
+
+func TestRunTestCases(t *testing.T) {
+	a, b := "a", "b"
+	testCases := []submissions.TestCase{
+		{StdIn: "a", ExpectedOutput: &a},
+		{StdIn: "bbb", ExpectedOutput: &b},
+		{StdIn: "cc"},
+	}
+
+	executor := &echoExecutor{}
+	result := RunTestCases(context.Background(), executor, runner.Submission{}, testCases, Exact, 0)
+	if executor.compiles != 1 || executor.executes != len(testCases) {
+		t.Fatalf("compiled %d and executed %d times, want 1 and %d", executor.compiles, executor.executes, len(testCases))
+	}
+	if result.Status != results.NewStatus(enums.WrongAnswer) {
+		t.Errorf("status = %s, want the first failing case's Wrong Answer", result.Status.Name)
+	}
+	if result.CompileOutput != "warning" {
+		t.Errorf("compile output = %q, want the compiler's warning", result.CompileOutput)
+	}
+	if len(result.TestCases) != len(testCases) {
+		t.Fatalf("got %d test case results, want %d", len(result.TestCases), len(testCases))
+	}
+	want := []enums.Status{enums.Accepted, enums.WrongAnswer, enums.Accepted}
+	for i, testCase := range result.TestCases {
+		if testCase.Index != i || testCase.Status != results.NewStatus(want[i]) {
+			t.Errorf("test case %d = #%d %s, want #%d %s", i, testCase.Index, testCase.Status.Name, i, results.NewStatus(want[i]).Name)
+		}
+	}
+	if result.Time != 3 || result.CpuTime != 1.5 || result.Memory != 3 {
+		t.Errorf("time %v, cpu time %v, memory %d; want the summed times and the peak memory", result.Time, result.CpuTime, result.Memory)
+	}
+
+	failing := &echoExecutor{compileFailure: true}
+	result = RunTestCases(context.Background(), failing, runner.Submission{}, testCases, Exact, 0)
+	if result.Status != results.NewStatus(enums.CompilationError) || failing.executes != 0 {
+		t.Errorf("status = %s after %d runs, want Compilation Error without running", result.Status.Name, failing.executes)
+	}
+}
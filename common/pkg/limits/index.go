@@ -0,0 +1,97 @@
+package limits
+
+import (
+	"fmt"
+	"go-compiler/models/languages"
+	"go-compiler/models/submissions"
+)
+
+// Default is applied to every limit a submission leaves unset
+var Default = submissions.Limits{
+	CpuTimeLimit:     5,
+	WallTimeLimit:    10,
+	MemoryLimit:      256000,
+	MaxProcesses:     64,
+	MaxOutputSize:    1024,
+	CompileTimeLimit: 10,
+}
+
+// Maximum is the largest value a submission may request for each limit
+var Maximum = submissions.Limits{
+	CpuTimeLimit:     15,
+	WallTimeLimit:    30,
+	MemoryLimit:      512000,
+	MaxProcesses:     128,
+	MaxOutputSize:    4096,
+	CompileTimeLimit: 30,
+}
+
+// Compile bounds the compile step of every submission, which submissions cannot change. Compilers
+// need more memory than most programs, and only enough of their output to read the errors is kept.
+// Only MemoryLimit, in KB, and MaxOutputSize, in KB, are used; the time limit is CompileTimeLimit.
+var Compile = submissions.Limits{
+	MemoryLimit:   1048576,
+	MaxOutputSize: 64,
+}
+
+// ForLanguage fills an unset compile time limit from the language's own default, capped at Maximum,
+// before Resolve fills the rest
+func ForLanguage(requested submissions.Limits, language languages.LanguageModel) submissions.Limits {
+	if requested.CompileTimeLimit == 0 && language.CompileTimeLimit > 0 {
+		requested.CompileTimeLimit = min(language.CompileTimeLimit, Maximum.CompileTimeLimit)
+	}
+	return requested
+}
+
+// Resolve fills unset limits from Default and rejects negative values or values above Maximum
+func Resolve(requested submissions.Limits) (submissions.Limits, error) {
+	resolved := requested
+	if resolved.CpuTimeLimit == 0 {
+		resolved.CpuTimeLimit = Default.CpuTimeLimit
+	}
+	if resolved.WallTimeLimit == 0 {
+		resolved.WallTimeLimit = Default.WallTimeLimit
+	}
+	if resolved.MemoryLimit == 0 {
+		resolved.MemoryLimit = Default.MemoryLimit
+	}
+	if resolved.MaxProcesses == 0 {
+		resolved.MaxProcesses = Default.MaxProcesses
+	}
+	if resolved.MaxOutputSize == 0 {
+		resolved.MaxOutputSize = Default.MaxOutputSize
+	}
+	if resolved.CompileTimeLimit == 0 {
+		resolved.CompileTimeLimit = Default.CompileTimeLimit
+	}
+
+	if err := check("cpu_time_limit", resolved.CpuTimeLimit, Maximum.CpuTimeLimit); err != nil {
+		return resolved, err
+	}
+	if err := check("wall_time_limit", resolved.WallTimeLimit, Maximum.WallTimeLimit); err != nil {
+		return resolved, err
+	}
+	if err := check("memory_limit", float64(resolved.MemoryLimit), float64(Maximum.MemoryLimit)); err != nil {
+		return resolved, err
+	}
+	if err := check("max_processes", float64(resolved.MaxProcesses), float64(Maximum.MaxProcesses)); err != nil {
+		return resolved, err
+	}
+	if err := check("max_output_size", float64(resolved.MaxOutputSize), float64(Maximum.MaxOutputSize)); err != nil {
+		return resolved, err
+	}
+	if err := check("compile_time_limit", resolved.CompileTimeLimit, Maximum.CompileTimeLimit); err != nil {
+		return resolved, err
+	}
+	return resolved, nil
+}
+
+func check(name string, value float64, maximum float64) error {
+	if value < 0 {
+		return fmt.Errorf("%s must not be negative", name)
+	}
+	if value > maximum {
+		return fmt.Errorf("%s %v exceeds the maximum of %v", name, value, maximum)
+	}
+	return nil
+}
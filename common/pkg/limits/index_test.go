@@ -0,0 +1,77 @@
+package limits
+
+import (
+	"go-compiler/models/languages"
+	"go-compiler/models/submissions"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested submissions.Limits
+		want      submissions.Limits
+		wantErr   bool
+	}{
+		{
+			name: "unset limits take the defaults",
+			want: Default,
+		},
+		{
+			name:      "set limits are kept",
+			requested: submissions.Limits{CpuTimeLimit: 1, MemoryLimit: 1024},
+			want: submissions.Limits{
+				CpuTimeLimit:     1,
+				WallTimeLimit:    Default.WallTimeLimit,
+				MemoryLimit:      1024,
+				MaxProcesses:     Default.MaxProcesses,
+				MaxOutputSize:    Default.MaxOutputSize,
+				CompileTimeLimit: Default.CompileTimeLimit,
+			},
+		},
+		{
+			name:      "limits at the maximum are allowed",
+			requested: Maximum,
+			want:      Maximum,
+		},
+		{name: "negative cpu time", requested: submissions.Limits{CpuTimeLimit: -1}, wantErr: true},
+		{name: "negative memory", requested: submissions.Limits{MemoryLimit: -1}, wantErr: true},
+		{name: "wall time above the maximum", requested: submissions.Limits{WallTimeLimit: Maximum.WallTimeLimit + 1}, wantErr: true},
+		{name: "processes above the maximum", requested: submissions.Limits{MaxProcesses: Maximum.MaxProcesses + 1}, wantErr: true},
+		{name: "output above the maximum", requested: submissions.Limits{MaxOutputSize: Maximum.MaxOutputSize + 1}, wantErr: true},
+		{name: "compile time above the maximum", requested: submissions.Limits{CompileTimeLimit: Maximum.CompileTimeLimit + 1}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Resolve(test.requested)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Resolve(%+v) error = %v, want error %v", test.requested, err, test.wantErr)
+			}
+			if !test.wantErr && got != test.want {
+				t.Errorf("Resolve(%+v) = %+v, want %+v", test.requested, got, test.want)
+			}
+		})
+	}
+}
+
+func TestForLanguage(t *testing.T) {
+	slow := languages.LanguageModel{CompileTimeLimit: 20}
+	tests := []struct {
+		name      string
+		requested submissions.Limits
+		language  languages.LanguageModel
+		want      float64
+	}{
+		{"language without a default", submissions.Limits{}, languages.LanguageModel{}, 0},
+		{"language default fills an unset limit", submissions.Limits{}, slow, 20},
+		{"submission limit wins", submissions.Limits{CompileTimeLimit: 5}, slow, 5},
+		{"language default is capped", submissions.Limits{}, languages.LanguageModel{CompileTimeLimit: Maximum.CompileTimeLimit + 10}, Maximum.CompileTimeLimit},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ForLanguage(test.requested, test.language).CompileTimeLimit; got != test.want {
+				t.Errorf("compile time limit = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package registry
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"go-compiler/models/languages"
+	"os"
+	"sort"
+)
+
+// ConfigPathEnv points at a languages config file that replaces the embedded default
+const ConfigPathEnv = "LANGUAGES_CONFIG"
+
+//go:embed languages.json
+var defaultConfig []byte
+
+// ILanguageRegistry defines the lookup used by executors to dispatch a submission
+type ILanguageRegistry interface {
+	Get(languageId int64) (languages.LanguageModel, bool)
+	List() []languages.LanguageModel
+}
+
+// Registry holds every supported language keyed by its language ID
+type Registry struct {
+	languages map[int64]languages.LanguageModel
+}
+
+type config struct {
+	Languages []languages.LanguageModel `json:"languages"`
+}
+
+// NewRegistry validates the given languages and builds a Registry from them
+func NewRegistry(models []languages.LanguageModel) (*Registry, error) {
+	registry := &Registry{
+		languages: make(map[int64]languages.LanguageModel, len(models)),
+	}
+	for _, language := range models {
+		if language.LanguageId <= 0 {
+			return nil, fmt.Errorf("language %q has invalid id %d", language.Name, language.LanguageId)
+		}
+		if _, exists := registry.languages[language.LanguageId]; exists {
+			return nil, fmt.Errorf("duplicate language id %d", language.LanguageId)
+		}
+		if language.Name == "" || language.SourceFile == "" {
+			return nil, fmt.Errorf("language %d must define a name and a source file", language.LanguageId)
+		}
+		if len(language.RunCmd) == 0 {
+			return nil, fmt.Errorf("language %d must define a run command", language.LanguageId)
+		}
+		if len(language.CompilerOptions) > 0 && !language.IsCompiled() {
+			return nil, fmt.Errorf("language %d defines compiler options but no compile command", language.LanguageId)
+		}
+		registry.languages[language.LanguageId] = language
+	}
+	return registry, nil
+}
+
+// Parse builds a Registry from the JSON contents of a languages config file
+func Parse(data []byte) (*Registry, error) {
+	var cfg config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse languages config: %v", err)
+	}
+	return NewRegistry(cfg.Languages)
+}
+
+// Load reads and parses the languages config file at path
+func Load(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read languages config %s: %v", path, err)
+	}
+	return Parse(data)
+}
+
+// NewDefaultRegistry loads the config file named by LANGUAGES_CONFIG, falling back to the embedded defaults
+func NewDefaultRegistry() (*Registry, error) {
+	if path := os.Getenv(ConfigPathEnv); path != "" {
+		return Load(path)
+	}
+	return Parse(defaultConfig)
+}
+
+// Get returns the language registered under languageId
+func (r *Registry) Get(languageId int64) (languages.LanguageModel, bool) {
+	language, found := r.languages[languageId]
+	return language, found
+}
+
+// List returns every registered language ordered by ID
+func (r *Registry) List() []languages.LanguageModel {
+	list := make([]languages.LanguageModel, 0, len(r.languages))
+	for _, language := range r.languages {
+		list = append(list, language)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].LanguageId < list[j].LanguageId
+	})
+	return list
+}
@@ -0,0 +1,75 @@
+package registry
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultRegistry(t *testing.T) {
+	registry, err := Parse(defaultConfig)
+	if err != nil {
+		t.Fatalf("the embedded languages config is invalid: %v", err)
+	}
+	// IDs clients already send must keep their language
+	for id, name := range map[int64]string{1: "Python", 2: "JavaScript", 3: "Bash", 4: "Java", 5: "Go"} {
+		language, found := registry.Get(id)
+		if !found || language.Name != name {
+			t.Errorf("language %d = %q, want %s", id, language.Name, name)
+		}
+	}
+	list := registry.List()
+	for i := 1; i < len(list); i++ {
+		if list[i-1].LanguageId >= list[i].LanguageId {
+			t.Fatalf("List is not ordered by ID: %d before %d", list[i-1].LanguageId, list[i].LanguageId)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name:   "interpreted and compiled languages",
+			config: `{"languages": [{"language_id": 1, "name": "Python", "source_file": "main.py", "run_cmd": ["python3", "main.py"]}, {"language_id": 7, "name": "C++", "source_file": "main.cpp", "compile_cmd": ["g++", "{options}", "main.cpp"], "run_cmd": ["./a.out"], "compiler_options": ["-O2"]}]}`,
+		},
+		{name: "invalid json", config: `{"languages": [`, wantErr: "failed to parse"},
+		{name: "zero id", config: `{"languages": [{"name": "Python", "source_file": "main.py", "run_cmd": ["python3"]}]}`, wantErr: "invalid id"},
+		{name: "negative id", config: `{"languages": [{"language_id": -1, "name": "Python", "source_file": "main.py", "run_cmd": ["python3"]}]}`, wantErr: "invalid id"},
+		{
+			name:    "duplicate id",
+			config:  `{"languages": [{"language_id": 1, "name": "Python", "source_file": "main.py", "run_cmd": ["python3"]}, {"language_id": 1, "name": "Ruby", "source_file": "main.rb", "run_cmd": ["ruby"]}]}`,
+			wantErr: "duplicate language id 1",
+		},
+		{name: "missing name", config: `{"languages": [{"language_id": 1, "source_file": "main.py", "run_cmd": ["python3"]}]}`, wantErr: "name and a source file"},
+		{name: "missing source file", config: `{"languages": [{"language_id": 1, "name": "Python", "run_cmd": ["python3"]}]}`, wantErr: "name and a source file"},
+		{name: "missing run command", config: `{"languages": [{"language_id": 1, "name": "Python", "source_file": "main.py"}]}`, wantErr: "run command"},
+		{
+			name:    "options without a compile command",
+			config:  `{"languages": [{"language_id": 1, "name": "Python", "source_file": "main.py", "run_cmd": ["python3"], "compiler_options": ["-O"]}]}`,
+			wantErr: "no compile command",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			registry, err := Parse([]byte(test.config))
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("Parse error = %v, want one containing %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if _, found := registry.Get(7); !found {
+				t.Error("Parse dropped language 7")
+			}
+			if _, found := registry.Get(2); found {
+				t.Error("Parse returned a language that is not configured")
+			}
+		})
+	}
+}
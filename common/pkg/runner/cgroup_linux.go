@@ -0,0 +1,131 @@
+//go:build linux
+
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"go-compiler/models/submissions"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// CgroupRootEnv names a writable cgroup v2 directory. When it is set every run gets its own child
+// cgroup there, which enforces the memory and process limits and reports OOM kills precisely.
+const CgroupRootEnv = "EXECUTOR_CGROUP_ROOT"
+
+// CgroupEnabled reports whether runs get their own cgroup. Without one the process limit is an
+// RLIMIT_NPROC, which counts every process of the user programs run as, so concurrent runs share it.
+func CgroupEnabled() bool {
+	return os.Getenv(CgroupRootEnv) != ""
+}
+
+type cgroup struct {
+	path string
+	fd   int
+}
+
+// newCgroup creates a child cgroup holding limits, or returns nil when no cgroup root is configured
+func newCgroup(limits submissions.Limits) (*cgroup, error) {
+	root := os.Getenv(CgroupRootEnv)
+	if root == "" || limits == (submissions.Limits{}) {
+		return nil, nil
+	}
+
+	path, err := os.MkdirTemp(root, "run-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cgroup: %v", err)
+	}
+	group := &cgroup{path: path, fd: -1}
+
+	if limits.MemoryLimit > 0 {
+		if err := group.write("memory.max", strconv.FormatInt(limits.MemoryLimit*1024, 10)); err != nil {
+			group.close()
+			return nil, err
+		}
+		// Swap accounting is not enabled everywhere, so a missing memory.swap.max is not an error
+		group.write("memory.swap.max", "0")
+	}
+	if limits.MaxProcesses > 0 {
+		if err := group.write("pids.max", strconv.Itoa(limits.MaxProcesses)); err != nil {
+			group.close()
+			return nil, err
+		}
+	}
+
+	group.fd, err = syscall.Open(path, syscall.O_RDONLY|syscall.O_DIRECTORY, 0)
+	if err != nil {
+		group.close()
+		return nil, fmt.Errorf("failed to open cgroup %s: %v", path, err)
+	}
+	return group, nil
+}
+
+// apply starts the process directly inside the cgroup
+func (c *cgroup) apply(attr *syscall.SysProcAttr) {
+	if c == nil {
+		return
+	}
+	attr.UseCgroupFD = true
+	attr.CgroupFD = c.fd
+}
+
+// oomKilled reports whether the kernel killed a process in the cgroup for exceeding memory.max
+func (c *cgroup) oomKilled() bool {
+	if c == nil {
+		return false
+	}
+	file, err := os.Open(filepath.Join(c.path, "memory.events"))
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "oom_kill" {
+			return fields[1] != "0"
+		}
+	}
+	return false
+}
+
+// peakMemory returns the cgroup's peak memory usage in KB, or 0 when the kernel does not report it
+func (c *cgroup) peakMemory() int64 {
+	if c == nil {
+		return 0
+	}
+	data, err := os.ReadFile(filepath.Join(c.path, "memory.peak"))
+	if err != nil {
+		return 0
+	}
+	peak, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return peak / 1024
+}
+
+// close kills anything left in the cgroup and removes it
+func (c *cgroup) close() {
+	if c == nil {
+		return
+	}
+	if c.fd >= 0 {
+		syscall.Close(c.fd)
+	}
+	c.write("cgroup.kill", "1")
+	os.Remove(c.path)
+}
+
+func (c *cgroup) write(file string, value string) error {
+	err := os.WriteFile(filepath.Join(c.path, file), []byte(value), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write cgroup %s: %v", file, err)
+	}
+	return nil
+}
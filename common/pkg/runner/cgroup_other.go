@@ -0,0 +1,31 @@
+//go:build !linux
+
+package runner
+
+import (
+	"go-compiler/models/submissions"
+	"syscall"
+)
+
+// cgroup is only available on Linux; elsewhere limits are enforced with rlimits alone
+type cgroup struct{}
+
+func CgroupEnabled() bool {
+	return false
+}
+
+func newCgroup(limits submissions.Limits) (*cgroup, error) {
+	return nil, nil
+}
+
+func (c *cgroup) apply(attr *syscall.SysProcAttr) {}
+
+func (c *cgroup) oomKilled() bool {
+	return false
+}
+
+func (c *cgroup) peakMemory() int64 {
+	return 0
+}
+
+func (c *cgroup) close() {}
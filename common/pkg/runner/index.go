@@ -0,0 +1,433 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-compiler/common/pkg/enums"
+	"go-compiler/common/pkg/limits"
+	"go-compiler/common/pkg/workspace"
+	"go-compiler/models/languages"
+	"go-compiler/models/results"
+	"go-compiler/models/submissions"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Submission is a single program to compile and run inside WorkDir
+type Submission struct {
+	Language languages.LanguageModel
+	Code     []byte
+	StdIn    string
+	WorkDir  string
+	Limits   submissions.Limits
+
+	// Files are written to WorkDir before Code, which is the language's source file. When Code is
+	// empty the source file must be one of the files.
+	Files map[string][]byte
+
+	// CompilerOptions are added to the compile command and must be allowed by the language
+	CompilerOptions []string
+
+	// Isolation, when set, confines both the compile and the run step
+	Isolation Isolation
+
+	// Output, when set, receives the program's output as it is written, up to the output limit.
+	// Compiler output is not streamed.
+	Output OutputFunc
+
+	// Input, when set, is fed to the program's stdin after the stdin passed to Execute, while the
+	// program runs. The program sees the end of its input once Input reaches EOF.
+	Input io.Reader
+	// SessionLimit, when Input is set, replaces the wall time limit of runs that are fed Input, since
+	// someone typing needs longer. Executors that do not feed Input keep the wall time limit.
+	SessionLimit float64
+
+	// RequestId and Tenant identify the submission to backends that record them, such as the
+	// Kubernetes Jobs it runs in. Both are optional.
+	RequestId string
+	Tenant    string
+}
+
+// RunLimits returns the limits a run that is fed Input is bounded by: Limits, with the wall time
+// limit replaced by SessionLimit when both Input and SessionLimit are set
+func (s Submission) RunLimits() submissions.Limits {
+	runLimits := s.Limits
+	if s.Input != nil && s.SessionLimit > 0 {
+		runLimits.WallTimeLimit = s.SessionLimit
+	}
+	return runLimits
+}
+
+// OutputFunc receives a chunk of a program's output. It is called in the order the program wrote,
+// while the program waits, so it must return quickly; chunk is only valid during the call.
+type OutputFunc func(stream results.Stream, chunk []byte)
+
+// runOptions describes where and how one command of a submission runs
+type runOptions struct {
+	workDir   string
+	limits    submissions.Limits
+	isolation Isolation
+	writable  bool
+	output    OutputFunc
+	input     io.Reader
+
+	// outputLimit, when set, keeps that many bytes of output and drops the rest without stopping
+	// the command, in place of limits.MaxOutputSize
+	outputLimit int64
+}
+
+// process captures everything observed about one finished command
+type process struct {
+	stdout         string
+	stderr         string
+	state          *os.ProcessState
+	elapsed        time.Duration
+	cpuTime        time.Duration
+	memory         int64
+	timedOut       bool
+	outputExceeded bool
+	oomKilled      bool
+}
+
+// Executor compiles and runs submissions. Local runs them as processes on this host; other backends
+// run them elsewhere but report results the same way.
+type Executor interface {
+	Compile(ctx context.Context, submission Submission) (results.ExecutionResult, bool)
+	Execute(ctx context.Context, submission Submission, stdin string) results.ExecutionResult
+}
+
+// BatchExecutor is an Executor that runs a compiled submission against many stdins in one go, for
+// backends where every Execute would repeat work such as compiling. ExecuteAll returns one result
+// per stdin, in order, or false together with the failed result when the program cannot be run.
+type BatchExecutor interface {
+	Executor
+	ExecuteAll(ctx context.Context, submission Submission, stdins []string) ([]results.ExecutionResult, results.ExecutionResult, bool)
+}
+
+// ExecuteAll runs an already compiled submission once per stdin, in one go when the executor is a
+// BatchExecutor and with one Execute per stdin otherwise
+func ExecuteAll(ctx context.Context, executor Executor, submission Submission, stdins []string) ([]results.ExecutionResult, results.ExecutionResult, bool) {
+	if batch, ok := executor.(BatchExecutor); ok {
+		return batch.ExecuteAll(ctx, submission, stdins)
+	}
+	runs := make([]results.ExecutionResult, 0, len(stdins))
+	for _, stdin := range stdins {
+		run := executor.Execute(ctx, submission, stdin)
+		// Executors that compile as part of every run report compile failures here
+		if run.Status == results.NewStatus(enums.CompilationError) {
+			return nil, run, false
+		}
+		runs = append(runs, run)
+	}
+	return runs, results.ExecutionResult{}, true
+}
+
+// Local executes submissions as processes on this host, confined by the submission's Isolation
+type Local struct{}
+
+func (Local) Compile(ctx context.Context, submission Submission) (results.ExecutionResult, bool) {
+	return Compile(ctx, submission)
+}
+
+func (Local) Execute(ctx context.Context, submission Submission, stdin string) results.ExecutionResult {
+	return Execute(ctx, submission, stdin)
+}
+
+// Run writes the submission to its working directory, compiles it when the language requires it
+// and runs it, reporting the outcome as a structured result
+func Run(ctx context.Context, submission Submission) results.ExecutionResult {
+	return RunWith(ctx, Local{}, submission)
+}
+
+// RunWith compiles and runs the submission once with its own stdin on the given executor
+func RunWith(ctx context.Context, executor Executor, submission Submission) results.ExecutionResult {
+	compiled, ok := executor.Compile(ctx, submission)
+	if !ok {
+		return compiled
+	}
+
+	result := executor.Execute(ctx, submission, submission.StdIn)
+	if result.CompileOutput == "" {
+		result.CompileOutput = compiled.CompileOutput
+	}
+	return result
+}
+
+// Compile writes the submission to its working directory and compiles it when the language requires it.
+// It reports false, together with the failed result, when the program cannot be run.
+func Compile(ctx context.Context, submission Submission) (results.ExecutionResult, bool) {
+	language := submission.Language
+
+	if err := workspace.Write(submission.WorkDir, submission.Files); err != nil {
+		return InternalError(err), false
+	}
+	sourceFile := filepath.Join(submission.WorkDir, language.SourceFile)
+	if len(submission.Code) > 0 {
+		if err := os.WriteFile(sourceFile, submission.Code, 0644); err != nil {
+			return InternalError(fmt.Errorf("failed to write %s source file: %v", language.Name, err)), false
+		}
+	}
+
+	var result results.ExecutionResult
+	if _, err := os.Stat(sourceFile); err != nil {
+		result.Status = results.NewStatus(enums.CompilationError)
+		result.CompileOutput = fmt.Sprintf("missing source file %s", language.SourceFile)
+		return result, false
+	}
+	if !language.IsCompiled() {
+		return result, true
+	}
+
+	command, err := language.CompileCommand(submission.CompilerOptions)
+	if err != nil {
+		result.Status = results.NewStatus(enums.CompilationError)
+		result.Message = err.Error()
+		return result, false
+	}
+
+	// The compile step has its own limits; the compiler may need more than the program
+	compiled, err := runCommand(ctx, command, "", runOptions{
+		workDir: submission.WorkDir,
+		limits: submissions.Limits{
+			WallTimeLimit: submission.Limits.CompileTimeLimit,
+			MemoryLimit:   limits.Compile.MemoryLimit,
+		},
+		isolation:   submission.Isolation,
+		writable:    true,
+		outputLimit: limits.Compile.MaxOutputSize * 1024,
+	})
+	if err != nil {
+		return InternalError(fmt.Errorf("failed to start %s compiler: %v", language.Name, err)), false
+	}
+	result.CompileOutput = compiled.stdout + compiled.stderr
+	if compiled.outputExceeded {
+		result.CompileOutput = TruncatedCompileOutput(result.CompileOutput)
+	}
+	switch {
+	case compiled.timedOut:
+		result.Status = results.NewStatus(enums.CompilationError)
+		result.Message = fmt.Sprintf("compilation exceeded the time limit of %vs", submission.Limits.CompileTimeLimit)
+		return result, false
+	case compiled.oomKilled:
+		result.Status = results.NewStatus(enums.CompilationError)
+		result.Message = fmt.Sprintf("compilation exceeded the memory limit of %d KB", limits.Compile.MemoryLimit)
+		return result, false
+	}
+	if !compiled.state.Success() {
+		result.Status = results.NewStatus(enums.CompilationError)
+		result.ExitCode = compiled.state.ExitCode()
+		return result, false
+	}
+	return result, true
+}
+
+// Execute runs an already compiled submission once with the given stdin under the submission's limits
+func Execute(ctx context.Context, submission Submission, stdin string) results.ExecutionResult {
+	language := submission.Language
+	runLimits := submission.RunLimits()
+
+	ran, err := runCommand(ctx, language.RunCmd, stdin, runOptions{
+		workDir:   submission.WorkDir,
+		limits:    runLimits,
+		isolation: submission.Isolation,
+		output:    submission.Output,
+		input:     submission.Input,
+	})
+	if err != nil {
+		return InternalError(fmt.Errorf("failed to start %s program: %v", language.Name, err))
+	}
+
+	status, exitCode, signal := verdict(ran, runLimits)
+	return results.ExecutionResult{
+		Status:   results.NewStatus(status),
+		Stdout:   ran.stdout,
+		Stderr:   ran.stderr,
+		ExitCode: exitCode,
+		Signal:   signal,
+		Time:     ran.elapsed.Seconds(),
+		CpuTime:  ran.cpuTime.Seconds(),
+		Memory:   ran.memory,
+	}
+}
+
+// TruncatedCompileOutput marks compiler output that was cut at limits.Compile.MaxOutputSize
+func TruncatedCompileOutput(output string) string {
+	return output + fmt.Sprintf("\n[compiler output truncated at %d KB]", limits.Compile.MaxOutputSize)
+}
+
+// InternalError reports a failure of the executor itself rather than of the submitted program
+func InternalError(err error) results.ExecutionResult {
+	return results.ExecutionResult{
+		Status:  results.NewStatus(enums.InternalError),
+		Message: err.Error(),
+	}
+}
+
+// runCommand runs command under the given options and waits for it to exit. An error is only
+// returned when the process could not be started; non-zero exits and limit violations are reported
+// through the returned process.
+func runCommand(ctx context.Context, command []string, stdin string, options runOptions) (*process, error) {
+	limits := options.limits
+	group, err := newCgroup(limits)
+	if err != nil {
+		return nil, err
+	}
+	defer group.close()
+
+	spec := initSpec{
+		Limits:   limits,
+		WorkDir:  options.workDir,
+		Writable: options.writable,
+	}
+	// Limits the cgroup enforces are not also applied as rlimits, so exceeding them is reported precisely
+	if group != nil {
+		spec.Limits.MemoryLimit = 0
+		spec.Limits.MaxProcesses = 0
+	}
+	if options.isolation != nil {
+		spec.Isolation = options.isolation.Name()
+		spec.Options, err = json.Marshal(options.isolation)
+		if err != nil {
+			return nil, err
+		}
+	}
+	argv, err := wrap(command, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = options.workDir
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	group.apply(cmd.SysProcAttr)
+	cmd.Cancel = func() error {
+		return killGroup(cmd)
+	}
+	if options.isolation != nil {
+		cleanup, err := options.isolation.Prepare(cmd, options.workDir)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+	}
+
+	outputLimit, onExceeded := limits.MaxOutputSize*1024, func() { killGroup(cmd) }
+	if options.outputLimit > 0 {
+		outputLimit, onExceeded = options.outputLimit, func() {}
+	}
+	output := NewOutputLimiter(outputLimit, options.output, onExceeded)
+	cmd.Stdout = output.Stdout
+	cmd.Stderr = output.Stderr
+	var input io.WriteCloser
+	if options.input != nil {
+		// Wait would otherwise block until Input ends, even after the program exited
+		input, err = cmd.StdinPipe()
+		if err != nil {
+			return nil, err
+		}
+	} else if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	if input != nil {
+		go feed(input, io.MultiReader(strings.NewReader(stdin), options.input))
+	}
+
+	var timedOut atomic.Bool
+	if limits.WallTimeLimit > 0 {
+		timer := time.AfterFunc(seconds(limits.WallTimeLimit), func() {
+			timedOut.Store(true)
+			killGroup(cmd)
+		})
+		defer timer.Stop()
+	}
+
+	err = cmd.Wait()
+	elapsed := time.Since(start)
+	if err != nil && cmd.ProcessState == nil {
+		return nil, err
+	}
+
+	memory := group.peakMemory()
+	if memory == 0 {
+		memory = maxRSS(cmd.ProcessState)
+	}
+
+	return &process{
+		stdout:         output.Stdout.String(),
+		stderr:         output.Stderr.String(),
+		state:          cmd.ProcessState,
+		elapsed:        elapsed,
+		cpuTime:        cmd.ProcessState.UserTime() + cmd.ProcessState.SystemTime(),
+		memory:         memory,
+		timedOut:       timedOut.Load(),
+		outputExceeded: output.Exceeded(),
+		oomKilled:      group.oomKilled(),
+	}, nil
+}
+
+// feed copies input to the program's stdin until input ends or the program stops reading, then
+// closes stdin. Wait closes the pipe once the program exits, which ends the copy at its next write.
+func feed(stdin io.WriteCloser, input io.Reader) {
+	io.Copy(stdin, input)
+	stdin.Close()
+}
+
+// killGroup kills the command's whole process group so nothing it forked outlives it
+func killGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// verdict maps how a program exited to a status, its exit code and the signal that killed it
+func verdict(ran *process, limits submissions.Limits) (enums.Status, int, int) {
+	exitCode := ran.state.ExitCode()
+	signal := 0
+	if ws, ok := ran.state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		signal = int(ws.Signal())
+	}
+
+	switch {
+	case ran.outputExceeded:
+		return enums.RuntimeErrorSIGXFSZ, exitCode, signal
+	case ran.timedOut, signal == int(syscall.SIGXCPU):
+		return enums.TimeLimitExceeded, exitCode, signal
+	case limits.CpuTimeLimit > 0 && ran.cpuTime > seconds(limits.CpuTimeLimit):
+		return enums.TimeLimitExceeded, exitCode, signal
+	case ran.oomKilled:
+		return enums.MemoryLimitExceeded, exitCode, signal
+	case limits.MemoryLimit > 0 && ran.memory > limits.MemoryLimit:
+		return enums.MemoryLimitExceeded, exitCode, signal
+	case signal != 0:
+		return enums.FindRuntimeErrorByStatusCode(signal), exitCode, signal
+	case exitCode != 0:
+		return enums.RuntimeErrorNZEC, exitCode, signal
+	default:
+		return enums.Accepted, exitCode, signal
+	}
+}
+
+// maxRSS returns the peak resident memory of the finished process in KB
+func maxRSS(state *os.ProcessState) int64 {
+	if usage, ok := state.SysUsage().(*syscall.Rusage); ok {
+		return int64(usage.Maxrss)
+	}
+	return 0
+}
+
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
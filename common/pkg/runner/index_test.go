@@ -0,0 +1,50 @@
+package runner
+
+import (
+	"go-compiler/models/submissions"
+	"strings"
+	"testing"
+)
+
+func TestRunLimits(t *testing.T) {
+	limits := submissions.Limits{CpuTimeLimit: 2, WallTimeLimit: 5, MemoryLimit: 65536}
+	tests := []struct {
+		name         string
+		submission   Submission
+		wantWallTime float64
+	}{
+		{
+			name:         "batch",
+			submission:   Submission{Limits: limits},
+			wantWallTime: 5,
+		},
+		{
+			name:         "batch with a session limit",
+			submission:   Submission{Limits: limits, SessionLimit: 300},
+			wantWallTime: 5,
+		},
+		{
+			name:         "input without a session limit",
+			submission:   Submission{Limits: limits, Input: strings.NewReader("1\n")},
+			wantWallTime: 5,
+		},
+		{
+			name:         "interactive",
+			submission:   Submission{Limits: limits, Input: strings.NewReader("1\n"), SessionLimit: 300},
+			wantWallTime: 300,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.submission.RunLimits()
+			if got.WallTimeLimit != test.wantWallTime {
+				t.Errorf("wall time limit = %v, want %v", got.WallTimeLimit, test.wantWallTime)
+			}
+			// Only the wall time limit gives way to the session
+			got.WallTimeLimit = limits.WallTimeLimit
+			if got != limits {
+				t.Errorf("RunLimits() = %+v, want the other limits kept", got)
+			}
+		})
+	}
+}
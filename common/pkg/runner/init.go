@@ -0,0 +1,115 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-compiler/models/submissions"
+	"math"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// initArg marks a re-executed copy of the current binary that must confine itself and exec the program
+const initArg = "__runner_init"
+
+// initFailureExitCode is returned when the helper could not confine itself or start the program
+const initFailureExitCode = 127
+
+// Init must be the first call in main of every binary that uses the runner. Limits and isolation are
+// applied by re-executing the current binary, which applies them to itself and then replaces itself
+// with the submitted program; Init performs that step and never returns in the re-executed copy.
+func Init() {
+	if len(os.Args) < 4 || os.Args[1] != initArg {
+		return
+	}
+
+	// Confinement is per thread until exec, so everything must happen on this one
+	runtime.LockOSThread()
+
+	var spec initSpec
+	if err := json.Unmarshal([]byte(os.Args[2]), &spec); err != nil {
+		initFailed(fmt.Errorf("invalid spec: %v", err))
+	}
+	if err := setRlimits(spec.Limits); err != nil {
+		initFailed(err)
+	}
+	if spec.Isolation != "" {
+		enter, found := isolations[spec.Isolation]
+		if !found {
+			initFailed(fmt.Errorf("unknown isolation %q", spec.Isolation))
+		}
+		if err := enter(spec.Options, spec.WorkDir, spec.Writable); err != nil {
+			initFailed(err)
+		}
+	}
+
+	program := os.Args[3:]
+	path, err := exec.LookPath(program[0])
+	if err != nil {
+		initFailed(err)
+	}
+	initFailed(syscall.Exec(path, program, os.Environ()))
+}
+
+func initFailed(err error) {
+	fmt.Fprintf(os.Stderr, "runner: %v\n", err)
+	os.Exit(initFailureExitCode)
+}
+
+// wrap makes command start through Init so spec is applied first
+func wrap(command []string, spec initSpec) ([]string, error) {
+	if spec.Limits == (submissions.Limits{}) && spec.Isolation == "" {
+		return command, nil
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate executor binary: %v", err)
+	}
+	encoded, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{self, initArg, string(encoded)}, command...), nil
+}
+
+// setRlimits applies limits to the current process so the program it is about to exec inherits them.
+// The CPU hard limit is one second above the soft limit so the program first receives SIGXCPU.
+func setRlimits(limits submissions.Limits) error {
+	if limits.CpuTimeLimit > 0 {
+		cpu := uint64(math.Ceil(limits.CpuTimeLimit))
+		if err := setRlimit(unix.RLIMIT_CPU, cpu, cpu+1); err != nil {
+			return err
+		}
+	}
+	if limits.MemoryLimit > 0 {
+		memory := uint64(limits.MemoryLimit) * 1024
+		if err := setRlimit(unix.RLIMIT_DATA, memory, memory); err != nil {
+			return err
+		}
+	}
+	if limits.MaxProcesses > 0 {
+		processes := uint64(limits.MaxProcesses)
+		if err := setRlimit(unix.RLIMIT_NPROC, processes, processes); err != nil {
+			return err
+		}
+	}
+	if limits.MaxOutputSize > 0 {
+		size := uint64(limits.MaxOutputSize) * 1024
+		if err := setRlimit(unix.RLIMIT_FSIZE, size, size); err != nil {
+			return err
+		}
+	}
+	return setRlimit(unix.RLIMIT_CORE, 0, 0)
+}
+
+func setRlimit(resource int, soft uint64, hard uint64) error {
+	err := unix.Setrlimit(resource, &unix.Rlimit{Cur: soft, Max: hard})
+	if err != nil {
+		return fmt.Errorf("failed to set rlimit %d: %v", resource, err)
+	}
+	return nil
+}
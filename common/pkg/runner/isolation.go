@@ -0,0 +1,37 @@
+package runner
+
+import (
+	"encoding/json"
+	"go-compiler/models/submissions"
+	"os/exec"
+)
+
+// Isolation confines a submitted program to a sandbox. Prepare runs in the executor before the
+// command is started and returns a cleanup to run once it has exited. The isolation is then
+// marshalled to JSON and handed to the EnterFunc registered under its name, which runs inside the
+// re-executed helper right before the program is exec'd.
+type Isolation interface {
+	Name() string
+	Prepare(cmd *exec.Cmd, workDir string) (func(), error)
+}
+
+// EnterFunc confines the helper process itself. options holds the marshalled Isolation, workDir the
+// submission's working directory and writable whether the program may write to it.
+type EnterFunc func(options []byte, workDir string, writable bool) error
+
+var isolations = map[string]EnterFunc{}
+
+// RegisterIsolation makes an isolation available to the helper. It must be called from a package
+// init function so the registration also exists in the re-executed helper before Init runs.
+func RegisterIsolation(name string, enter EnterFunc) {
+	isolations[name] = enter
+}
+
+// initSpec is everything the helper needs to confine itself before it execs the program
+type initSpec struct {
+	Limits    submissions.Limits `json:"limits"`
+	Isolation string             `json:"isolation,omitempty"`
+	Options   json.RawMessage    `json:"options,omitempty"`
+	WorkDir   string             `json:"work_dir,omitempty"`
+	Writable  bool               `json:"writable,omitempty"`
+}
@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"bytes"
+	"go-compiler/models/results"
+	"sync"
+)
+
+// OutputLimiter caps the combined size of a program's stdout and stderr, written to Stdout and
+// Stderr. Once the cap is crossed further output is discarded and onExceeded is called exactly
+// once. Output that is kept is also handed to output, when set.
+type OutputLimiter struct {
+	mu         sync.Mutex
+	remaining  int64
+	limited    bool
+	exceeded   bool
+	output     OutputFunc
+	onExceeded func()
+
+	Stdout *LimitedBuffer
+	Stderr *LimitedBuffer
+}
+
+// LimitedBuffer keeps one stream's share of the output an OutputLimiter allows
+type LimitedBuffer struct {
+	limiter *OutputLimiter
+	stream  results.Stream
+	buffer  bytes.Buffer
+}
+
+// NewOutputLimiter allows limit bytes of output in total; a limit of zero means unlimited
+func NewOutputLimiter(limit int64, output OutputFunc, onExceeded func()) *OutputLimiter {
+	limiter := &OutputLimiter{
+		remaining:  limit,
+		limited:    limit > 0,
+		output:     output,
+		onExceeded: onExceeded,
+	}
+	limiter.Stdout = &LimitedBuffer{limiter: limiter, stream: results.StdoutStream}
+	limiter.Stderr = &LimitedBuffer{limiter: limiter, stream: results.StderrStream}
+	return limiter
+}
+
+// Exceeded reports whether the program tried to write more than the limit
+func (l *OutputLimiter) Exceeded() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.exceeded
+}
+
+// Write always reports the whole of p as written so the program is never blocked on a full pipe
+func (b *LimitedBuffer) Write(p []byte) (int, error) {
+	l := b.limiter
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.limited {
+		return b.keep(p), nil
+	}
+	if l.exceeded {
+		return len(p), nil
+	}
+	if int64(len(p)) > l.remaining {
+		b.keep(p[:l.remaining])
+		l.remaining = 0
+		l.exceeded = true
+		l.onExceeded()
+		return len(p), nil
+	}
+	l.remaining -= int64(len(p))
+	b.keep(p)
+	return len(p), nil
+}
+
+// keep buffers p and hands it to the limiter's output; the caller holds the limiter's lock, which
+// keeps the chunks of both streams in the order they were written
+func (b *LimitedBuffer) keep(p []byte) int {
+	b.buffer.Write(p)
+	if b.limiter.output != nil && len(p) > 0 {
+		b.limiter.output(b.stream, p)
+	}
+	return len(p)
+}
+
+func (b *LimitedBuffer) String() string {
+	b.limiter.mu.Lock()
+	defer b.limiter.mu.Unlock()
+	return b.buffer.String()
+}
@@ -0,0 +1,111 @@
+package sandbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-compiler/common/pkg/runner"
+	"os"
+	"strconv"
+)
+
+const (
+	// Name identifies the sandbox to the runner helper
+	Name = "sandbox"
+
+	// EnabledEnv turns the sandbox off when set to false, which is only meant for local development
+	EnabledEnv = "SANDBOX_ENABLED"
+	// UidEnv and GidEnv override the unprivileged user programs run as
+	UidEnv = "SANDBOX_UID"
+	GidEnv = "SANDBOX_GID"
+	// TmpSizeEnv overrides the size of the private /tmp in KB
+	TmpSizeEnv = "SANDBOX_TMP_SIZE"
+)
+
+// Config describes the environment a sandboxed program sees
+type Config struct {
+	Uid int `json:"uid"`
+	Gid int `json:"gid"`
+
+	// TmpSize is the size of the private /tmp in KB
+	TmpSize int64 `json:"tmp_size"`
+}
+
+// DefaultConfig runs programs as nobody with a 256 MB /tmp
+var DefaultConfig = Config{
+	Uid:     65534,
+	Gid:     65534,
+	TmpSize: 262144,
+}
+
+// Sandbox runs every command of a submission in fresh mount, PID, network, IPC and UTS namespaces
+// on a read-only root filesystem, as an unprivileged user and behind a seccomp filter. Only the
+// submission's working directory, mounted at /box, and a private /tmp are writable.
+type Sandbox struct {
+	Config
+}
+
+func init() {
+	runner.RegisterIsolation(Name, enter)
+}
+
+// NewSandbox returns a sandbox for config, failing when the host cannot provide one
+func NewSandbox(config Config) (*Sandbox, error) {
+	if err := supported(); err != nil {
+		return nil, err
+	}
+	return &Sandbox{Config: config}, nil
+}
+
+// Enabled reports whether the sandbox is turned on, which it is unless SANDBOX_ENABLED is false
+func Enabled() bool {
+	enabled, err := strconv.ParseBool(os.Getenv(EnabledEnv))
+	return err != nil || enabled
+}
+
+// ConfigFromEnv returns DefaultConfig with any overrides from the environment applied
+func ConfigFromEnv() (Config, error) {
+	config := DefaultConfig
+	if value := os.Getenv(UidEnv); value != "" {
+		uid, err := strconv.Atoi(value)
+		if err != nil {
+			return config, fmt.Errorf("invalid %s: %v", UidEnv, err)
+		}
+		config.Uid = uid
+	}
+	if value := os.Getenv(GidEnv); value != "" {
+		gid, err := strconv.Atoi(value)
+		if err != nil {
+			return config, fmt.Errorf("invalid %s: %v", GidEnv, err)
+		}
+		config.Gid = gid
+	}
+	if value := os.Getenv(TmpSizeEnv); value != "" {
+		size, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || size <= 0 {
+			return config, fmt.Errorf("invalid %s: %q", TmpSizeEnv, value)
+		}
+		config.TmpSize = size
+	}
+	if config.Uid == 0 || config.Gid == 0 {
+		return config, fmt.Errorf("sandboxed programs must not run as root")
+	}
+	return config, nil
+}
+
+func (s *Sandbox) Name() string {
+	return Name
+}
+
+// enter is the helper side of the sandbox, see runner.EnterFunc
+func enter(options []byte, workDir string, writable bool) error {
+	var config Config
+	if err := json.Unmarshal(options, &config); err != nil {
+		return fmt.Errorf("invalid sandbox options: %v", err)
+	}
+	return confine(config, workDir, writable)
+}
+
+// rootDir is the empty directory the sandbox's root filesystem is assembled on
+func rootDir(workDir string) string {
+	return workDir + ".root"
+}
@@ -0,0 +1,265 @@
+//go:build linux && (amd64 || arm64)
+
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// systemDirs are bind mounted read-only from the host so compilers and interpreters keep working
+var systemDirs = []string{"/bin", "/sbin", "/usr", "/lib", "/lib64", "/etc", "/opt"}
+
+// devices are the only device nodes a sandboxed program can open
+var devices = []string{"null", "zero", "random", "urandom"}
+
+const (
+	boxDir      = "/box"
+	oldDir      = "/.old"
+	defaultPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+)
+
+const namespaces = unix.CLONE_NEWNS | unix.CLONE_NEWPID | unix.CLONE_NEWNET | unix.CLONE_NEWIPC | unix.CLONE_NEWUTS
+
+func supported() error {
+	if os.Geteuid() != 0 {
+		return errors.New("the sandbox must be started as root to create namespaces and drop to an unprivileged user")
+	}
+	return nil
+}
+
+// Prepare hands the working directory to the sandbox user, creates the directory the sandbox root
+// is assembled on and starts the command in new namespaces
+func (s *Sandbox) Prepare(cmd *exec.Cmd, workDir string) (func(), error) {
+	err := filepath.WalkDir(workDir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Lchown(name, s.Uid, s.Gid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to hand %s to the sandbox user: %v", workDir, err)
+	}
+
+	root := rootDir(workDir)
+	if err := os.Mkdir(root, 0755); err != nil && !os.IsExist(err) {
+		return nil, fmt.Errorf("failed to create sandbox root: %v", err)
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Cloneflags |= namespaces
+
+	// Everything mounted on root lives in the command's mount namespace and is gone once it exits
+	return func() {
+		os.Remove(root)
+	}, nil
+}
+
+// confine runs in the helper, which is PID 1 of the new namespaces and still root. It assembles a
+// read-only root filesystem, pivots into it, drops to the sandbox user and installs the seccomp
+// filter, after which the helper execs the program.
+func confine(config Config, workDir string, writable bool) error {
+	root := rootDir(workDir)
+
+	// Keep every mount below out of the host's mount namespace
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("failed to make mounts private: %v", err)
+	}
+	if err := unix.Mount("tmpfs", root, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "size=1m,mode=0755"); err != nil {
+		return fmt.Errorf("failed to mount sandbox root: %v", err)
+	}
+
+	for _, dir := range systemDirs {
+		if err := mountSystemDir(root, dir); err != nil {
+			return err
+		}
+	}
+	if err := bind(workDir, filepath.Join(root, boxDir), !writable); err != nil {
+		return err
+	}
+	if err := mountTmp(root, config.TmpSize); err != nil {
+		return err
+	}
+	if err := mountProc(root); err != nil {
+		return err
+	}
+	if err := mountDev(root); err != nil {
+		return err
+	}
+	if err := pivot(root); err != nil {
+		return err
+	}
+	if err := unix.Sethostname([]byte(Name)); err != nil {
+		return fmt.Errorf("failed to set hostname: %v", err)
+	}
+
+	// Nothing from the executor's environment, such as credentials, may reach the program
+	path := sandboxPath(os.Getenv("PATH"))
+	os.Clearenv()
+	os.Setenv("PATH", path)
+	os.Setenv("HOME", "/tmp")
+	os.Setenv("LANG", "C.UTF-8")
+
+	if err := dropPrivileges(config); err != nil {
+		return err
+	}
+	return installSeccomp()
+}
+
+// sandboxPath keeps the entries of the executor's PATH that are visible inside the sandbox, such as
+// /usr/local/go/bin, so toolchains installed outside the default PATH are still found
+func sandboxPath(hostPath string) string {
+	path := defaultPath
+	for _, entry := range filepath.SplitList(hostPath) {
+		for _, dir := range systemDirs {
+			if strings.HasPrefix(entry, dir+"/") && !slices.Contains(filepath.SplitList(path), entry) {
+				path += string(filepath.ListSeparator) + entry
+			}
+		}
+	}
+	return path
+}
+
+// mountSystemDir bind mounts dir read-only, recreating it as a symlink when the host has one
+func mountSystemDir(root string, dir string) error {
+	info, err := os.Lstat(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	target := filepath.Join(root, dir)
+	if info.Mode()&os.ModeSymlink != 0 {
+		link, err := os.Readlink(dir)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(link, target)
+	}
+	return bind(dir, target, true)
+}
+
+func bind(source string, target string, readOnly bool) error {
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return err
+	}
+	if err := unix.Mount(source, target, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+		return fmt.Errorf("failed to bind %s: %v", source, err)
+	}
+	flags := uintptr(unix.MS_BIND | unix.MS_REMOUNT | unix.MS_NOSUID | unix.MS_NODEV)
+	if readOnly {
+		flags |= unix.MS_RDONLY
+	}
+	if err := unix.Mount("", target, "", flags, ""); err != nil {
+		return fmt.Errorf("failed to restrict %s: %v", source, err)
+	}
+	return nil
+}
+
+func mountTmp(root string, size int64) error {
+	target := filepath.Join(root, "tmp")
+	if err := os.Mkdir(target, 0755); err != nil {
+		return err
+	}
+	options := fmt.Sprintf("size=%dk,mode=1777", size)
+	if err := unix.Mount("tmpfs", target, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, options); err != nil {
+		return fmt.Errorf("failed to mount /tmp: %v", err)
+	}
+	return nil
+}
+
+// mountProc mounts a proc that only shows the sandbox's own PID namespace
+func mountProc(root string) error {
+	target := filepath.Join(root, "proc")
+	if err := os.Mkdir(target, 0755); err != nil {
+		return err
+	}
+	flags := uintptr(unix.MS_NOSUID | unix.MS_NODEV | unix.MS_NOEXEC)
+	if err := unix.Mount("proc", target, "proc", flags, ""); err != nil {
+		return fmt.Errorf("failed to mount /proc: %v", err)
+	}
+	return nil
+}
+
+func mountDev(root string) error {
+	dev := filepath.Join(root, "dev")
+	if err := os.Mkdir(dev, 0755); err != nil {
+		return err
+	}
+	for _, device := range devices {
+		target := filepath.Join(dev, device)
+		if err := os.WriteFile(target, nil, 0644); err != nil {
+			return err
+		}
+		if err := unix.Mount(filepath.Join("/dev", device), target, "", unix.MS_BIND, ""); err != nil {
+			return fmt.Errorf("failed to bind /dev/%s: %v", device, err)
+		}
+	}
+	links := map[string]string{
+		"fd":     "/proc/self/fd",
+		"stdin":  "/proc/self/fd/0",
+		"stdout": "/proc/self/fd/1",
+		"stderr": "/proc/self/fd/2",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(dev, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pivot makes root the root filesystem, detaches the host's and makes root itself read-only
+func pivot(root string) error {
+	old := filepath.Join(root, oldDir)
+	if err := os.Mkdir(old, 0700); err != nil {
+		return err
+	}
+	if err := unix.PivotRoot(root, old); err != nil {
+		return fmt.Errorf("failed to pivot root: %v", err)
+	}
+	if err := unix.Chdir("/"); err != nil {
+		return err
+	}
+	if err := unix.Unmount(oldDir, unix.MNT_DETACH); err != nil {
+		return fmt.Errorf("failed to detach host root: %v", err)
+	}
+	if err := os.Remove(oldDir); err != nil {
+		return err
+	}
+	flags := uintptr(unix.MS_REMOUNT | unix.MS_BIND | unix.MS_RDONLY | unix.MS_NOSUID | unix.MS_NODEV)
+	if err := unix.Mount("", "/", "", flags, ""); err != nil {
+		return fmt.Errorf("failed to make root read-only: %v", err)
+	}
+	return unix.Chdir(boxDir)
+}
+
+// dropPrivileges switches to the sandbox user for good. The syscall package applies the change to
+// every thread of the helper.
+func dropPrivileges(config Config) error {
+	if err := syscall.Setgroups(nil); err != nil {
+		return fmt.Errorf("failed to clear groups: %v", err)
+	}
+	if err := syscall.Setgid(config.Gid); err != nil {
+		return fmt.Errorf("failed to set gid: %v", err)
+	}
+	if err := syscall.Setuid(config.Uid); err != nil {
+		return fmt.Errorf("failed to set uid: %v", err)
+	}
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to set no_new_privs: %v", err)
+	}
+	return nil
+}
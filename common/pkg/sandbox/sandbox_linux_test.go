@@ -0,0 +1,223 @@
+//go:build linux && (amd64 || arm64)
+
+package sandbox
+
+import (
+	"context"
+	"go-compiler/common/pkg/enums"
+	"go-compiler/common/pkg/runner"
+	"go-compiler/models/languages"
+	"go-compiler/models/results"
+	"go-compiler/models/submissions"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The runner re-executes the test binary to confine itself before running a program
+func TestMain(m *testing.M) {
+	runner.Init()
+	os.Exit(m.Run())
+}
+
+// hostileLimits are tight on purpose so a program that escapes a limit is noticed quickly
+var hostileLimits = submissions.Limits{
+	CpuTimeLimit:  2,
+	WallTimeLimit: 5,
+	MemoryLimit:   131072,
+	MaxProcesses:  16,
+	MaxOutputSize: 64,
+}
+
+var (
+	bash = languages.LanguageModel{
+		Name:       "Bash",
+		SourceFile: "main.sh",
+		RunCmd:     []string{"bash", "main.sh"},
+	}
+	python = languages.LanguageModel{
+		Name:       "Python",
+		SourceFile: "main.py",
+		RunCmd:     []string{"python3", "main.py"},
+	}
+)
+
+const (
+	// hostilePath is a host path no sandboxed program may create
+	hostilePath = "/etc/sandbox-escaped"
+	// canaryEnv is set in the executor to prove its environment does not reach the program
+	canaryEnv = "SANDBOX_CANARY"
+)
+
+func TestSandboxContainsHostilePrograms(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("the sandbox needs root to create namespaces")
+	}
+	sandbox, err := NewSandbox(DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Other services on the host may run as the same user
+	hostProcesses := processesOf(DefaultConfig.Uid)
+
+	tests := []struct {
+		name     string
+		language languages.LanguageModel
+		code     string
+		check    func(t *testing.T, result results.ExecutionResult)
+	}{
+		{
+			name:     "fork bomb",
+			language: bash,
+			code:     `bomb() { bomb | bomb & }; bomb`,
+			check: func(t *testing.T, result results.ExecutionResult) {
+				if result.Status.Id == int(enums.InternalError) {
+					t.Fatalf("executor failed: %s", result.Message)
+				}
+				if result.Time > hostileLimits.WallTimeLimit+2 {
+					t.Errorf("ran for %.1fs, past the wall time limit", result.Time)
+				}
+				for pid := range processesOf(DefaultConfig.Uid) {
+					if !hostProcesses[pid] {
+						t.Errorf("process %s of the sandbox user outlived the run", pid)
+					}
+				}
+			},
+		},
+		{
+			name:     "network access",
+			language: bash,
+			code: `(exec 3<>/dev/tcp/1.1.1.1/53) 2>/dev/null && echo connected ||
+(exec 3<>/dev/tcp/127.0.0.1/22) 2>/dev/null && echo connected || echo denied`,
+			check: expectDenied,
+		},
+		{
+			name:     "write outside the workspace",
+			language: bash,
+			code: `echo pwned > ` + hostilePath + ` 2>/dev/null && echo escaped ||
+touch /usr/pwned /pwned 2>/dev/null && echo escaped || echo denied`,
+			check: func(t *testing.T, result results.ExecutionResult) {
+				if _, err := os.Stat(hostilePath); err == nil {
+					os.Remove(hostilePath)
+					t.Errorf("created %s on the host", hostilePath)
+				}
+				expectDenied(t, result)
+			},
+		},
+		{
+			name:     "write to the workspace while running",
+			language: bash,
+			code:     `echo pwned > main.sh 2>/dev/null && echo escaped || echo denied`,
+			check:    expectDenied,
+		},
+		{
+			name:     "ptrace",
+			language: python,
+			code: `import ctypes, os
+libc = ctypes.CDLL(None, use_errno=True)
+PTRACE_TRACEME, PTRACE_ATTACH = 0, 16
+child = os.fork()
+if child == 0:
+    os.execvp("sleep", ["sleep", "5"])
+attached = libc.ptrace(PTRACE_ATTACH, child, 0, 0) == 0 or libc.ptrace(PTRACE_TRACEME, 0, 0, 0) == 0
+os.kill(child, 9)
+print("attached" if attached else "denied")`,
+			check: expectDenied,
+		},
+		{
+			name:     "mount",
+			language: bash,
+			code:     `mount -t tmpfs none /tmp 2>/dev/null && echo mounted || unshare -m true 2>/dev/null && echo unshared || echo denied`,
+			check:    expectDenied,
+		},
+		{
+			name:     "setuid",
+			language: python,
+			code: `import os
+try:
+    os.setuid(0)
+except PermissionError:
+    pass
+print("root" if os.getuid() == 0 or os.geteuid() == 0 else "denied")`,
+			check: expectDenied,
+		},
+		{
+			name:     "setuid binary",
+			language: bash,
+			code:     `for binary in su sudo passwd; do command -v "$binary" > /dev/null && "$binary" -c id < /dev/null 2>&1 | grep -q 'uid=0' && echo root; done; echo denied`,
+			check:    expectDenied,
+		},
+		{
+			name:     "read the executor environment",
+			language: bash,
+			code:     `[ -n "${` + canaryEnv + `}" ] && echo leaked || echo denied`,
+			check:    expectDenied,
+		},
+	}
+
+	t.Setenv(canaryEnv, "leaked")
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.language.Name == python.Name {
+				if _, err := os.Stat("/usr/bin/python3"); err != nil {
+					t.Skip("python3 is not installed in /usr/bin")
+				}
+			}
+			test.check(t, run(t, sandbox, test.language, test.code))
+		})
+	}
+}
+
+// run executes code in the sandbox from a fresh workspace
+func run(t *testing.T, sandbox *Sandbox, language languages.LanguageModel, code string) results.ExecutionResult {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	workDir, err := os.MkdirTemp("", "sandbox-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workDir)
+
+	return runner.Run(ctx, runner.Submission{
+		Language:  language,
+		Code:      []byte(code),
+		WorkDir:   workDir,
+		Limits:    hostileLimits,
+		Isolation: sandbox,
+	})
+}
+
+// expectDenied fails unless the program finished normally and its last line of output is "denied"
+func expectDenied(t *testing.T, result results.ExecutionResult) {
+	t.Helper()
+	if result.Status.Id != int(enums.Accepted) {
+		t.Fatalf("finished with %s: %s%s", result.Status.Name, result.Message, result.Stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(result.Stdout), "\n")
+	if len(lines) != 1 || lines[0] != "denied" {
+		t.Errorf("expected only %q, got %q (stderr %q)", "denied", result.Stdout, result.Stderr)
+	}
+}
+
+// processesOf returns the PIDs of the running processes owned by uid
+func processesOf(uid int) map[string]bool {
+	statuses, _ := filepath.Glob("/proc/[0-9]*/status")
+	pids := make(map[string]bool)
+	for _, status := range statuses {
+		data, err := os.ReadFile(status)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) > 1 && fields[0] == "Uid:" && fields[1] == strconv.Itoa(uid) {
+				pids[filepath.Base(filepath.Dir(status))] = true
+			}
+		}
+	}
+	return pids
+}
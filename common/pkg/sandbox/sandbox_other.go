@@ -0,0 +1,22 @@
+//go:build !linux || !(amd64 || arm64)
+
+package sandbox
+
+import (
+	"errors"
+	"os/exec"
+)
+
+var errUnsupported = errors.New("the sandbox requires Linux on amd64 or arm64")
+
+func supported() error {
+	return errUnsupported
+}
+
+func (s *Sandbox) Prepare(cmd *exec.Cmd, workDir string) (func(), error) {
+	return nil, errUnsupported
+}
+
+func confine(config Config, workDir string, writable bool) error {
+	return errUnsupported
+}
@@ -0,0 +1,168 @@
+//go:build linux && (amd64 || arm64)
+
+package sandbox
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// deniedSyscalls fail with EPERM. Together with the namespaces and the unprivileged user they keep
+// a program from reconfiguring the sandbox, inspecting other processes or reaching the kernel's
+// less hardened interfaces.
+var deniedSyscalls = append([]uint32{
+	unix.SYS_PTRACE,
+	unix.SYS_PROCESS_VM_READV,
+	unix.SYS_PROCESS_VM_WRITEV,
+	unix.SYS_MOUNT,
+	unix.SYS_UMOUNT2,
+	unix.SYS_PIVOT_ROOT,
+	unix.SYS_CHROOT,
+	unix.SYS_SETNS,
+	unix.SYS_UNSHARE,
+	unix.SYS_REBOOT,
+	unix.SYS_KEXEC_LOAD,
+	unix.SYS_KEXEC_FILE_LOAD,
+	unix.SYS_INIT_MODULE,
+	unix.SYS_FINIT_MODULE,
+	unix.SYS_DELETE_MODULE,
+	unix.SYS_SWAPON,
+	unix.SYS_SWAPOFF,
+	unix.SYS_ACCT,
+	unix.SYS_SETHOSTNAME,
+	unix.SYS_SETDOMAINNAME,
+	unix.SYS_SETTIMEOFDAY,
+	unix.SYS_CLOCK_SETTIME,
+	unix.SYS_ADJTIMEX,
+	unix.SYS_KEYCTL,
+	unix.SYS_ADD_KEY,
+	unix.SYS_REQUEST_KEY,
+	unix.SYS_BPF,
+	unix.SYS_PERF_EVENT_OPEN,
+	unix.SYS_USERFAULTFD,
+	unix.SYS_FANOTIFY_INIT,
+	unix.SYS_NAME_TO_HANDLE_AT,
+	unix.SYS_OPEN_BY_HANDLE_AT,
+	unix.SYS_IO_URING_SETUP,
+}, archDeniedSyscalls...)
+
+// instruction is a BPF instruction whose jumps name labels instead of offsets
+type instruction struct {
+	unix.SockFilter
+	jt, jf string
+}
+
+const (
+	next     = ""
+	allow    = "allow"
+	deny     = "deny"
+	enosys   = "enosys"
+	kill     = "kill"
+	checkArg = "check_arg"
+)
+
+func load(offset uint32) instruction {
+	return instruction{SockFilter: unix.SockFilter{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: offset}}
+}
+
+func jump(op uint16, value uint32, jt string, jf string) instruction {
+	return instruction{SockFilter: unix.SockFilter{Code: unix.BPF_JMP | op | unix.BPF_K, K: value}, jt: jt, jf: jf}
+}
+
+func ret(action uint32) instruction {
+	return instruction{SockFilter: unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: action}}
+}
+
+// Offsets into struct seccomp_data; arguments are read by their low 32 bits on little-endian hosts
+const (
+	nrOffset   = 0
+	archOffset = 4
+	arg0Offset = 16
+)
+
+// filter builds the seccomp program. Syscalls of foreign ABIs kill the process, clone3 reports
+// ENOSYS so libc falls back to clone, whose flags can be checked, and sockets are limited to
+// AF_UNIX so no network family is reachable even if a namespace were escaped.
+func filter() ([]unix.SockFilter, error) {
+	program := []instruction{
+		load(archOffset),
+		jump(unix.BPF_JEQ, auditArch, next, kill),
+		load(nrOffset),
+	}
+	if foreignSyscallBit != 0 {
+		program = append(program, jump(unix.BPF_JGE, foreignSyscallBit, kill, next))
+	}
+	for _, nr := range deniedSyscalls {
+		program = append(program, jump(unix.BPF_JEQ, nr, deny, next))
+	}
+	program = append(program,
+		jump(unix.BPF_JEQ, unix.SYS_CLONE3, enosys, next),
+		jump(unix.BPF_JEQ, unix.SYS_SOCKET, checkArg, next),
+		jump(unix.BPF_JEQ, unix.SYS_SOCKETPAIR, checkArg, next),
+		jump(unix.BPF_JEQ, unix.SYS_CLONE, "clone", allow),
+
+		// clone must not create namespaces, in particular a user namespace that would grant capabilities
+		load(arg0Offset),
+		jump(unix.BPF_JSET, namespaces|unix.CLONE_NEWUSER|unix.CLONE_NEWCGROUP, deny, allow),
+
+		load(arg0Offset),
+		jump(unix.BPF_JEQ, unix.AF_UNIX, allow, deny),
+
+		ret(unix.SECCOMP_RET_ALLOW),
+		ret(unix.SECCOMP_RET_ERRNO|uint32(unix.EPERM)),
+		ret(unix.SECCOMP_RET_ERRNO|uint32(unix.ENOSYS)),
+		ret(unix.SECCOMP_RET_KILL_PROCESS),
+	)
+
+	n := len(program)
+	labels := map[string]int{
+		"clone":  n - 8,
+		checkArg: n - 6,
+		allow:    n - 4,
+		deny:     n - 3,
+		enosys:   n - 2,
+		kill:     n - 1,
+	}
+	compiled := make([]unix.SockFilter, n)
+	for i, insn := range program {
+		jt, err := offset(labels, insn.jt, i)
+		if err != nil {
+			return nil, err
+		}
+		jf, err := offset(labels, insn.jf, i)
+		if err != nil {
+			return nil, err
+		}
+		insn.Jt, insn.Jf = jt, jf
+		compiled[i] = insn.SockFilter
+	}
+	return compiled, nil
+}
+
+func offset(labels map[string]int, label string, from int) (uint8, error) {
+	if label == next {
+		return 0, nil
+	}
+	distance := labels[label] - from - 1
+	if distance < 0 || distance > 255 {
+		return 0, fmt.Errorf("seccomp jump to %s out of range", label)
+	}
+	return uint8(distance), nil
+}
+
+// installSeccomp loads the filter for every thread of the helper; it is inherited across exec
+func installSeccomp() error {
+	program, err := filter()
+	if err != nil {
+		return err
+	}
+	fprog := unix.SockFprog{Len: uint16(len(program)), Filter: &program[0]}
+	_, _, errno := unix.Syscall(unix.SYS_SECCOMP, unix.SECCOMP_SET_MODE_FILTER,
+		unix.SECCOMP_FILTER_FLAG_TSYNC, uintptr(unsafe.Pointer(&fprog)))
+	if errno != 0 {
+		return fmt.Errorf("failed to install seccomp filter: %v", errno)
+	}
+	return nil
+}
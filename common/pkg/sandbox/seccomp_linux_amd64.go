@@ -0,0 +1,15 @@
+package sandbox
+
+import "golang.org/x/sys/unix"
+
+const auditArch = unix.AUDIT_ARCH_X86_64
+
+// foreignSyscallBit marks x32 syscalls, which share the x86_64 audit arch but not its numbers
+const foreignSyscallBit = 0x40000000
+
+var archDeniedSyscalls = []uint32{
+	unix.SYS_IOPL,
+	unix.SYS_IOPERM,
+	unix.SYS_MODIFY_LDT,
+	unix.SYS_USELIB,
+}
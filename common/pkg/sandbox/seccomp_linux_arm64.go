@@ -0,0 +1,10 @@
+package sandbox
+
+import "golang.org/x/sys/unix"
+
+const auditArch = unix.AUDIT_ARCH_AARCH64
+
+// foreignSyscallBit is unused on arm64, where the audit arch alone identifies the ABI
+const foreignSyscallBit = 0
+
+var archDeniedSyscalls = []uint32{}
@@ -0,0 +1,114 @@
+package workspace
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"go-compiler/models/submissions"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// MaxFiles bounds how many files one submission may unpack into its workspace
+	MaxFiles = 64
+	// MaxSize bounds the decoded size of all files of one submission in bytes
+	MaxSize = 8 << 20
+)
+
+// Decode turns a submission's file map and archive into file contents keyed by their path relative
+// to the workspace. Paths must stay inside the workspace; a file in both wins from the file map.
+func Decode(sources submissions.Sources) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	var size int64
+
+	add := func(name string, content []byte) error {
+		name = filepath.Clean(filepath.FromSlash(name))
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("file %q is outside the workspace", name)
+		}
+		if _, found := files[name]; !found && len(files) == MaxFiles {
+			return fmt.Errorf("too many files, maximum is %d", MaxFiles)
+		}
+		size += int64(len(content))
+		if size > MaxSize {
+			return fmt.Errorf("files are too large, maximum is %d bytes", MaxSize)
+		}
+		files[name] = content
+		return nil
+	}
+
+	if sources.Archive != "" {
+		archive, err := base64.StdEncoding.DecodeString(sources.Archive)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding base64 archive: %v", err)
+		}
+		reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid zip archive: %v", err)
+		}
+		for _, entry := range reader.File {
+			if entry.FileInfo().IsDir() {
+				continue
+			}
+			if !entry.Mode().IsRegular() {
+				return nil, fmt.Errorf("archive entry %q is not a regular file", entry.Name)
+			}
+			content, err := readEntry(entry, MaxSize-size)
+			if err != nil {
+				return nil, err
+			}
+			if err := add(entry.Name, content); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for name, encoded := range sources.Files {
+		content, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding base64 file %q: %v", name, err)
+		}
+		if err := add(name, content); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// readEntry decompresses one archive entry, refusing to inflate more than limit bytes
+func readEntry(entry *zip.File, limit int64) ([]byte, error) {
+	reader, err := entry.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive entry %q: %v", entry.Name, err)
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive entry %q: %v", entry.Name, err)
+	}
+	if int64(len(content)) > limit {
+		return nil, fmt.Errorf("files are too large, maximum is %d bytes", MaxSize)
+	}
+	return content, nil
+}
+
+// Write creates files below dir, including any parent directories they need
+func Write(dir string, files map[string][]byte) error {
+	for name, content := range files {
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("file %q is outside the workspace", name)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %q: %v", name, err)
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			return fmt.Errorf("failed to write %q: %v", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package workspace
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"go-compiler/models/submissions"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// zipEntry is one file of a test archive; a mode of 0 writes a regular file
+type zipEntry struct {
+	name    string
+	content string
+	mode    os.FileMode
+}
+
+func encodeZip(t *testing.T, entries ...zipEntry) string {
+	t.Helper()
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		if entry.mode != 0 {
+			header.SetMode(entry.mode)
+		}
+		file, err := writer.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := file.Write([]byte(entry.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(archive.Bytes())
+}
+
+func encode(content string) string {
+	return base64.StdEncoding.EncodeToString([]byte(content))
+}
+
+func TestDecode(t *testing.T) {
+	tooMany := make(map[string]string, MaxFiles+1)
+	for i := 0; i <= MaxFiles; i++ {
+		tooMany[fmt.Sprintf("file%d.txt", i)] = encode("x")
+	}
+	half := strings.Repeat("x", MaxSize/2+1)
+
+	tests := []struct {
+		name    string
+		sources func(t *testing.T) submissions.Sources
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:    "nothing to decode",
+			sources: func(t *testing.T) submissions.Sources { return submissions.Sources{} },
+			want:    map[string]string{},
+		},
+		{
+			name: "file map",
+			sources: func(t *testing.T) submissions.Sources {
+				return submissions.Sources{Files: map[string]string{"lib/util.py": encode("x = 1"), "./data.txt": encode("42")}}
+			},
+			want: map[string]string{filepath.Join("lib", "util.py"): "x = 1", "data.txt": "42"},
+		},
+		{
+			name: "archive",
+			sources: func(t *testing.T) submissions.Sources {
+				return submissions.Sources{Archive: encodeZip(t, zipEntry{name: "pkg/"}, zipEntry{name: "pkg/a.go", content: "package pkg"})}
+			},
+			want: map[string]string{filepath.Join("pkg", "a.go"): "package pkg"},
+		},
+		{
+			name: "file map wins from the archive",
+			sources: func(t *testing.T) submissions.Sources {
+				return submissions.Sources{
+					Archive: encodeZip(t, zipEntry{name: "a.txt", content: "archive"}),
+					Files:   map[string]string{"a.txt": encode("files")},
+				}
+			},
+			want: map[string]string{"a.txt": "files"},
+		},
+		{
+			name: "parent directory in the file map",
+			sources: func(t *testing.T) submissions.Sources {
+				return submissions.Sources{Files: map[string]string{"../escape.txt": encode("x")}}
+			},
+			wantErr: "outside the workspace",
+		},
+		{
+			name: "absolute path in the file map",
+			sources: func(t *testing.T) submissions.Sources {
+				return submissions.Sources{Files: map[string]string{"/etc/passwd": encode("x")}}
+			},
+			wantErr: "outside the workspace",
+		},
+		{
+			name: "zip slip",
+			sources: func(t *testing.T) submissions.Sources {
+				return submissions.Sources{Archive: encodeZip(t, zipEntry{name: "lib/../../escape.txt", content: "x"})}
+			},
+			wantErr: "outside the workspace",
+		},
+		{
+			name: "symlink in the archive",
+			sources: func(t *testing.T) submissions.Sources {
+				return submissions.Sources{Archive: encodeZip(t, zipEntry{name: "link", content: "/etc/passwd", mode: os.ModeSymlink | 0777})}
+			},
+			wantErr: "not a regular file",
+		},
+		{
+			name:    "too many files",
+			sources: func(t *testing.T) submissions.Sources { return submissions.Sources{Files: tooMany} },
+			wantErr: "too many files",
+		},
+		{
+			name: "files too large together",
+			sources: func(t *testing.T) submissions.Sources {
+				return submissions.Sources{Files: map[string]string{"a.txt": encode(half), "b.txt": encode(half)}}
+			},
+			wantErr: "too large",
+		},
+		{
+			name: "archive inflating past the size cap",
+			sources: func(t *testing.T) submissions.Sources {
+				return submissions.Sources{Archive: encodeZip(t, zipEntry{name: "bomb.txt", content: strings.Repeat("0", MaxSize+1)})}
+			},
+			wantErr: "too large",
+		},
+		{
+			name: "invalid base64 file",
+			sources: func(t *testing.T) submissions.Sources {
+				return submissions.Sources{Files: map[string]string{"a.txt": "!!"}}
+			},
+			wantErr: "base64",
+		},
+		{
+			name:    "invalid zip",
+			sources: func(t *testing.T) submissions.Sources { return submissions.Sources{Archive: encode("not a zip")} },
+			wantErr: "invalid zip",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			files, err := Decode(test.sources(t))
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("Decode error = %v, want one containing %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if len(files) != len(test.want) {
+				t.Fatalf("Decode = %d files, want %d", len(files), len(test.want))
+			}
+			for name, content := range test.want {
+				if string(files[name]) != content {
+					t.Errorf("file %s = %q, want %q", name, files[name], content)
+				}
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{filepath.Join("lib", "util.py"): []byte("x = 1")}
+	if err := Write(dir, files); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "lib", "util.py"))
+	if err != nil || string(content) != "x = 1" {
+		t.Errorf("written file = %q, %v", content, err)
+	}
+
+	if err := Write(dir, map[string][]byte{filepath.Join("..", "escape.txt"): nil}); err == nil {
+		t.Error("Write accepted a file outside the workspace")
+	}
+}
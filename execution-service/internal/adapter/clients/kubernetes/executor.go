@@ -0,0 +1,373 @@
+package kubernetes
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"go-compiler/common/pkg/enums"
+	"go-compiler/common/pkg/limits"
+	"go-compiler/common/pkg/runner"
+	"go-compiler/models/results"
+	"go-compiler/models/submissions"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DefaultLoaderImage only needs sh, base64 and tar to unpack the code
+	DefaultLoaderImage = "busybox:1.36"
+
+	// stdinFile holds the run's stdin next to the code
+	stdinFile = ".stdin"
+	// stdoutFile and stderrFile hold the program's output until it is copied to the container log,
+	// so the output limit applies to them as a file size limit
+	stdoutFile = ".stdout"
+	stderrFile = ".stderr"
+
+	// maxArchiveSize bounds the encoded code delivered to the loader through its environment
+	maxArchiveSize = 512 << 10
+)
+
+// errArchiveTooLarge is returned by pack when the code and stdin exceed maxArchiveSize
+var errArchiveTooLarge = errors.New("submission is too large for the kubernetes backend")
+
+// JobExecutor runs every submission as a Kubernetes Job in the language's sandbox image. Each Job
+// loads the code into the /code emptyDir, compiles it and runs it once per stdin, so compile
+// failures are reported by Execute rather than by Compile.
+type JobExecutor struct {
+	client      IKubernetesClient
+	loaderImage string
+}
+
+func NewJobExecutor(client IKubernetesClient, loaderImage string) *JobExecutor {
+	if loaderImage == "" {
+		loaderImage = DefaultLoaderImage
+	}
+	return &JobExecutor{
+		client:      client,
+		loaderImage: loaderImage,
+	}
+}
+
+// Compile checks that the submission can be turned into a Job; the Job itself compiles it
+func (e *JobExecutor) Compile(ctx context.Context, submission runner.Submission) (results.ExecutionResult, bool) {
+	language := submission.Language
+	if language.Image == "" {
+		return runner.InternalError(fmt.Errorf("%s has no sandbox image for the kubernetes backend", language.Name)), false
+	}
+	if _, err := language.CompileCommand(submission.CompilerOptions); err != nil {
+		return results.ExecutionResult{
+			Status:  results.NewStatus(enums.CompilationError),
+			Message: err.Error(),
+		}, false
+	}
+	return results.ExecutionResult{}, true
+}
+
+// Execute runs the submission once with the given stdin as a Job and reports its outcome. Jobs are
+// started with their stdin packed next to the code, so the submission's live Input is not read and
+// runs keep the wall time limit rather than its SessionLimit.
+func (e *JobExecutor) Execute(ctx context.Context, submission runner.Submission, stdin string) results.ExecutionResult {
+	runs, failed, ok := e.ExecuteAll(ctx, submission, []string{stdin})
+	if !ok {
+		return failed
+	}
+	return runs[0]
+}
+
+// ExecuteAll runs the submission once per stdin. Each Job compiles the program once and runs it for
+// as many stdins as fit in its archive and logs, so most batches need a single Job.
+func (e *JobExecutor) ExecuteAll(ctx context.Context, submission runner.Submission, stdins []string) ([]results.ExecutionResult, results.ExecutionResult, bool) {
+	runs := make([]results.ExecutionResult, 0, len(stdins))
+	for len(runs) < len(stdins) {
+		batch, failed, ok := e.runJob(ctx, submission, stdins[len(runs):])
+		if !ok {
+			return nil, failed, false
+		}
+		runs = append(runs, batch...)
+	}
+	return runs, results.ExecutionResult{}, true
+}
+
+// runJob runs the submission in one Job for the first of stdins, at least one, and returns their results
+func (e *JobExecutor) runJob(ctx context.Context, submission runner.Submission, stdins []string) ([]results.ExecutionResult, results.ExecutionResult, bool) {
+	language := submission.Language
+
+	archive, err := pack(submission, stdins)
+	// A batch whose stdins do not fit in one archive is split over several Jobs
+	for errors.Is(err, errArchiveTooLarge) && len(stdins) > 1 {
+		stdins = stdins[:len(stdins)/2]
+		archive, err = pack(submission, stdins)
+	}
+	if err != nil {
+		return nil, runner.InternalError(err), false
+	}
+	profile, err := ResolveProfile(language)
+	if err != nil {
+		return nil, runner.InternalError(err), false
+	}
+	request := JobRequest{
+		Image:              language.Image,
+		LoaderImage:        e.loaderImage,
+		Archive:            archive,
+		RunCmd:             runCommand(submission.Limits, len(stdins), language.RunCmd),
+		Limits:             submission.Limits,
+		Runs:               len(stdins),
+		Profile:            profile,
+		CompileMemoryLimit: limits.Compile.MemoryLimit,
+		RequestId:          submission.RequestId,
+		LanguageId:         language.LanguageId,
+		Language:           strings.TrimSpace(language.Name + " " + language.Version),
+		Tenant:             submission.Tenant,
+	}
+	if language.IsCompiled() {
+		request.CompileCmd, err = language.CompileCommand(submission.CompilerOptions)
+		if err != nil {
+			return nil, runner.InternalError(err), false
+		}
+		request.CompileCmd = compileCommand(submission.Limits, request.CompileCmd)
+	} else {
+		// Only compiled languages get the compile time added to the Job's deadline
+		request.Limits.CompileTimeLimit = 0
+	}
+
+	jobName, err := e.client.CreateJob(request)
+	if err != nil {
+		return nil, runner.InternalError(err), false
+	}
+	defer e.client.DeleteJob(jobName)
+
+	var failure *JobFailure
+	err = e.client.WaitForJobCompletion(ctx, jobName, request.ActiveDeadline())
+	if err != nil && !errors.As(err, &failure) {
+		return nil, runner.InternalError(err), false
+	}
+	return e.verdicts(jobName, failure, submission.Limits, len(stdins))
+}
+
+// verdicts maps the runs the finished Job logged to results. A Job that failed while the program
+// ran, such as by running out of memory or time, reports why as the result of the run it stopped in.
+func (e *JobExecutor) verdicts(jobName string, failure *JobFailure, submissionLimits submissions.Limits, runs int) ([]results.ExecutionResult, results.ExecutionResult, bool) {
+	if failure != nil {
+		switch failure.Reason {
+		case ReasonCompileFailed:
+			return nil, results.ExecutionResult{
+				Status:        results.NewStatus(enums.CompilationError),
+				CompileOutput: e.compileOutput(jobName),
+				ExitCode:      int(failure.ExitCode),
+			}, false
+		case ReasonFailed:
+			return nil, runner.InternalError(failure), false
+		}
+	}
+
+	// The Pod is usually gone once the deadline passed, so what a failed Job logged is best effort
+	var compileOutput string
+	pod, err := e.client.GetJobPod(jobName)
+	if err == nil && containerStatus(pod.Status.InitContainerStatuses, CompilerContainer) != nil {
+		compileOutput = e.compileOutput(jobName)
+	}
+	var logged []loggedRun
+	logs, err := e.client.GetJobLogs(jobName, RunnerContainer, 0)
+	if err == nil {
+		logged, err = parseRuns(logs)
+	}
+	if failure == nil && err != nil {
+		return nil, runner.InternalError(fmt.Errorf("failed to read the runs of job %s: %v", jobName, err)), false
+	}
+	if len(logged) > runs {
+		return nil, runner.InternalError(fmt.Errorf("job %s logged %d runs of %d", jobName, len(logged), runs)), false
+	}
+
+	executions := make([]results.ExecutionResult, 0, len(logged)+1)
+	for _, run := range logged {
+		result := run.result(submissionLimits)
+		result.CompileOutput = compileOutput
+		executions = append(executions, result)
+	}
+	if failure != nil && len(logged) < runs {
+		result := results.ExecutionResult{CompileOutput: compileOutput}
+		switch failure.Reason {
+		case ReasonDeadlineExceeded:
+			result.Status = results.NewStatus(enums.TimeLimitExceeded)
+		default:
+			classify(&result, int(failure.ExitCode), false, failure.Reason == ReasonOOMKilled)
+		}
+		executions = append(executions, result)
+	}
+	if len(executions) == 0 {
+		return nil, runner.InternalError(fmt.Errorf("job %s finished without running the program", jobName)), false
+	}
+	return executions, results.ExecutionResult{}, true
+}
+
+// result judges the run against the submission's limits
+func (r loggedRun) result(submissionLimits submissions.Limits) results.ExecutionResult {
+	result := results.ExecutionResult{
+		Stdout:  r.stdout,
+		Stderr:  r.stderr,
+		Time:    r.elapsed.Seconds(),
+		CpuTime: r.cpuTime.Seconds(),
+		Memory:  r.memory,
+	}
+	// stdout and stderr were each cut one byte past the limit, which bounds them together
+	limit := submissionLimits.MaxOutputSize * 1024
+	tooLong := limit > 0 && int64(len(r.stdout)+len(r.stderr)) > limit
+	outputExceeded := r.outputExceeded || tooLong
+	if tooLong {
+		result.Stdout = r.stdout[:min(int64(len(r.stdout)), limit)]
+		result.Stderr = r.stderr[:limit-int64(len(result.Stdout))]
+	}
+
+	classify(&result, r.exitCode, outputExceeded, r.oomKilled)
+	// timeout kills the program at the wall time limit, which it may be measured to have reached exactly
+	timedOut := (submissionLimits.WallTimeLimit > 0 && r.elapsed >= seconds(submissionLimits.WallTimeLimit)) ||
+		(submissionLimits.CpuTimeLimit > 0 && r.cpuTime > seconds(submissionLimits.CpuTimeLimit))
+	if timedOut && !outputExceeded {
+		result.Status = results.NewStatus(enums.TimeLimitExceeded)
+	}
+	return result
+}
+
+// compileOutput reads the compiler container's logs up to limits.Compile.MaxOutputSize. The logs are
+// best effort, so a failure to read them leaves the output empty.
+func (e *JobExecutor) compileOutput(jobName string) string {
+	limit := limits.Compile.MaxOutputSize * 1024
+	output, _ := e.client.GetJobLogs(jobName, CompilerContainer, limit+1)
+	if int64(len(output)) > limit {
+		return runner.TruncatedCompileOutput(output[:limit])
+	}
+	return output
+}
+
+// limitCommands returns the shell commands that apply the CPU time, process and output limits to the
+// shell and the program it starts, each followed by &&. The CPU hard limit is one second above the
+// soft limit so the program first receives SIGXCPU; the soft limit is set first because shells
+// refuse a hard limit below the current soft one. The process limit counts every process of the
+// Pod's user on the node; dash calls it -p rather than -u. The output limit bounds each file the
+// program writes, which is one 512 byte block more than max_output_size so crossing it can be told
+// apart from reaching it.
+func limitCommands(limits submissions.Limits) string {
+	var commands strings.Builder
+	if limits.CpuTimeLimit > 0 {
+		cpu := int64(math.Ceil(limits.CpuTimeLimit))
+		fmt.Fprintf(&commands, "ulimit -S -t %d && ulimit -H -t %d && ", cpu, cpu+1)
+	}
+	if limits.MaxProcesses > 0 {
+		fmt.Fprintf(&commands, "{ ulimit -u %[1]d 2>/dev/null || ulimit -p %[1]d; } && ", limits.MaxProcesses)
+	}
+	if limits.MaxOutputSize > 0 {
+		fmt.Fprintf(&commands, "ulimit -f %d && ", limits.MaxOutputSize*2+1)
+	}
+	return commands.String()
+}
+
+// classify sets the exit code, signal and status of a program that ran to completion
+func classify(result *results.ExecutionResult, exitCode int, outputExceeded bool, oomKilled bool) {
+	result.ExitCode = exitCode
+	// Containers killed by a signal exit with 128 plus the signal number
+	if exitCode > 128 {
+		result.Signal = exitCode - 128
+	}
+
+	switch {
+	case outputExceeded:
+		result.Status = results.NewStatus(enums.RuntimeErrorSIGXFSZ)
+	case oomKilled:
+		result.Status = results.NewStatus(enums.MemoryLimitExceeded)
+	case result.Signal == int(syscall.SIGXCPU):
+		result.Status = results.NewStatus(enums.TimeLimitExceeded)
+	case result.Signal != 0:
+		result.Status = results.NewStatus(enums.FindRuntimeErrorByStatusCode(result.Signal))
+	case exitCode != 0:
+		result.Status = results.NewStatus(enums.RuntimeErrorNZEC)
+	default:
+		result.Status = results.NewStatus(enums.Accepted)
+	}
+}
+
+func containerStatus(statuses []corev1.ContainerStatus, name string) *corev1.ContainerStatus {
+	for i := range statuses {
+		if statuses[i].Name == name {
+			return &statuses[i]
+		}
+	}
+	return nil
+}
+
+// pack bundles the submission's files, its source file and the stdin of each run into a base64
+// encoded tar.gz. The stdin of run i is named stdinFile.i.
+func pack(submission runner.Submission, stdins []string) (string, error) {
+	archive, err := bundle(submission, stdinFiles(stdins))
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.StdEncoding.EncodeToString(archive)
+	if len(encoded) > maxArchiveSize {
+		return "", fmt.Errorf("%w: %d bytes encoded, maximum is %d", errArchiveTooLarge, len(encoded), maxArchiveSize)
+	}
+	return encoded, nil
+}
+
+// stdinFiles names the stdin of run i stdinFile.i
+func stdinFiles(stdins []string) map[string][]byte {
+	files := make(map[string][]byte, len(stdins))
+	for i, stdin := range stdins {
+		files[fmt.Sprintf("%s.%d", stdinFile, i)] = []byte(stdin)
+	}
+	return files
+}
+
+// bundle writes the submission's files, its source file and inputs, named files holding stdin, into a tar.gz
+func bundle(submission runner.Submission, inputs map[string][]byte) ([]byte, error) {
+	files := make(map[string][]byte, len(submission.Files)+len(inputs)+1)
+	for name, content := range submission.Files {
+		files[filepath.ToSlash(name)] = content
+	}
+	if len(submission.Code) > 0 {
+		files[submission.Language.SourceFile] = submission.Code
+	}
+	if _, found := files[submission.Language.SourceFile]; !found {
+		return nil, fmt.Errorf("missing source file %s", submission.Language.SourceFile)
+	}
+	for name, content := range inputs {
+		files[name] = content
+	}
+
+	// Sorted so the same submission always produces the same archive
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buffer bytes.Buffer
+	compressed := gzip.NewWriter(&buffer)
+	archive := tar.NewWriter(compressed)
+	for _, name := range names {
+		content := files[name]
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}
+		if err := archive.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := archive.Write(content); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	if err := compressed.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
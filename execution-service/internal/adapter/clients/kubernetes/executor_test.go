@@ -0,0 +1,573 @@
+package kubernetes
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"go-compiler/common/pkg/broker"
+	"go-compiler/common/pkg/config"
+	"go-compiler/common/pkg/enums"
+	"go-compiler/common/pkg/events"
+	"go-compiler/common/pkg/runner"
+	"go-compiler/models/languages"
+	"go-compiler/models/results"
+	"go-compiler/models/submissions"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	v1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// jobRun is what the fake kubelet makes of one Job
+type jobRun struct {
+	compileExit   int32
+	compileOutput string
+	logs          string
+	runnerExit    int32
+	runnerReason  string
+	// deadline fails the Job as if it ran past its active deadline
+	deadline bool
+}
+
+// kubelet decides how the job-th Job of a test, carrying stdins, runs
+type kubelet func(job int, stdins []string) jobRun
+
+// fakeCluster runs the real client against the fake clientset. A fake kubelet finishes every Job it
+// creates, and the runner and compiler logs it writes are served by GetJobLogs, which the fake
+// clientset cannot fake.
+type fakeCluster struct {
+	*KubernetesClient
+	clientset *fake.Clientset
+	kubelet   kubelet
+
+	mu   sync.Mutex
+	jobs []*v1.Job
+	logs map[string]map[string]string
+}
+
+func newFakeCluster(t *testing.T, kubelet kubelet) *fakeCluster {
+	clientset := fake.NewSimpleClientset()
+	cluster := &fakeCluster{
+		KubernetesClient: NewKubernetesClientForClientset(clientset, "test"),
+		clientset:        clientset,
+		kubelet:          kubelet,
+		logs:             map[string]map[string]string{},
+	}
+	clientset.PrependReactor("create", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		job := action.(k8stesting.CreateAction).GetObject().(*v1.Job).DeepCopy()
+		cluster.mu.Lock()
+		index := len(cluster.jobs)
+		cluster.jobs = append(cluster.jobs, job)
+		cluster.mu.Unlock()
+		// The clientset is locked while reactors run, so the Job is finished once it was stored
+		go cluster.run(t, index, job)
+		return false, nil, nil
+	})
+	// The fake clientset does not replay what changed before the watch started, so Jobs are only
+	// created once it has. The clientset is locked until the watch is registered.
+	watching := make(chan struct{})
+	var once sync.Once
+	clientset.PrependWatchReactor("jobs", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		once.Do(func() { close(watching) })
+		return false, nil, nil
+	})
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	cluster.Start(ctx)
+	<-watching
+	return cluster
+}
+
+func (c *fakeCluster) GetJobLogs(jobName string, container string, limitBytes int64) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	logs, found := c.logs[jobName][container]
+	if !found {
+		return "", fmt.Errorf("no logs for container %s of job %s", container, jobName)
+	}
+	if limitBytes > 0 && int64(len(logs)) > limitBytes {
+		logs = logs[:limitBytes]
+	}
+	return logs, nil
+}
+
+// createdJobs returns the Jobs created so far
+func (c *fakeCluster) createdJobs() []*v1.Job {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*v1.Job(nil), c.jobs...)
+}
+
+// run plays the kubelet: it runs the Job's Pod as the test's kubelet says and reports the outcome
+// in the Pod's and the Job's status
+func (c *fakeCluster) run(t *testing.T, index int, job *v1.Job) {
+	ctx := context.Background()
+	stdins, err := unpackStdins(job)
+	if err != nil {
+		t.Errorf("job %s: %v", job.Name, err)
+		return
+	}
+	outcome := c.kubelet(index, stdins)
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: job.Name + "-pod", Labels: map[string]string{"job-name": job.Name}}}
+	logs := map[string]string{RunnerContainer: outcome.logs}
+	pod.Status.InitContainerStatuses = append(pod.Status.InitContainerStatuses, terminated(LoaderContainer, 0, ""))
+	compiled := len(job.Spec.Template.Spec.InitContainers) > 1
+	if compiled {
+		pod.Status.InitContainerStatuses = append(pod.Status.InitContainerStatuses, terminated(CompilerContainer, outcome.compileExit, ""))
+		logs[CompilerContainer] = outcome.compileOutput
+	}
+	if outcome.compileExit == 0 {
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, terminated(RunnerContainer, outcome.runnerExit, outcome.runnerReason))
+	}
+	c.mu.Lock()
+	c.logs[job.Name] = logs
+	c.mu.Unlock()
+	if _, err := c.clientset.CoreV1().Pods("test").Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		t.Errorf("failed to create the pod of job %s: %v", job.Name, err)
+		return
+	}
+
+	finished := job.DeepCopy()
+	switch {
+	case outcome.deadline:
+		finished.Status.Failed = 1
+		finished.Status.Conditions = []v1.JobCondition{{Type: v1.JobFailed, Status: corev1.ConditionTrue, Reason: v1.JobReasonDeadlineExceeded}}
+	case outcome.compileExit != 0 || outcome.runnerExit != 0:
+		finished.Status.Failed = 1
+		finished.Status.Conditions = []v1.JobCondition{{Type: v1.JobFailed, Status: corev1.ConditionTrue, Reason: v1.JobReasonBackoffLimitExceeded}}
+	default:
+		finished.Status.Succeeded = 1
+	}
+	if _, err := c.clientset.BatchV1().Jobs("test").UpdateStatus(ctx, finished, metav1.UpdateOptions{}); err != nil {
+		t.Errorf("failed to finish job %s: %v", job.Name, err)
+	}
+}
+
+func terminated(name string, exitCode int32, reason string) corev1.ContainerStatus {
+	return corev1.ContainerStatus{
+		Name:  name,
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: exitCode, Reason: reason}},
+	}
+}
+
+// unpackStdins reads the stdin of each run from the archive the Job's loader unpacks
+func unpackStdins(job *v1.Job) ([]string, error) {
+	loader := job.Spec.Template.Spec.InitContainers[0]
+	if len(loader.Env) == 0 {
+		return nil, fmt.Errorf("loader has no archive")
+	}
+	archive, err := base64.StdEncoding.DecodeString(loader.Env[0].Value)
+	if err != nil {
+		return nil, err
+	}
+	return readStdins(archive)
+}
+
+// readStdins reads the stdin of each run from a tar.gz built by bundle
+func readStdins(archive []byte) ([]string, error) {
+	compressed, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	files := tar.NewReader(compressed)
+	stdins := map[int]string{}
+	for {
+		header, err := files.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		index, found := strings.CutPrefix(header.Name, stdinFile+".")
+		if !found {
+			continue
+		}
+		i, err := strconv.Atoi(index)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected stdin file %s", header.Name)
+		}
+		content, err := io.ReadAll(files)
+		if err != nil {
+			return nil, err
+		}
+		stdins[i] = string(content)
+	}
+	ordered := make([]string, len(stdins))
+	for i, stdin := range stdins {
+		if i >= len(ordered) {
+			return nil, fmt.Errorf("stdin files are not numbered from 0")
+		}
+		ordered[i] = stdin
+	}
+	return ordered, nil
+}
+
+// echo runs every stdin and logs it back as the run's stdout
+func echo(stdins []string) string {
+	var logs strings.Builder
+	for _, stdin := range stdins {
+		logs.WriteString(logRun(0, 5, 1000, 1<<20, 0, stdin, ""))
+	}
+	return logs.String()
+}
+
+// digests returns a short stand-in for each stdin, to echo stdins larger than the output limit
+func digests(stdins []string) []string {
+	short := make([]string, len(stdins))
+	for i, stdin := range stdins {
+		short[i] = fmt.Sprintf("%x", stdin[:min(len(stdin), 8)])
+	}
+	return short
+}
+
+// randomStdin returns size bytes that do not compress
+func randomStdin(random *rand.Rand, size int) string {
+	content := make([]byte, size)
+	random.Read(content)
+	return string(content)
+}
+
+var (
+	python = languages.LanguageModel{
+		LanguageId: 1,
+		Name:       "Python",
+		SourceFile: "main.py",
+		Image:      "python:3.9-slim",
+		RunCmd:     []string{"python3", "main.py"},
+	}
+	gcc = languages.LanguageModel{
+		LanguageId: 6,
+		Name:       "C",
+		SourceFile: "main.c",
+		Image:      "gcc:13",
+		CompileCmd: []string{"gcc", "-o", "main", "main.c"},
+		RunCmd:     []string{"./main"},
+	}
+)
+
+func TestJobExecutorExecuteAll(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	large := []string{randomStdin(random, 150<<10), randomStdin(random, 150<<10), randomStdin(random, 150<<10), randomStdin(random, 150<<10)}
+
+	tests := []struct {
+		name     string
+		language languages.LanguageModel
+		stdins   []string
+		kubelet  kubelet
+		// want holds the status of each run, or the one failed result when wantFailed is set
+		want       []enums.Status
+		wantFailed bool
+		wantJobs   int
+		check      func(t *testing.T, runs []results.ExecutionResult, failed results.ExecutionResult)
+	}{
+		{
+			name:     "a batch runs in one Job",
+			language: python,
+			stdins:   []string{"a", "b", "c"},
+			kubelet:  func(job int, stdins []string) jobRun { return jobRun{logs: echo(stdins)} },
+			want:     []enums.Status{enums.Accepted, enums.Accepted, enums.Accepted},
+			wantJobs: 1,
+			check: func(t *testing.T, runs []results.ExecutionResult, failed results.ExecutionResult) {
+				for i, stdout := range []string{"a", "b", "c"} {
+					if runs[i].Stdout != stdout || runs[i].Time != 0.05 || runs[i].CpuTime != 0.001 || runs[i].Memory != 1024 {
+						t.Errorf("run %d = %+v, want the stdout %q with its time and memory", i, runs[i], stdout)
+					}
+				}
+			},
+		},
+		{
+			name:     "a compiled batch is compiled once",
+			language: gcc,
+			stdins:   []string{"1", "2"},
+			kubelet: func(job int, stdins []string) jobRun {
+				return jobRun{compileOutput: "warning: unused variable", logs: echo(stdins)}
+			},
+			want:     []enums.Status{enums.Accepted, enums.Accepted},
+			wantJobs: 1,
+			check: func(t *testing.T, runs []results.ExecutionResult, failed results.ExecutionResult) {
+				if runs[0].CompileOutput != "warning: unused variable" {
+					t.Errorf("compile output = %q, want the compiler's warning", runs[0].CompileOutput)
+				}
+			},
+		},
+		{
+			name:     "a batch too large for one archive is split",
+			language: python,
+			stdins:   large,
+			kubelet:  func(job int, stdins []string) jobRun { return jobRun{logs: echo(digests(stdins))} },
+			want:     []enums.Status{enums.Accepted, enums.Accepted, enums.Accepted, enums.Accepted},
+			wantJobs: 2,
+			check: func(t *testing.T, runs []results.ExecutionResult, failed results.ExecutionResult) {
+				for i, digest := range digests(large) {
+					if runs[i].Stdout != digest {
+						t.Errorf("run %d got the stdout of another run", i)
+					}
+				}
+			},
+		},
+		{
+			name:     "runs past the log budget go to another Job",
+			language: python,
+			stdins:   []string{"a", "b", "c"},
+			kubelet:  func(job int, stdins []string) jobRun { return jobRun{logs: echo(stdins[:1])} },
+			want:     []enums.Status{enums.Accepted, enums.Accepted, enums.Accepted},
+			wantJobs: 3,
+			check: func(t *testing.T, runs []results.ExecutionResult, failed results.ExecutionResult) {
+				if runs[2].Stdout != "c" {
+					t.Errorf("last run = %q, want c", runs[2].Stdout)
+				}
+			},
+		},
+		{
+			name:     "each run gets its own verdict",
+			language: python,
+			stdins:   []string{"ok", "segfault", "oom", "slow", "loud"},
+			kubelet: func(job int, stdins []string) jobRun {
+				return jobRun{logs: logRun(0, 5, 1000, 1<<20, 0, "ok", "") +
+					logRun(139, 5, 1000, 1<<20, 0, "", "") +
+					logRun(137, 5, 1000, 64<<20, 1, "", "") +
+					logRun(0, 5, 1500000, 64<<20, 0, "", "") +
+					logRun(0, 5, 1000, 64<<20, 0, strings.Repeat("x", 1025), "")}
+			},
+			want:     []enums.Status{enums.Accepted, enums.RuntimeErrorSIGSEGV, enums.MemoryLimitExceeded, enums.TimeLimitExceeded, enums.RuntimeErrorSIGXFSZ},
+			wantJobs: 1,
+		},
+		{
+			name:     "a Job past its deadline fails the run it stopped in",
+			language: python,
+			stdins:   []string{"a", "b", "c"},
+			kubelet: func(job int, stdins []string) jobRun {
+				if job == 0 {
+					return jobRun{logs: echo(stdins[:1]), deadline: true}
+				}
+				return jobRun{logs: echo(stdins)}
+			},
+			want:     []enums.Status{enums.Accepted, enums.TimeLimitExceeded, enums.Accepted},
+			wantJobs: 2,
+		},
+		{
+			name:     "a runner killed for its memory fails the run it stopped in",
+			language: python,
+			stdins:   []string{"a", "b"},
+			kubelet: func(job int, stdins []string) jobRun {
+				return jobRun{logs: echo(stdins[:1]), runnerExit: 137, runnerReason: ReasonOOMKilled}
+			},
+			want:     []enums.Status{enums.Accepted, enums.MemoryLimitExceeded},
+			wantJobs: 1,
+		},
+		{
+			name:     "a compile failure stops the batch",
+			language: gcc,
+			stdins:   []string{"1", "2"},
+			kubelet: func(job int, stdins []string) jobRun {
+				return jobRun{compileExit: 1, compileOutput: "main.c:1: error: expected ';'"}
+			},
+			want:       []enums.Status{enums.CompilationError},
+			wantFailed: true,
+			wantJobs:   1,
+			check: func(t *testing.T, runs []results.ExecutionResult, failed results.ExecutionResult) {
+				if failed.CompileOutput != "main.c:1: error: expected ';'" || failed.ExitCode != 1 {
+					t.Errorf("failed result = %+v, want the compiler's output and exit code", failed)
+				}
+			},
+		},
+		{
+			name:       "unreadable runner output is an internal error",
+			language:   python,
+			stdins:     []string{"a"},
+			kubelet:    func(job int, stdins []string) jobRun { return jobRun{logs: "sh: exec format error\n"} },
+			want:       []enums.Status{enums.InternalError},
+			wantFailed: true,
+			wantJobs:   1,
+		},
+		{
+			name:       "more runs logged than asked for is an internal error",
+			language:   python,
+			stdins:     []string{"a"},
+			kubelet:    func(job int, stdins []string) jobRun { return jobRun{logs: echo([]string{"a", "b"})} },
+			want:       []enums.Status{enums.InternalError},
+			wantFailed: true,
+			wantJobs:   1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cluster := newFakeCluster(t, test.kubelet)
+			executor := NewJobExecutor(cluster, "")
+			submission := runner.Submission{
+				Language:  test.language,
+				Code:      []byte("code"),
+				Limits:    submissions.Limits{CpuTimeLimit: 1, WallTimeLimit: 2, MaxOutputSize: 1, MaxProcesses: 16, MemoryLimit: 65536, CompileTimeLimit: 10},
+				RequestId: "request",
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			runs, failed, ok := executor.ExecuteAll(ctx, submission, test.stdins)
+			if ok == test.wantFailed {
+				t.Fatalf("ExecuteAll ok = %v with %+v, want %v", ok, failed, !test.wantFailed)
+			}
+			statuses := make([]enums.Status, 0, len(runs))
+			if test.wantFailed {
+				statuses = append(statuses, statusOf(failed))
+			}
+			for _, run := range runs {
+				statuses = append(statuses, statusOf(run))
+			}
+			if fmt.Sprint(statuses) != fmt.Sprint(test.want) {
+				t.Errorf("statuses = %v, want %v", statuses, test.want)
+			}
+			if test.check != nil {
+				test.check(t, runs, failed)
+			}
+
+			jobs := cluster.createdJobs()
+			if len(jobs) != test.wantJobs {
+				t.Errorf("created %d Jobs, want %d", len(jobs), test.wantJobs)
+			}
+			remaining, err := cluster.clientset.BatchV1().Jobs("test").List(ctx, metav1.ListOptions{})
+			if err != nil || len(remaining.Items) != 0 {
+				t.Errorf("%d Jobs were left behind (%v)", len(remaining.Items), err)
+			}
+		})
+	}
+}
+
+func TestWrappedJobExecutorRunsBatchesInOneJob(t *testing.T) {
+	reporter := events.NewReporter(nil, nil)
+	wrappers := []struct {
+		name string
+		wrap func(runner.Executor) runner.Executor
+	}{
+		{
+			name: "stages",
+			wrap: func(executor runner.Executor) runner.Executor { return reporter.Progress("", "").Executor(executor) },
+		},
+		{
+			name: "streamed output",
+			wrap: func(executor runner.Executor) runner.Executor {
+				output := events.NewReporter(discard{}, nil).Output("request", "connection")
+				t.Cleanup(output.Close)
+				return output.Executor(executor)
+			},
+		},
+		{
+			name: "interactive input",
+			wrap: func(executor runner.Executor) runner.Executor {
+				input, err := events.OpenInput(silent{}, "request", "connection", config.Interactive{IdleTimeout: 1, SessionLimit: 60})
+				if err != nil {
+					t.Fatalf("OpenInput() error = %v", err)
+				}
+				t.Cleanup(input.Close)
+				return input.Executor(executor)
+			},
+		},
+	}
+	for _, wrapper := range wrappers {
+		t.Run(wrapper.name, func(t *testing.T) {
+			cluster := newFakeCluster(t, func(job int, stdins []string) jobRun { return jobRun{logs: echo(stdins)} })
+			executor := wrapper.wrap(NewJobExecutor(cluster, ""))
+			submission := runner.Submission{
+				Language: python,
+				Code:     []byte("code"),
+				Limits:   submissions.Limits{WallTimeLimit: 2, MaxOutputSize: 1, MemoryLimit: 65536},
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			runs, failed, ok := runner.ExecuteAll(ctx, executor, submission, []string{"a", "b", "c"})
+			if !ok || len(runs) != 3 {
+				t.Fatalf("ExecuteAll = %d runs, ok %v with %+v; want 3 runs", len(runs), ok, failed)
+			}
+			jobs := cluster.createdJobs()
+			if len(jobs) != 1 {
+				t.Fatalf("created %d Jobs, want the batch in one", len(jobs))
+			}
+			// Jobs do not read interactive input, so their runs keep the wall time limit
+			if deadline := jobs[0].Spec.ActiveDeadlineSeconds; deadline == nil || *deadline != jobStartupMargin+3*(2+1) {
+				t.Errorf("active deadline = %v, want %d", deadline, jobStartupMargin+3*(2+1))
+			}
+		})
+	}
+}
+
+// discard publishes events nowhere
+type discard struct{}
+
+func (discard) PublishEvent(exchange string, key string, body []byte) error {
+	return nil
+}
+
+// silent is a subscriber whose queues never receive anything
+type silent struct{}
+
+func (silent) ConsumeTransient(queue string, tag string, bindings ...broker.Binding) (<-chan amqp.Delivery, error) {
+	return make(chan amqp.Delivery), nil
+}
+
+func (silent) Cancel(tag string) error {
+	return nil
+}
+
+func TestJobExecutorJobSpec(t *testing.T) {
+	cluster := newFakeCluster(t, func(job int, stdins []string) jobRun { return jobRun{logs: echo(stdins)} })
+	executor := NewJobExecutor(cluster, "")
+	submission := runner.Submission{
+		Language: gcc,
+		Code:     []byte("int main() {}"),
+		Limits:   submissions.Limits{WallTimeLimit: 2.5, MemoryLimit: 65536, CompileTimeLimit: 10},
+	}
+	if _, _, ok := executor.ExecuteAll(context.Background(), submission, []string{"1", "2", "3"}); !ok {
+		t.Fatal("ExecuteAll failed")
+	}
+	job := cluster.createdJobs()[0]
+
+	// The Pod gets the startup margin, the compile its time limit and each of the three runs its wall
+	// time limit plus a second
+	if deadline := job.Spec.ActiveDeadlineSeconds; deadline == nil || *deadline != jobStartupMargin+10+3*(3+1) {
+		t.Errorf("active deadline = %v, want %d", deadline, jobStartupMargin+10+3*(3+1))
+	}
+	spec := job.Spec.Template.Spec
+	if len(spec.InitContainers) != 2 || spec.InitContainers[1].Name != CompilerContainer {
+		t.Fatalf("init containers = %v, want the loader and the compiler", spec.InitContainers)
+	}
+	if compile := strings.Join(spec.InitContainers[1].Command, " "); compile != "timeout -s KILL 10 gcc -o main main.c" {
+		t.Errorf("compiler command = %q, want gcc killed at the compile time limit", compile)
+	}
+	if memory := spec.Containers[0].Resources.Limits.Memory().Value(); memory != 65536*1024 {
+		t.Errorf("runner memory limit = %d, want the submission's", memory)
+	}
+	if script := spec.Containers[0].Command[2]; !strings.Contains(script, `while [ "$i" -lt 3 ]`) {
+		t.Errorf("runner does not run the program three times:\n%s", script)
+	}
+	if security := spec.Containers[0].SecurityContext; security == nil || !*security.RunAsNonRoot || *security.AllowPrivilegeEscalation {
+		t.Errorf("runner security context = %+v, want it hardened", security)
+	}
+}
+
+// statusOf returns the verdict of a result
+func statusOf(result results.ExecutionResult) enums.Status {
+	for status := enums.Queue; status <= enums.MemoryLimitExceeded; status++ {
+		if result.Status == results.NewStatus(status) {
+			return status
+		}
+	}
+	return 0
+}
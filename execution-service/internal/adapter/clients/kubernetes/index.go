@@ -2,10 +2,12 @@ package kubernetes
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"go-compiler/models/submissions"
 	"io"
-	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/client-go/tools/clientcmd"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
@@ -13,23 +15,105 @@ import (
 
 	v1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
 
+const (
+	// CodeDir is the emptyDir every container of an execution Job mounts
+	CodeDir = "/code"
+
+	// Container names of an execution Job, in the order they run
+	LoaderContainer   = "code-loader"
+	CompilerContainer = "code-compiler"
+	RunnerContainer   = "code-runner"
+
+	// NetworkLabel marks execution pods the isolation NetworkPolicy applies to
+	NetworkLabel    = "code-execution/network"
+	NetworkIsolated = "isolated"
+	// NetworkPolicyName is the policy EnsureNetworkPolicy creates
+	NetworkPolicyName = "code-execution-isolated"
+)
+
+// ErrJobFailed is wrapped by every JobFailure
+var ErrJobFailed = errors.New("job failed")
+
+// Reasons a JobFailure reports
+const (
+	ReasonDeadlineExceeded = "DeadlineExceeded"
+	ReasonOOMKilled        = "OOMKilled"
+	ReasonNonZeroExit      = "NonZeroExit"
+	ReasonCompileFailed    = "CompileFailed"
+	ReasonFailed           = "Failed"
+)
+
+// JobFailure is returned by WaitForJobCompletion when the Job finished without succeeding
+type JobFailure struct {
+	JobName string
+	Reason  string
+	// ExitCode of the container that failed, when one did
+	ExitCode int32
+}
+
+func (f *JobFailure) Error() string {
+	if f.ExitCode != 0 {
+		return fmt.Sprintf("job %s failed: %s (exit code %d)", f.JobName, f.Reason, f.ExitCode)
+	}
+	return fmt.Sprintf("job %s failed: %s", f.JobName, f.Reason)
+}
+
+func (f *JobFailure) Unwrap() error {
+	return ErrJobFailed
+}
+
+// completionGrace is how long past its active deadline a Job may take to be reported as finished
+const completionGrace = 30 * time.Second
+
+// JobRequest describes one execution Job. The loader container unpacks Archive into CodeDir, the
+// compiler container runs CompileCmd there when it is set and the runner container runs RunCmd,
+// which runs the program Runs times.
+type JobRequest struct {
+	Image       string
+	LoaderImage string
+	Archive     string // base64 encoded tar.gz unpacked into CodeDir
+	CompileCmd  []string
+	RunCmd      []string
+	Env         []string
+	Limits      submissions.Limits
+	Runs        int
+	Profile     Profile
+	// CompileMemoryLimit is the compiler container's memory limit in KB
+	CompileMemoryLimit int64
+
+	// RequestId, LanguageId, Language and Tenant label and annotate the Job. They are optional.
+	RequestId  string
+	LanguageId int64
+	Language   string
+	Tenant     string
+}
+
 // IKubernetesClient defines the interface for interacting with Kubernetes Jobs.
 type IKubernetesClient interface {
-	CreateJob(imageName string, cmd []string, env []string) (string, error)
-	WaitForJobCompletion(jobName string) error
-	GetJobLogs(jobName string) (string, error)
+	CreateJob(request JobRequest) (string, error)
+	WaitForJobCompletion(ctx context.Context, jobName string, activeDeadline int64) error
+	GetJobPod(jobName string) (*corev1.Pod, error)
+	GetJobLogs(jobName string, container string, limitBytes int64) (string, error)
 	DeleteJob(jobName string) error
+	ListRequestJobs(requestId string) ([]v1.Job, error)
 }
 
 // KubernetesClient is a client for creating and managing Kubernetes Jobs.
 type KubernetesClient struct {
-	clientset *kubernetes.Clientset
+	clientset kubernetes.Interface
 	namespace string
+	// config is needed to exec into Pods; clients built from a clientset alone cannot
+	config *rest.Config
+
+	// tracker watches Jobs for WaitForJobCompletion once Start was called
+	tracker *jobTracker
 }
 
 // NewKubernetesClient creates a new Kubernetes client.
@@ -50,7 +134,15 @@ func NewKubernetesClient(namespace string) (*KubernetesClient, error) {
 		return nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
 	}
 
-	return &KubernetesClient{clientset: clientset, namespace: namespace}, nil
+	client := NewKubernetesClientForClientset(clientset, namespace)
+	client.config = config
+	return client, nil
+}
+
+// NewKubernetesClientForClientset creates a client on top of an existing clientset, such as the fake
+// clientset from k8s.io/client-go/kubernetes/fake.
+func NewKubernetesClientForClientset(clientset kubernetes.Interface, namespace string) *KubernetesClient {
+	return &KubernetesClient{clientset: clientset, namespace: namespace}
 }
 
 // homeDir returns the home directory for the executing user.
@@ -61,54 +153,75 @@ func homeDir() string {
 	return os.Getenv("USERPROFILE") // Windows compatibility
 }
 
-// CreateJob creates a Kubernetes Job to execute the given command in the provided container image.
-func (k *KubernetesClient) CreateJob(imageName string, cmd []string, env []string) (string, error) {
-	jobName := fmt.Sprintf("code-job-%d", time.Now().Unix())
+// CreateJob creates a Kubernetes Job that loads, compiles and runs one submission.
+// The memory limits become the container memory limits and the compile and wall time limits the Job's active deadline.
+func (k *KubernetesClient) CreateJob(request JobRequest) (string, error) {
+	jobName := newJobName(request.RequestId)
+	jobLabels, jobAnnotations := jobMetadata(request)
+	limits := request.Limits
+
+	var activeDeadlineSeconds *int64
+	if deadline := request.ActiveDeadline(); deadline > 0 {
+		activeDeadlineSeconds = &deadline
+	}
+	backoffLimit := int32(0)
+
+	profile := request.Profile
+	resources := profile.containerResources(limits.MemoryLimit)
+	securityContext := profile.containerSecurityContext()
+	env := append(createEnvVars(request.Env), homeEnv)
+
+	initContainers := []corev1.Container{
+		{
+			Name:            LoaderContainer,
+			Image:           request.LoaderImage,
+			Command:         []string{"sh", "-c", `echo "$CODE_ARCHIVE" | base64 -d | tar -xzf - -C ` + CodeDir},
+			Env:             []corev1.EnvVar{{Name: "CODE_ARCHIVE", Value: request.Archive}},
+			VolumeMounts:    volumeMounts,
+			SecurityContext: securityContext,
+		},
+	}
+	if len(request.CompileCmd) > 0 {
+		initContainers = append(initContainers, corev1.Container{
+			Name:            CompilerContainer,
+			Image:           request.Image,
+			Command:         request.CompileCmd,
+			WorkingDir:      CodeDir,
+			Env:             env,
+			VolumeMounts:    volumeMounts,
+			Resources:       profile.containerResources(request.CompileMemoryLimit),
+			SecurityContext: securityContext,
+		})
+	}
+	containers := []corev1.Container{
+		{
+			Name:            RunnerContainer,
+			Image:           request.Image,
+			Command:         request.RunCmd,
+			WorkingDir:      CodeDir,
+			VolumeMounts:    volumeMounts,
+			Env:             env,
+			Resources:       resources,
+			SecurityContext: securityContext,
+		},
+	}
 
 	job := &v1.Job{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: jobName,
-			Labels: map[string]string{
-				"frontend": "code-execution",
-			},
+			Name:        jobName,
+			Labels:      jobLabels,
+			Annotations: jobAnnotations,
 		},
 		Spec: v1.JobSpec{
+			ActiveDeadlineSeconds:   activeDeadlineSeconds,
+			BackoffLimit:            &backoffLimit,
+			TTLSecondsAfterFinished: ptr(profile.TtlSecondsAfterFinished),
 			Template: corev1.PodTemplateSpec{
-				Spec: corev1.PodSpec{
-					RestartPolicy: corev1.RestartPolicyNever,
-					Containers: []corev1.Container{
-						{
-							Name:    "code-runner",
-							Image:   imageName,
-							Command: cmd,
-							VolumeMounts: []corev1.VolumeMount{
-								{
-									Name:      "code-volume",
-									MountPath: "/code",
-								},
-							},
-							Env: createEnvVars(env),
-							Resources: corev1.ResourceRequirements{
-								Requests: corev1.ResourceList{
-									corev1.ResourceCPU:    resource.MustParse("100m"),
-									corev1.ResourceMemory: resource.MustParse("128Mi"),
-								},
-								Limits: corev1.ResourceList{
-									corev1.ResourceCPU:    resource.MustParse("500m"),
-									corev1.ResourceMemory: resource.MustParse("256Mi"),
-								},
-							},
-						},
-					},
-					Volumes: []corev1.Volume{
-						{
-							Name: "code-volume",
-							VolumeSource: corev1.VolumeSource{
-								EmptyDir: &corev1.EmptyDirVolumeSource{},
-							},
-						},
-					},
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      profile.podLabels(jobLabels),
+					Annotations: jobAnnotations,
 				},
+				Spec: profile.podSpec(initContainers, containers),
 			},
 		},
 	}
@@ -121,58 +234,154 @@ func (k *KubernetesClient) CreateJob(imageName string, cmd []string, env []strin
 	return jobName, nil
 }
 
-// WaitForJobCompletion waits for the Kubernetes Job to complete successfully.
-func (k *KubernetesClient) WaitForJobCompletion(jobName string) error {
-	timeout := time.After(10 * time.Minute) // Set a timeout to avoid indefinite waiting
-	for {
-		select {
-		case <-timeout:
-			return fmt.Errorf("timed out waiting for job %s to complete", jobName)
-		default:
-			job, err := k.clientset.BatchV1().Jobs(k.namespace).Get(context.TODO(), jobName, metav1.GetOptions{})
-			if err != nil {
-				return fmt.Errorf("failed to get Job status: %v", err)
-			}
+// jobStartupMargin is added to every Job's deadline, which also counts scheduling the Pod, pulling
+// its images and loading the code. The compiler and runner enforce their time limits themselves, so
+// the deadline only stops Jobs that hang.
+const jobStartupMargin = 60
 
-			if job.Status.Succeeded > 0 {
-				return nil // Job completed successfully
-			}
+// ActiveDeadline is the Job's deadline in seconds: the startup margin, the compile time limit and
+// each run's wall time limit plus a second to measure and report it. It is 0, no deadline, without a
+// wall time limit.
+func (r JobRequest) ActiveDeadline() int64 {
+	if r.Limits.WallTimeLimit <= 0 {
+		return 0
+	}
+	run := int64(math.Ceil(r.Limits.WallTimeLimit)) + 1
+	return jobStartupMargin + int64(math.Ceil(r.Limits.CompileTimeLimit)) + int64(max(r.Runs, 1))*run
+}
+
+// WaitForJobCompletion waits for the Kubernetes Job to finish, watching Jobs instead of polling.
+// It gives up when ctx is done or the Job outlives activeDeadline, its deadline in seconds, and
+// reports a Job that finished without succeeding as a *JobFailure saying why. A Job deleted before
+// it finished, such as by hand, is reported as ErrJobDeleted.
+func (k *KubernetesClient) WaitForJobCompletion(ctx context.Context, jobName string, activeDeadline int64) error {
+	tracker := k.tracker
+	if tracker == nil {
+		return fmt.Errorf("cannot wait for job %s: the Job watch was not started", jobName)
+	}
+	if err := tracker.synced(ctx); err != nil {
+		return fmt.Errorf("stopped waiting for job %s: %w", jobName, err)
+	}
+
+	timeout := 10 * time.Minute
+	if activeDeadline > 0 {
+		timeout = time.Duration(activeDeadline)*time.Second + completionGrace
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	job, err := tracker.wait(waitCtx, jobName)
+	if errors.Is(err, ErrJobDeleted) {
+		return fmt.Errorf("stopped waiting for job %s: %w", jobName, err)
+	}
+	// Only our own timeout means the Job ran too long; a cancelled ctx means the caller gave up
+	if err != nil && ctx.Err() == nil {
+		return &JobFailure{JobName: jobName, Reason: ReasonDeadlineExceeded}
+	}
+	if err != nil {
+		return fmt.Errorf("stopped waiting for job %s: %w", jobName, err)
+	}
+	if job.Status.Succeeded > 0 {
+		return nil // Job completed successfully
+	}
+	return k.classifyFailure(job)
+}
 
-			if job.Status.Failed > 0 {
-				return fmt.Errorf("job %s failed", jobName)
+// Start watches execution Jobs until ctx, the lifetime of the service, is done. WaitForJobCompletion
+// waits through the watch, so Start must be called once before the first Job is created.
+func (k *KubernetesClient) Start(ctx context.Context) {
+	k.tracker = newJobTracker(k.clientset, k.namespace)
+	k.tracker.start(ctx)
+}
+
+// classifyFailure works out from the Job and its Pod why the Job failed
+func (k *KubernetesClient) classifyFailure(job *v1.Job) *JobFailure {
+	failure := &JobFailure{JobName: job.Name, Reason: ReasonFailed}
+	if reason, _ := failureReason(job); reason == v1.JobReasonDeadlineExceeded {
+		failure.Reason = ReasonDeadlineExceeded
+		return failure
+	}
+
+	pod, err := k.GetJobPod(job.Name)
+	if err != nil {
+		return failure
+	}
+	for _, status := range pod.Status.InitContainerStatuses {
+		if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+			failure.ExitCode = terminated.ExitCode
+			if status.Name == CompilerContainer {
+				failure.Reason = ReasonCompileFailed
 			}
+			return failure
+		}
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+			failure.ExitCode = terminated.ExitCode
+			failure.Reason = ReasonNonZeroExit
+			if terminated.Reason == ReasonOOMKilled {
+				failure.Reason = ReasonOOMKilled
+			}
+			return failure
+		}
+	}
+	return failure
+}
+
+// jobFailed reports whether the Job has given up, for example because its deadline passed
+func jobFailed(job *v1.Job) bool {
+	_, failed := failureReason(job)
+	return failed
+}
 
-			time.Sleep(2 * time.Second) // Poll every 2 seconds
+// failureReason returns the reason of the Job's failed condition, such as DeadlineExceeded
+func failureReason(job *v1.Job) (string, bool) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == v1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return condition.Reason, true
 		}
 	}
+	return "", false
 }
 
-// GetJobLogs retrieves the logs from the Pod created by the Job.
-func (k *KubernetesClient) GetJobLogs(jobName string) (string, error) {
+// GetJobPod returns the Pod created by the Job.
+func (k *KubernetesClient) GetJobPod(jobName string) (*corev1.Pod, error) {
 	// List Pods created by the Job, using job-name as the label selector.
 	podList, err := k.clientset.CoreV1().Pods(k.namespace).List(context.TODO(), metav1.ListOptions{
 		LabelSelector: fmt.Sprintf("job-name=%s", jobName),
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to get Pod list for Job %s: %v", jobName, err)
+		return nil, fmt.Errorf("failed to get Pod list for Job %s: %v", jobName, err)
 	}
 
 	if len(podList.Items) == 0 {
-		return "", fmt.Errorf("no Pods found for Job %s", jobName)
+		return nil, fmt.Errorf("no Pods found for Job %s", jobName)
+	}
+	return &podList.Items[0], nil
+}
+
+// GetJobLogs retrieves up to limitBytes of logs of one container of the Pod created by the Job.
+// A limitBytes of zero reads all logs.
+func (k *KubernetesClient) GetJobLogs(jobName string, container string, limitBytes int64) (string, error) {
+	pod, err := k.GetJobPod(jobName)
+	if err != nil {
+		return "", err
 	}
 
-	// Get logs from the first Pod
-	podName := podList.Items[0].Name
-	logs, err := k.clientset.CoreV1().Pods(k.namespace).GetLogs(podName, &corev1.PodLogOptions{}).Stream(context.TODO())
+	options := &corev1.PodLogOptions{Container: container}
+	if limitBytes > 0 {
+		options.LimitBytes = &limitBytes
+	}
+	logs, err := k.clientset.CoreV1().Pods(k.namespace).GetLogs(pod.Name, options).Stream(context.TODO())
 	if err != nil {
-		return "", fmt.Errorf("failed to get logs for Pod %s: %v", podName, err)
+		return "", fmt.Errorf("failed to get logs for Pod %s: %v", pod.Name, err)
 	}
 	defer logs.Close()
 
 	var logData strings.Builder
 	_, err = io.Copy(&logData, logs)
 	if err != nil {
-		return "", fmt.Errorf("failed to read logs from Pod %s: %v", podName, err)
+		return "", fmt.Errorf("failed to read logs from Pod %s: %v", pod.Name, err)
 	}
 
 	return logData.String(), nil
@@ -180,7 +389,7 @@ func (k *KubernetesClient) GetJobLogs(jobName string) (string, error) {
 
 // DeleteJob deletes the Job and its associated Pods.
 func (k *KubernetesClient) DeleteJob(jobName string) error {
-	deletePolicy := metav1.DeletePropagationForeground
+	deletePolicy := metav1.DeletePropagationBackground
 	err := k.clientset.BatchV1().Jobs(k.namespace).Delete(context.TODO(), jobName, metav1.DeleteOptions{
 		PropagationPolicy: &deletePolicy,
 	})
@@ -190,6 +399,52 @@ func (k *KubernetesClient) DeleteJob(jobName string) error {
 	return nil
 }
 
+// ListRequestJobs returns the execution Jobs of a submission. A submission runs as one Job that
+// compiles it and runs every test case inside it; only test cases that do not fit in one Job's
+// archive or logs spill over into further Jobs. The label narrows the list down and the annotation
+// holds the exact request ID.
+func (k *KubernetesClient) ListRequestJobs(requestId string) ([]v1.Job, error) {
+	jobs, err := k.clientset.BatchV1().Jobs(k.namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", RequestIdLabel, labelValue(requestId)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Jobs of request %s: %v", requestId, err)
+	}
+	requestJobs := make([]v1.Job, 0, len(jobs.Items))
+	for _, job := range jobs.Items {
+		if job.Annotations[RequestIdAnnotation] == requestId {
+			requestJobs = append(requestJobs, job)
+		}
+	}
+	return requestJobs, nil
+}
+
+// EnsureNetworkPolicy creates the NetworkPolicy that denies all ingress and egress to execution
+// pods labelled as isolated, unless it already exists.
+func (k *KubernetesClient) EnsureNetworkPolicy() error {
+	policy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: NetworkPolicyName,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: map[string]string{NetworkLabel: NetworkIsolated},
+			},
+			// No rules: nothing is allowed in either direction
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+		},
+	}
+	_, err := k.clientset.NetworkingV1().NetworkPolicies(k.namespace).Create(context.TODO(), policy, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create NetworkPolicy %s: %v", NetworkPolicyName, err)
+	}
+	return nil
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
 // createEnvVars creates a list of Kubernetes environment variables from a string slice.
 func createEnvVars(env []string) []corev1.EnvVar {
 	envVars := []corev1.EnvVar{}
@@ -0,0 +1,76 @@
+package kubernetes
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/rand"
+)
+
+const (
+	// Labels and annotations every execution Job carries so it can be traced back to its submission.
+	// Labels are shortened to what Kubernetes accepts; annotations keep the exact values.
+	RequestIdLabel = "code-execution/request-id"
+	LanguageLabel  = "code-execution/language-id"
+	TenantLabel    = "code-execution/tenant"
+
+	RequestIdAnnotation = "code-execution/request-id"
+	LanguageAnnotation  = "code-execution/language"
+	TenantAnnotation    = "code-execution/tenant"
+
+	jobNamePrefix = "code-job-"
+	// jobNameSuffixLength random characters keep names unique across Jobs of the same request
+	jobNameSuffixLength = 6
+	// maxJobNameLength keeps the job-name label Kubernetes puts on the Pods valid
+	maxJobNameLength = 63
+	maxLabelLength   = 63
+)
+
+var invalidLabelCharacters = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// newJobName derives a unique DNS-1123 name from the request ID, such as code-job-3f2a9c-x7k2bq
+func newJobName(requestId string) string {
+	suffix := rand.String(jobNameSuffixLength)
+	base := strings.Trim(invalidLabelCharacters.ReplaceAllString(strings.ToLower(requestId), "-"), "-._")
+	base = strings.NewReplacer(".", "-", "_", "-").Replace(base)
+
+	maxBase := maxJobNameLength - len(jobNamePrefix) - len(suffix) - 1
+	if len(base) > maxBase {
+		base = strings.TrimRight(base[:maxBase], "-")
+	}
+	if base == "" {
+		return jobNamePrefix + suffix
+	}
+	return jobNamePrefix + base + "-" + suffix
+}
+
+// labelValue shortens a value to a valid label value, which may be empty
+func labelValue(value string) string {
+	value = invalidLabelCharacters.ReplaceAllString(value, "-")
+	if len(value) > maxLabelLength {
+		value = value[:maxLabelLength]
+	}
+	return strings.Trim(value, "-._")
+}
+
+// jobMetadata returns the labels and annotations of the Job running request
+func jobMetadata(request JobRequest) (map[string]string, map[string]string) {
+	labels := map[string]string{
+		"frontend": "code-execution",
+	}
+	annotations := map[string]string{}
+	if request.RequestId != "" {
+		labels[RequestIdLabel] = labelValue(request.RequestId)
+		annotations[RequestIdAnnotation] = request.RequestId
+	}
+	if request.LanguageId != 0 {
+		labels[LanguageLabel] = strconv.FormatInt(request.LanguageId, 10)
+		annotations[LanguageAnnotation] = request.Language
+	}
+	if request.Tenant != "" {
+		labels[TenantLabel] = labelValue(request.Tenant)
+		annotations[TenantAnnotation] = request.Tenant
+	}
+	return labels, annotations
+}
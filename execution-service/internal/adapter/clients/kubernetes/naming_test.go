@@ -0,0 +1,70 @@
+package kubernetes
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// dns1123Label is what Kubernetes accepts as a Job name that also fits the job-name label
+var dns1123Label = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+func TestNewJobName(t *testing.T) {
+	tests := []struct {
+		requestId  string
+		wantPrefix string
+	}{
+		{"114ecba7-61fb-4ae8-ad15-f67b44c07da7", "code-job-114ecba7-61fb-4ae8-ad15-f67b44c07da7-"},
+		{"Request_ID.42", "code-job-request-id-42-"},
+		{"--weird//id--", "code-job-weird-id-"},
+		{"", "code-job-"},
+		{"!!!", "code-job-"},
+		{strings.Repeat("a", 100), "code-job-" + strings.Repeat("a", maxJobNameLength-len(jobNamePrefix)-jobNameSuffixLength-1) + "-"},
+		{strings.Repeat("a", 46) + "-b", "code-job-" + strings.Repeat("a", 46) + "-"},
+	}
+	for _, test := range tests {
+		name := newJobName(test.requestId)
+		if !strings.HasPrefix(name, test.wantPrefix) || len(name) != len(test.wantPrefix)+jobNameSuffixLength {
+			t.Errorf("newJobName(%q) = %q, want %q and a %d character suffix", test.requestId, name, test.wantPrefix, jobNameSuffixLength)
+		}
+		if len(name) > maxJobNameLength || !dns1123Label.MatchString(name) {
+			t.Errorf("newJobName(%q) = %q, which is not a valid Job name", test.requestId, name)
+		}
+	}
+
+	// Jobs of the same request never share a name
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		name := newJobName("request")
+		if seen[name] {
+			t.Fatalf("newJobName returned %q twice", name)
+		}
+		seen[name] = true
+	}
+}
+
+func TestJobMetadata(t *testing.T) {
+	labels, annotations := jobMetadata(JobRequest{
+		RequestId:  "Request/With Spaces",
+		LanguageId: 1,
+		Language:   "Python",
+		Tenant:     strings.Repeat("t", 100),
+	})
+	if labels[RequestIdLabel] != "Request-With-Spaces" || annotations[RequestIdAnnotation] != "Request/With Spaces" {
+		t.Errorf("request id label %q and annotation %q", labels[RequestIdLabel], annotations[RequestIdAnnotation])
+	}
+	if labels[LanguageLabel] != "1" || annotations[LanguageAnnotation] != "Python" {
+		t.Errorf("language label %q and annotation %q", labels[LanguageLabel], annotations[LanguageAnnotation])
+	}
+	if len(labels[TenantLabel]) != maxLabelLength || len(annotations[TenantAnnotation]) != 100 {
+		t.Errorf("tenant label of %d and annotation of %d characters", len(labels[TenantLabel]), len(annotations[TenantAnnotation]))
+	}
+	if labels["frontend"] != "code-execution" {
+		t.Errorf("Job is missing the label the Job watch selects")
+	}
+
+	labels, _ = jobMetadata(JobRequest{})
+	if len(labels) != 1 {
+		t.Errorf("labels without a request = %v, want only the watch label", labels)
+	}
+}
@@ -0,0 +1,167 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+const (
+	// PoolLabel marks the idle sandbox Pods of the warm pool
+	PoolLabel = "code-execution/pool"
+	PoolWarm  = "warm"
+)
+
+// PodRequest describes a long-running sandbox Pod that submissions are exec'd into
+type PodRequest struct {
+	Name        string
+	Image       string
+	Labels      map[string]string
+	Profile     Profile
+	MemoryLimit int64 // KB
+	// Lifetime bounds how long the Pod may exist, so Pods of a crashed service do not linger
+	Lifetime int64 // seconds
+}
+
+// IPodClient manages the sandbox Pods of the warm pool
+type IPodClient interface {
+	CreatePod(request PodRequest) error
+	WaitForPodRunning(ctx context.Context, podName string) error
+	ExecInPod(ctx context.Context, podName string, command []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) (int, error)
+	DeletePod(podName string) error
+}
+
+// CreatePod creates a sandbox Pod whose runner container idles until commands are exec'd into it.
+// It has the same hardening as an execution Job.
+func (k *KubernetesClient) CreatePod(request PodRequest) error {
+	profile := request.Profile
+	containers := []corev1.Container{
+		{
+			Name:  RunnerContainer,
+			Image: request.Image,
+			// sleep in the background so the container stops as soon as it is asked to
+			Command:         []string{"sh", "-c", `trap 'exit 0' TERM; while true; do sleep 3600 & wait $!; done`},
+			WorkingDir:      CodeDir,
+			VolumeMounts:    volumeMounts,
+			Env:             []corev1.EnvVar{homeEnv},
+			Resources:       profile.containerResources(request.MemoryLimit),
+			SecurityContext: profile.containerSecurityContext(),
+		},
+	}
+	spec := profile.podSpec(nil, containers)
+	if request.Lifetime > 0 {
+		spec.ActiveDeadlineSeconds = ptr(request.Lifetime)
+	}
+	spec.TerminationGracePeriodSeconds = ptr(int64(1))
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   request.Name,
+			Labels: profile.podLabels(request.Labels),
+		},
+		Spec: spec,
+	}
+	_, err := k.clientset.CoreV1().Pods(k.namespace).Create(context.TODO(), pod, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create Pod %s: %v", request.Name, err)
+	}
+	return nil
+}
+
+// WaitForPodRunning watches the Pod until its runner container is running
+func (k *KubernetesClient) WaitForPodRunning(ctx context.Context, podName string) error {
+	watcher, err := k.clientset.CoreV1().Pods(k.namespace).Watch(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", podName).String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch Pod %s: %v", podName, err)
+	}
+	defer watcher.Stop()
+
+	// The Pod may already be running before the watch started
+	pod, err := k.clientset.CoreV1().Pods(k.namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get Pod %s: %v", podName, err)
+	}
+	for {
+		switch pod.Status.Phase {
+		case corev1.PodRunning:
+			return nil
+		case corev1.PodSucceeded, corev1.PodFailed:
+			return fmt.Errorf("pod %s stopped before it was used: %s", podName, pod.Status.Reason)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("pod %s did not start: %w", podName, ctx.Err())
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return fmt.Errorf("watch on Pod %s closed", podName)
+			}
+			if event.Type == watch.Deleted {
+				return fmt.Errorf("pod %s was deleted before it was used", podName)
+			}
+			if updated, isPod := event.Object.(*corev1.Pod); isPod {
+				pod = updated
+			}
+		}
+	}
+}
+
+// ExecInPod runs command in the Pod's runner container and returns its exit code. It stops
+// streaming when ctx is done, which leaves the command running, so the Pod must not be reused then.
+func (k *KubernetesClient) ExecInPod(ctx context.Context, podName string, command []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) (int, error) {
+	if k.config == nil {
+		return 0, fmt.Errorf("exec into Pod %s needs a client created from a REST config", podName)
+	}
+	request := k.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(k.namespace).
+		Name(podName).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: RunnerContainer,
+			Command:   command,
+			Stdin:     stdin != nil,
+			Stdout:    stdout != nil,
+			Stderr:    stderr != nil,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(k.config, "POST", request.URL())
+	if err != nil {
+		return 0, fmt.Errorf("failed to exec into Pod %s: %v", podName, err)
+	}
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+	var exitError utilexec.ExitError
+	if errors.As(err, &exitError) {
+		return exitError.ExitStatus(), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("exec into Pod %s failed: %w", podName, err)
+	}
+	return 0, nil
+}
+
+// DeletePod deletes the Pod without waiting for it to stop
+func (k *KubernetesClient) DeletePod(podName string) error {
+	err := k.clientset.CoreV1().Pods(k.namespace).Delete(context.TODO(), podName, metav1.DeleteOptions{
+		GracePeriodSeconds: ptr(int64(0)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete Pod %s: %v", podName, err)
+	}
+	return nil
+}
@@ -0,0 +1,667 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go-compiler/common/pkg/enums"
+	"go-compiler/common/pkg/limits"
+	"go-compiler/common/pkg/registry"
+	"go-compiler/common/pkg/runner"
+	"go-compiler/models/languages"
+	"go-compiler/models/results"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/rand"
+)
+
+const (
+	// poolStartTimeout bounds how long a new Pod may take to be scheduled and pull its image
+	poolStartTimeout = 5 * time.Minute
+	// poolMaintenance is how often pools replace failed and expired Pods and roll their demand window
+	poolMaintenance = 15 * time.Second
+	// demandWindow is the period over which peak demand is measured for the autoscaling hint
+	demandWindow = 5 * time.Minute
+	// cleanupTimeout bounds resetting a Pod for its next submission
+	cleanupTimeout = 30 * time.Second
+	// podExecGrace is how long past its time limit a command in a Pod may take to report before its
+	// exec is abandoned; the command kills itself at the limit
+	podExecGrace = 5 * time.Second
+)
+
+// PoolConfig sizes the warm pool
+type PoolConfig struct {
+	// Sizes is the number of idle Pods kept ready per language ID
+	Sizes map[int64]int
+	// MaxUses is how many submissions a Pod runs before it is replaced
+	MaxUses int
+	// Lifetime is how long a Pod is used before it is replaced
+	Lifetime time.Duration
+	// MemoryLimit of the Pods in KB. Submissions asking for a different memory limit run as Jobs.
+	MemoryLimit int64
+}
+
+// DefaultPoolConfig replaces Pods after 50 submissions or an hour and serves submissions using the
+// default memory limit
+var DefaultPoolConfig = PoolConfig{
+	MaxUses:     50,
+	Lifetime:    time.Hour,
+	MemoryLimit: limits.Default.MemoryLimit,
+}
+
+// ParsePoolSizes parses pool sizes written as comma separated language_id=size pairs, such as "1=3,2=2"
+func ParsePoolSizes(value string) (map[int64]int, error) {
+	sizes := make(map[int64]int)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		id, size, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid pool size %q, expected language_id=size", pair)
+		}
+		languageId, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid language id in pool size %q: %v", pair, err)
+		}
+		count, err := strconv.Atoi(strings.TrimSpace(size))
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("invalid size in pool size %q", pair)
+		}
+		sizes[languageId] = count
+	}
+	return sizes, nil
+}
+
+// PoolStats describes one language's pool. Recommended is the autoscaling hint: the most
+// submissions of the language that ran at once recently, which is the size that would have served
+// all of them from warm Pods.
+type PoolStats struct {
+	LanguageId  int64  `json:"language_id"`
+	Language    string `json:"language"`
+	Target      int    `json:"target"`
+	Ready       int    `json:"ready"`
+	Starting    int    `json:"starting"`
+	Busy        int    `json:"busy"`
+	Served      uint64 `json:"served"`
+	Missed      uint64 `json:"missed"`
+	PeakDemand  int    `json:"peak_demand"`
+	Recommended int    `json:"recommended"`
+}
+
+// WarmPool keeps idle sandbox Pods per language and runs submissions in them by exec, which saves
+// the scheduling and image pull time of a Job. Submissions of other languages, with a memory limit
+// the Pods were not created with, or arriving while no Pod is ready run on the fallback executor.
+type WarmPool struct {
+	client   IPodClient
+	fallback runner.Executor
+	config   PoolConfig
+	pools    map[int64]*languagePool
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type warmPod struct {
+	name    string
+	uses    int
+	created time.Time
+	// broken marks a Pod that must not run another submission
+	broken bool
+}
+
+type languagePool struct {
+	language languages.LanguageModel
+	profile  Profile
+	target   int
+
+	refill chan struct{}
+
+	mu        sync.Mutex
+	ready     []*warmPod
+	starting  int
+	busy      int
+	fallbacks int
+	served    uint64
+	missed    uint64
+	peak      int
+	lastPeak  int
+	closed    bool
+}
+
+// NewWarmPool starts filling a pool for every language in config.Sizes
+func NewWarmPool(client IPodClient, languageRegistry registry.ILanguageRegistry, config PoolConfig, fallback runner.Executor) (*WarmPool, error) {
+	if config.MaxUses <= 0 {
+		config.MaxUses = DefaultPoolConfig.MaxUses
+	}
+	if config.Lifetime <= 0 {
+		config.Lifetime = DefaultPoolConfig.Lifetime
+	}
+	if config.MemoryLimit <= 0 {
+		config.MemoryLimit = DefaultPoolConfig.MemoryLimit
+	}
+
+	pools := make(map[int64]*languagePool, len(config.Sizes))
+	for languageId, size := range config.Sizes {
+		language, found := languageRegistry.Get(languageId)
+		if !found {
+			return nil, fmt.Errorf("warm pool for unknown language id %d", languageId)
+		}
+		if language.Image == "" {
+			return nil, fmt.Errorf("warm pool for %s, which has no sandbox image", language.Name)
+		}
+		profile, err := ResolveProfile(language)
+		if err != nil {
+			return nil, err
+		}
+		pools[languageId] = &languagePool{
+			language: language,
+			profile:  profile,
+			target:   size,
+			refill:   make(chan struct{}, 1),
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	warmPool := &WarmPool{
+		client:   client,
+		fallback: fallback,
+		config:   config,
+		pools:    pools,
+		cancel:   cancel,
+	}
+	for _, pool := range pools {
+		warmPool.wg.Add(1)
+		go warmPool.maintain(ctx, pool)
+	}
+	return warmPool, nil
+}
+
+// Compile validates the submission like the fallback; the Pod compiles it once before its runs
+func (w *WarmPool) Compile(ctx context.Context, submission runner.Submission) (results.ExecutionResult, bool) {
+	return w.fallback.Compile(ctx, submission)
+}
+
+// Execute runs the submission once with the given stdin, in a warm Pod when one is ready
+func (w *WarmPool) Execute(ctx context.Context, submission runner.Submission, stdin string) results.ExecutionResult {
+	runs, failed, ok := w.ExecuteAll(ctx, submission, []string{stdin})
+	if !ok {
+		return failed
+	}
+	return runs[0]
+}
+
+// ExecuteAll runs the submission once per stdin in a warm Pod when one is ready, compiling it once
+// for all of them, and on the fallback otherwise. Runs a Pod could not take because it broke run on
+// the fallback as well.
+func (w *WarmPool) ExecuteAll(ctx context.Context, submission runner.Submission, stdins []string) ([]results.ExecutionResult, results.ExecutionResult, bool) {
+	pool := w.pools[submission.Language.LanguageId]
+	if pool == nil || submission.Limits.MemoryLimit != w.config.MemoryLimit {
+		return runner.ExecuteAll(ctx, w.fallback, submission, stdins)
+	}
+	pod := pool.acquire()
+	if pod == nil {
+		defer pool.fallbackDone()
+		return runner.ExecuteAll(ctx, w.fallback, submission, stdins)
+	}
+
+	runs, failed, ok, err := w.run(ctx, pod, submission, stdins)
+	go w.release(pool, pod, !pod.broken)
+	if err == nil {
+		return runs, failed, ok
+	}
+	if ctx.Err() != nil {
+		return nil, runner.InternalError(err), false
+	}
+	// The program did not run in the broken Pod for the rest of the stdins, which can still run as Jobs
+	log.Printf("Warm pod %s failed, running %d of %d runs as Jobs: %v", pod.name, len(stdins)-len(runs), len(stdins), err)
+	rest, failed, ok := runner.ExecuteAll(ctx, w.fallback, submission, stdins[len(runs):])
+	if !ok {
+		return nil, failed, false
+	}
+	return append(runs, rest...), results.ExecutionResult{}, true
+}
+
+// run loads the submission with every stdin into the Pod, compiles it once and runs it per stdin. It
+// marks the Pod broken when it must not be reused, and returns an error when the Pod broke before
+// the program ran for the stdin following the runs it returns.
+func (w *WarmPool) run(ctx context.Context, pod *warmPod, submission runner.Submission, stdins []string) ([]results.ExecutionResult, results.ExecutionResult, bool, error) {
+	archive, err := bundle(submission, stdinFiles(stdins))
+	if err != nil {
+		return nil, runner.InternalError(err), false, nil
+	}
+	loadErrors := runner.NewOutputLimiter(0, nil, func() {})
+	exitCode, err := w.client.ExecInPod(ctx, pod.name, []string{"tar", "-xzf", "-", "-C", CodeDir}, bytes.NewReader(archive), nil, loadErrors.Stderr)
+	if err == nil && exitCode != 0 {
+		err = fmt.Errorf("unpacking the code exited with %d: %s", exitCode, loadErrors.Stderr.String())
+	}
+	if err != nil {
+		pod.broken = true
+		return nil, results.ExecutionResult{}, false, err
+	}
+
+	var compileOutput string
+	if submission.Language.IsCompiled() {
+		compiled, ok, err := w.compile(ctx, pod, submission)
+		if err != nil || !ok {
+			return nil, compiled, false, err
+		}
+		compileOutput = compiled.CompileOutput
+	}
+
+	runs := make([]results.ExecutionResult, 0, len(stdins))
+	for i, stdin := range stdins {
+		result, err := w.execute(ctx, pod, submission, i, stdin)
+		if err != nil {
+			pod.broken = true
+			return runs, results.ExecutionResult{}, true, err
+		}
+		result.CompileOutput = compileOutput
+		runs = append(runs, result)
+	}
+	return runs, results.ExecutionResult{}, true, nil
+}
+
+// compile compiles the loaded submission under the compile time and output limits, with the
+// compile memory limit applied as a data size limit like the local backend does without a cgroup.
+// Its error reports a Pod that broke.
+func (w *WarmPool) compile(ctx context.Context, pod *warmPod, submission runner.Submission) (results.ExecutionResult, bool, error) {
+	timeLimit := submission.Limits.CompileTimeLimit
+	compileCmd, err := submission.Language.CompileCommand(submission.CompilerOptions)
+	if err != nil {
+		return results.ExecutionResult{Status: results.NewStatus(enums.CompilationError), Message: err.Error()}, false, nil
+	}
+	var limitCommands string
+	if limits.Compile.MemoryLimit > 0 {
+		limitCommands = fmt.Sprintf("ulimit -d %d && ", limits.Compile.MemoryLimit)
+	}
+
+	output := runner.NewOutputLimiter(limits.Compile.MaxOutputSize*1024, nil, func() {})
+	command := podRunCommand(limitCommands, timeLimit, "< /dev/null", compileCmd)
+	run, stopped, err := w.measure(ctx, pod, command, nil, output, timeLimit)
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return results.ExecutionResult{}, false, err
+	}
+	result := results.ExecutionResult{CompileOutput: output.Stdout.String() + output.Stderr.String()}
+	if output.Exceeded() {
+		result.CompileOutput = runner.TruncatedCompileOutput(result.CompileOutput)
+	}
+	switch {
+	case run.oomKilled:
+		// The Pod's own memory limit may be the lower one
+		pod.broken = true
+		result.Status = results.NewStatus(enums.CompilationError)
+		result.Message = fmt.Sprintf("compilation exceeded the memory limit of %d KB", min(limits.Compile.MemoryLimit, w.config.MemoryLimit))
+		return result, false, nil
+	case stopped || (timeLimit > 0 && run.elapsed >= seconds(timeLimit)):
+		result.Status = results.NewStatus(enums.CompilationError)
+		result.Message = fmt.Sprintf("compilation exceeded the time limit of %vs", timeLimit)
+		return result, false, nil
+	case run.exitCode != 0:
+		result.Status = results.NewStatus(enums.CompilationError)
+		result.ExitCode = run.exitCode
+		return result, false, nil
+	}
+	return result, true, nil
+}
+
+// execute runs the compiled program for the index-th stdin and judges the run against the
+// submission's limits. Its error reports a Pod that broke.
+func (w *WarmPool) execute(ctx context.Context, pod *warmPod, submission runner.Submission, index int, stdin string) (results.ExecutionResult, error) {
+	submissionLimits := submission.RunLimits()
+
+	// Interactive programs read the bundled stdin, then the live input, over the exec's stdin
+	redirect := fmt.Sprintf("< %s.%d", stdinFile, index)
+	var input io.Reader
+	if submission.Input != nil {
+		redirect = ""
+		input = io.MultiReader(strings.NewReader(stdin), submission.Input)
+	}
+
+	// The program is stopped once its stdout and stderr together cross the output limit. Output is
+	// streamed as the exec delivers it, which keeps each stream's order but may reorder chunks
+	// across the two.
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	output := runner.NewOutputLimiter(submissionLimits.MaxOutputSize*1024, submission.Output, stop)
+	command := podRunCommand(limitCommands(submissionLimits), submissionLimits.WallTimeLimit, redirect, submission.Language.RunCmd)
+	run, _, err := w.measure(runCtx, pod, command, input, output, submissionLimits.WallTimeLimit)
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return results.ExecutionResult{}, err
+	}
+
+	// Only the OOM killer's count tells an OOM kill from any other SIGKILL
+	if run.oomKilled {
+		pod.broken = true
+	}
+	run.stdout = output.Stdout.String()
+	run.stderr = output.Stderr.String()
+	run.outputExceeded = output.Exceeded()
+	return run.result(submissionLimits), nil
+}
+
+// measure runs a podRunCommand in the Pod and reads the run it measured. When ctx is done or the
+// command outlives timeLimit, in seconds, by podExecGrace, the exec is abandoned, every process in
+// the Pod is killed and the run is reported as stopped with only its wall time known. The error
+// reports a Pod that broke.
+func (w *WarmPool) measure(ctx context.Context, pod *warmPod, command []string, input io.Reader, output *runner.OutputLimiter, timeLimit float64) (loggedRun, bool, error) {
+	execCtx, cancel := ctx, context.CancelFunc(func() {})
+	if timeLimit > 0 {
+		execCtx, cancel = context.WithTimeout(ctx, seconds(timeLimit)+podExecGrace)
+	}
+	defer cancel()
+
+	start := time.Now()
+	_, err := w.client.ExecInPod(execCtx, pod.name, command, input, output.Stdout, output.Stderr)
+	if err != nil && execCtx.Err() != nil {
+		// The command keeps running in the Pod once its exec is abandoned
+		if err := w.kill(pod); err != nil {
+			return loggedRun{}, true, err
+		}
+		return loggedRun{exitCode: 128 + int(syscall.SIGKILL), elapsed: time.Since(start)}, true, nil
+	}
+	if err != nil {
+		return loggedRun{}, false, err
+	}
+
+	readCtx, cancelRead := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancelRead()
+	var header strings.Builder
+	exitCode, err := w.client.ExecInPod(readCtx, pod.name, []string{"cat", CodeDir + "/" + runFile}, nil, &header, nil)
+	if err == nil && exitCode != 0 {
+		err = fmt.Errorf("reading the run exited with %d", exitCode)
+	}
+	if err != nil {
+		return loggedRun{}, false, err
+	}
+	runs, err := parseRuns(header.String())
+	if err == nil && len(runs) != 1 {
+		err = fmt.Errorf("expected one run, read %d", len(runs))
+	}
+	if err != nil {
+		return loggedRun{}, false, err
+	}
+	return runs[0], false, nil
+}
+
+// kill stops every process in the Pod but its init
+func (w *WarmPool) kill(pod *warmPod) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+	// kill -1 signals every process but the container's init and the shell itself
+	_, err := w.client.ExecInPod(ctx, pod.name, []string{"sh", "-c", `kill -9 -1 2>/dev/null; true`}, nil, nil, nil)
+	return err
+}
+
+// release resets a reusable Pod for the next submission and puts it back, or deletes it
+func (w *WarmPool) release(pool *languagePool, pod *warmPod, reusable bool) {
+	pod.uses++
+	if reusable && pod.uses < w.config.MaxUses && time.Since(pod.created) < w.config.Lifetime {
+		ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+		// kill -1 signals every process but the container's init and the shell itself
+		exitCode, err := w.client.ExecInPod(ctx, pod.name, []string{"sh", "-c",
+			`kill -9 -1 2>/dev/null; rm -rf ` + CodeDir + `/* ` + CodeDir + `/.[!.]* /tmp/* /tmp/.[!.]* 2>/dev/null; true`}, nil, nil, nil)
+		cancel()
+		reusable = err == nil && exitCode == 0
+	} else {
+		reusable = false
+	}
+
+	if pool.put(pod, reusable) {
+		return
+	}
+	w.destroy(pod)
+	pool.requestRefill()
+}
+
+func (w *WarmPool) destroy(pod *warmPod) {
+	if err := w.client.DeletePod(pod.name); err != nil {
+		log.Printf("Failed to delete warm pod: %v", err)
+	}
+}
+
+// maintain keeps the pool at its target size until ctx is done
+func (w *WarmPool) maintain(ctx context.Context, pool *languagePool) {
+	defer w.wg.Done()
+	ticker := time.NewTicker(poolMaintenance)
+	defer ticker.Stop()
+	windowStart := time.Now()
+
+	for {
+		for _, expired := range pool.expired(w.config.Lifetime) {
+			w.destroy(expired)
+		}
+		for i := pool.reserve(); i > 0; i-- {
+			w.wg.Add(1)
+			go w.start(ctx, pool)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-pool.refill:
+		case now := <-ticker.C:
+			if now.Sub(windowStart) >= demandWindow {
+				pool.rollWindow()
+				windowStart = now
+			}
+		}
+	}
+}
+
+// start creates one Pod and adds it to the pool once it runs
+func (w *WarmPool) start(ctx context.Context, pool *languagePool) {
+	defer w.wg.Done()
+	language := pool.language
+	pod := &warmPod{
+		name:    fmt.Sprintf("code-pool-%d-%s", language.LanguageId, rand.String(8)),
+		created: time.Now(),
+	}
+	// Pods outlive their lifetime by the longest a submission may take, then Kubernetes stops them
+	lifetime := w.config.Lifetime + seconds(limits.Maximum.CompileTimeLimit+limits.Maximum.WallTimeLimit) + cleanupTimeout
+	err := w.client.CreatePod(PodRequest{
+		Name:  pod.name,
+		Image: language.Image,
+		Labels: map[string]string{
+			PoolLabel:     PoolWarm,
+			LanguageLabel: strconv.FormatInt(language.LanguageId, 10),
+		},
+		Profile:     pool.profile,
+		MemoryLimit: w.config.MemoryLimit,
+		Lifetime:    int64(lifetime.Seconds()),
+	})
+	if err == nil {
+		startCtx, cancel := context.WithTimeout(ctx, poolStartTimeout)
+		err = w.client.WaitForPodRunning(startCtx, pod.name)
+		cancel()
+		if err != nil {
+			w.destroy(pod)
+		}
+	}
+	if err != nil {
+		// The next maintenance tick tries again
+		log.Printf("Failed to start warm pod for %s: %v", language.Name, err)
+		pool.startFailed()
+		return
+	}
+	if !pool.started(pod) {
+		w.destroy(pod)
+	}
+}
+
+// Stats reports every pool, ordered by language ID
+func (w *WarmPool) Stats() []PoolStats {
+	stats := make([]PoolStats, 0, len(w.pools))
+	for _, pool := range w.pools {
+		stats = append(stats, pool.stats())
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].LanguageId < stats[j].LanguageId
+	})
+	return stats
+}
+
+// Close stops refilling the pools and deletes their idle Pods. Busy Pods are deleted once released.
+func (w *WarmPool) Close() {
+	w.cancel()
+	w.wg.Wait()
+	for _, pool := range w.pools {
+		for _, pod := range pool.close() {
+			w.destroy(pod)
+		}
+	}
+}
+
+// acquire takes a ready Pod, or returns nil and counts the submission as missed
+func (p *languagePool) acquire() *warmPod {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	defer p.requestRefill()
+
+	// The oldest Pod first; expired ones are retired by the maintenance loop
+	if len(p.ready) > 0 {
+		pod := p.ready[0]
+		p.ready = p.ready[1:]
+		p.busy++
+		p.served++
+		p.observeDemand()
+		return pod
+	}
+	p.missed++
+	p.fallbacks++
+	p.observeDemand()
+	return nil
+}
+
+func (p *languagePool) fallbackDone() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fallbacks--
+}
+
+// put takes a released Pod back and returns it to the ready list when it is reusable and the
+// pool is still open
+func (p *languagePool) put(pod *warmPod, reusable bool) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.busy--
+	if !reusable || p.closed {
+		return false
+	}
+	p.ready = append(p.ready, pod)
+	return true
+}
+
+// reserve counts the Pods needed to reach the target as starting and returns how many
+func (p *languagePool) reserve() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	missing := p.target - len(p.ready) - p.starting
+	if p.closed || missing <= 0 {
+		return 0
+	}
+	p.starting += missing
+	return missing
+}
+
+func (p *languagePool) started(pod *warmPod) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.starting--
+	if p.closed {
+		return false
+	}
+	p.ready = append(p.ready, pod)
+	p.requestRefill()
+	return true
+}
+
+func (p *languagePool) startFailed() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.starting--
+}
+
+// expired removes and returns the ready Pods older than lifetime
+func (p *languagePool) expired(lifetime time.Duration) []*warmPod {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var expired []*warmPod
+	ready := p.ready[:0]
+	for _, pod := range p.ready {
+		if time.Since(pod.created) >= lifetime {
+			expired = append(expired, pod)
+		} else {
+			ready = append(ready, pod)
+		}
+	}
+	p.ready = ready
+	return expired
+}
+
+// observeDemand records the submissions running at once; the caller holds p.mu
+func (p *languagePool) observeDemand() {
+	if demand := p.busy + p.fallbacks; demand > p.peak {
+		p.peak = demand
+	}
+}
+
+// rollWindow starts a new demand window, keeping the previous peak for one more window
+func (p *languagePool) rollWindow() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastPeak = p.peak
+	p.peak = p.busy + p.fallbacks
+}
+
+func (p *languagePool) requestRefill() {
+	select {
+	case p.refill <- struct{}{}:
+	default:
+	}
+}
+
+func (p *languagePool) stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	peak := max(p.peak, p.lastPeak)
+	return PoolStats{
+		LanguageId:  p.language.LanguageId,
+		Language:    p.language.Name,
+		Target:      p.target,
+		Ready:       len(p.ready),
+		Starting:    p.starting,
+		Busy:        p.busy,
+		Served:      p.served,
+		Missed:      p.missed,
+		PeakDemand:  peak,
+		Recommended: peak,
+	}
+}
+
+func (p *languagePool) close() []*warmPod {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	ready := p.ready
+	p.ready = nil
+	return ready
+}
+
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
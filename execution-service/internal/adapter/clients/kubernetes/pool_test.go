@@ -0,0 +1,399 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-compiler/common/pkg/enums"
+	"go-compiler/common/pkg/registry"
+	"go-compiler/common/pkg/runner"
+	"go-compiler/models/languages"
+	"go-compiler/models/results"
+	"go-compiler/models/submissions"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// podRun is what a fake warm Pod makes of one command the pool runs, with the wall time in
+// hundredths of a second, the CPU time in microseconds and the memory in bytes
+type podRun struct {
+	stdout   string
+	stderr   string
+	exitCode int
+	wall     int64
+	cpu      int64
+	memory   int64
+	ooms     int
+}
+
+// stdinRedirect finds the stdin file a podRunScript reads
+var stdinRedirect = regexp.MustCompile(`< ` + regexp.QuoteMeta(stdinFile) + `\.(\d+)`)
+
+// fakePods plays the warm Pods. It keeps the stdins the pool unpacks and answers every command the
+// pool runs by podRunScript with what program makes of it, logging the header for the next read.
+type fakePods struct {
+	program func(command []string, stdin string) podRun
+	// failRun makes the failRun-th run of the program, counting from 1, fail as if the Pod broke
+	failRun int
+
+	mu      sync.Mutex
+	execs   []string
+	scripts []string
+	runs    int
+	stdins  []string
+	header  string
+	deleted []string
+}
+
+func (f *fakePods) CreatePod(request PodRequest) error {
+	return nil
+}
+
+func (f *fakePods) WaitForPodRunning(ctx context.Context, podName string) error {
+	return nil
+}
+
+func (f *fakePods) DeletePod(podName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted = append(f.deleted, podName)
+	return nil
+}
+
+func (f *fakePods) ExecInPod(ctx context.Context, podName string, command []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case command[0] == "tar":
+		archive, err := io.ReadAll(stdin)
+		if err != nil {
+			return 0, err
+		}
+		f.stdins, err = readStdins(archive)
+		f.execs = append(f.execs, "load")
+		return 0, err
+	case command[0] == "cat":
+		f.execs = append(f.execs, "read")
+		_, err := io.WriteString(stdout, f.header)
+		return 0, err
+	case strings.Contains(command[2], "> "+runFile):
+		return f.run(ctx, command, stdin, stdout, stderr)
+	case strings.Contains(command[2], "rm -rf"):
+		f.execs = append(f.execs, "reset")
+		return 0, nil
+	case strings.Contains(command[2], "kill -9 -1"):
+		f.execs = append(f.execs, "kill")
+		return 0, nil
+	}
+	return 0, fmt.Errorf("unexpected command %q", command)
+}
+
+// run answers a podRunScript; the caller holds f.mu
+func (f *fakePods) run(ctx context.Context, command []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) (int, error) {
+	script, program := command[2], command[4:]
+	f.scripts = append(f.scripts, script)
+	input := ""
+	if match := stdinRedirect.FindStringSubmatch(script); match != nil {
+		i, _ := strconv.Atoi(match[1])
+		input = f.stdins[i]
+	} else if stdin != nil {
+		content, err := io.ReadAll(stdin)
+		if err != nil {
+			return 0, err
+		}
+		input = string(content)
+	}
+	if strings.Contains(script, "< /dev/null") {
+		f.execs = append(f.execs, "compile")
+	} else {
+		f.runs++
+		f.execs = append(f.execs, "run")
+		if f.runs == f.failRun {
+			return 0, errors.New("connection reset by peer")
+		}
+	}
+
+	outcome := f.program(program, input)
+	io.WriteString(stdout, outcome.stdout)
+	io.WriteString(stderr, outcome.stderr)
+	// An exec abandoned by the pool reports nothing more
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+	f.header = logRun(outcome.exitCode, outcome.wall, outcome.cpu, outcome.memory, outcome.ooms, "", "")
+	return 0, nil
+}
+
+func (f *fakePods) state() ([]string, []string, []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.execs...), append([]string(nil), f.scripts...), append([]string(nil), f.deleted...)
+}
+
+// jobFallback stands in for the JobExecutor behind the pool
+type jobFallback struct{}
+
+func (jobFallback) Compile(ctx context.Context, submission runner.Submission) (results.ExecutionResult, bool) {
+	return results.ExecutionResult{}, true
+}
+
+func (jobFallback) Execute(ctx context.Context, submission runner.Submission, stdin string) results.ExecutionResult {
+	return results.ExecutionResult{Status: results.NewStatus(enums.Accepted), Stdout: "job " + stdin}
+}
+
+// echoRun runs the program for stdin and echoes it
+func echoRun(command []string, stdin string) podRun {
+	if command[len(command)-1] == "main.c" {
+		return podRun{wall: 50}
+	}
+	return podRun{stdout: stdin, wall: 5, cpu: 2000, memory: 2 << 20}
+}
+
+// newTestPool starts a pool of one C Pod on pods and waits until the Pod is ready
+func newTestPool(t *testing.T, pods *fakePods) *WarmPool {
+	t.Helper()
+	languageRegistry, err := registry.NewRegistry([]languages.LanguageModel{gcc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool, err := NewWarmPool(pods, languageRegistry, PoolConfig{Sizes: map[int64]int{gcc.LanguageId: 1}, MemoryLimit: 65536}, jobFallback{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	waitFor(t, "a ready Pod", func() bool { return pool.Stats()[0].Ready == 1 })
+	return pool
+}
+
+func waitFor(t *testing.T, what string, done func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !done(); time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+func TestWarmPoolExecuteAll(t *testing.T) {
+	limits := submissions.Limits{CpuTimeLimit: 1, WallTimeLimit: 2, MemoryLimit: 65536, MaxProcesses: 8, MaxOutputSize: 1, CompileTimeLimit: 10}
+
+	tests := []struct {
+		name string
+		// failRun breaks the Pod on that run, counting from 1
+		failRun int
+		limits  submissions.Limits
+		// interpreted runs the program without compiling it
+		interpreted bool
+		stdins      []string
+		program     func(command []string, stdin string) podRun
+		// want holds the status of each run, or the one failed result when wantFailed is set
+		want       []enums.Status
+		wantFailed bool
+		wantExecs  string
+		// wantDeleted is set when the Pod must not be reused
+		wantDeleted bool
+		check       func(t *testing.T, runs []results.ExecutionResult, failed results.ExecutionResult)
+	}{
+		{
+			name:      "a batch is compiled once and measured per run",
+			stdins:    []string{"a", "b", "c"},
+			program:   echoRun,
+			want:      []enums.Status{enums.Accepted, enums.Accepted, enums.Accepted},
+			wantExecs: "load compile read run read run read run read reset",
+			check: func(t *testing.T, runs []results.ExecutionResult, failed results.ExecutionResult) {
+				for i, stdout := range []string{"a", "b", "c"} {
+					if runs[i].Stdout != stdout || runs[i].Time != 0.05 || runs[i].CpuTime != 0.002 || runs[i].Memory != 2048 {
+						t.Errorf("run %d = %+v, want %q measured by the Pod", i, runs[i], stdout)
+					}
+				}
+			},
+		},
+		{
+			name:        "stdout and stderr over the output limit together stop the program",
+			interpreted: true,
+			stdins:      []string{"a", "b"},
+			program: func(command []string, stdin string) podRun {
+				if stdin == "a" {
+					return podRun{stdout: strings.Repeat("o", 600), stderr: strings.Repeat("e", 600)}
+				}
+				return echoRun(command, stdin)
+			},
+			want:      []enums.Status{enums.RuntimeErrorSIGXFSZ, enums.Accepted},
+			wantExecs: "load run kill run read reset",
+			check: func(t *testing.T, runs []results.ExecutionResult, failed results.ExecutionResult) {
+				if output := len(runs[0].Stdout) + len(runs[0].Stderr); output != 1024 {
+					t.Errorf("kept %d bytes of output, want 1024", output)
+				}
+			},
+		},
+		{
+			name:        "the OOM killer's count makes a memory limit verdict",
+			interpreted: true,
+			stdins:      []string{"a"},
+			program: func(command []string, stdin string) podRun {
+				return podRun{exitCode: 137, ooms: 1, memory: 64 << 20}
+			},
+			want:        []enums.Status{enums.MemoryLimitExceeded},
+			wantExecs:   "load run read",
+			wantDeleted: true,
+		},
+		{
+			name:        "a SIGKILL without an OOM kill is not a memory limit verdict",
+			interpreted: true,
+			stdins:      []string{"a"},
+			program: func(command []string, stdin string) podRun {
+				return podRun{exitCode: 137}
+			},
+			want:      []enums.Status{enums.RuntimeErrorOther},
+			wantExecs: "load run read reset",
+		},
+		{
+			name:        "runs past the wall time limit",
+			interpreted: true,
+			stdins:      []string{"a"},
+			program: func(command []string, stdin string) podRun {
+				return podRun{exitCode: 137, wall: 201}
+			},
+			want:      []enums.Status{enums.TimeLimitExceeded},
+			wantExecs: "load run read reset",
+		},
+		{
+			name:   "a compile failure stops the batch",
+			stdins: []string{"a", "b"},
+			program: func(command []string, stdin string) podRun {
+				return podRun{exitCode: 1, stderr: "main.c:1: error: expected ';'"}
+			},
+			wantFailed: true,
+			want:       []enums.Status{enums.CompilationError},
+			wantExecs:  "load compile read reset",
+			check: func(t *testing.T, runs []results.ExecutionResult, failed results.ExecutionResult) {
+				if failed.CompileOutput != "main.c:1: error: expected ';'" || failed.ExitCode != 1 {
+					t.Errorf("failed = %+v, want the compiler's error", failed)
+				}
+			},
+		},
+		{
+			name:   "a compile past the time limit",
+			stdins: []string{"a"},
+			program: func(command []string, stdin string) podRun {
+				return podRun{exitCode: 137, wall: 1000}
+			},
+			wantFailed: true,
+			want:       []enums.Status{enums.CompilationError},
+			wantExecs:  "load compile read reset",
+			check: func(t *testing.T, runs []results.ExecutionResult, failed results.ExecutionResult) {
+				if !strings.Contains(failed.Message, "time limit") {
+					t.Errorf("message = %q, want the compile time limit", failed.Message)
+				}
+			},
+		},
+		{
+			name:        "the runs a broken Pod did not take run as Jobs",
+			failRun:     2,
+			stdins:      []string{"a", "b", "c"},
+			program:     echoRun,
+			want:        []enums.Status{enums.Accepted, enums.Accepted, enums.Accepted},
+			wantExecs:   "load compile read run read run",
+			wantDeleted: true,
+			check: func(t *testing.T, runs []results.ExecutionResult, failed results.ExecutionResult) {
+				for i, stdout := range []string{"a", "job b", "job c"} {
+					if runs[i].Stdout != stdout {
+						t.Errorf("run %d stdout = %q, want %q", i, runs[i].Stdout, stdout)
+					}
+				}
+			},
+		},
+		{
+			name:        "another memory limit runs as Jobs",
+			interpreted: true,
+			limits:      submissions.Limits{MemoryLimit: 1024},
+			stdins:      []string{"a"},
+			program:     echoRun,
+			want:        []enums.Status{enums.Accepted},
+			wantExecs:   "",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pods := &fakePods{program: test.program, failRun: test.failRun}
+			pool := newTestPool(t, pods)
+
+			submissionLimits := limits
+			if test.limits.MemoryLimit != 0 {
+				submissionLimits = test.limits
+			}
+			submission := runner.Submission{Language: gcc, Code: []byte("int main() {}"), Limits: submissionLimits}
+			if test.interpreted {
+				submission.Language.CompileCmd = nil
+			}
+			runs, failed, ok := pool.ExecuteAll(context.Background(), submission, test.stdins)
+
+			if test.wantFailed {
+				if ok || statusOf(failed) != test.want[0] {
+					t.Fatalf("ExecuteAll = %v, %+v, want it to fail with %v", ok, failed, test.want[0])
+				}
+			} else {
+				if !ok || len(runs) != len(test.want) {
+					t.Fatalf("ExecuteAll = %v, %d runs (%+v), want %d runs", ok, len(runs), failed, len(test.want))
+				}
+				for i, want := range test.want {
+					if statusOf(runs[i]) != want {
+						t.Errorf("run %d status = %s, want %s", i, runs[i].Status.Name, results.NewStatus(want).Name)
+					}
+				}
+			}
+			if test.check != nil {
+				test.check(t, runs, failed)
+			}
+
+			if test.wantExecs != "" {
+				waitFor(t, "the Pod to be released", func() bool {
+					execs, _, deleted := pods.state()
+					return len(deleted) > 0 || (len(execs) > 0 && execs[len(execs)-1] == "reset")
+				})
+			}
+			execs, _, deleted := pods.state()
+			if got := strings.Join(execs, " "); got != test.wantExecs {
+				t.Errorf("execs = %q, want %q", got, test.wantExecs)
+			}
+			if test.wantDeleted != (len(deleted) > 0) {
+				t.Errorf("deleted Pods = %v, want the Pod deleted: %v", deleted, test.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestWarmPoolCommands(t *testing.T) {
+	pods := &fakePods{program: echoRun}
+	pool := newTestPool(t, pods)
+	submission := runner.Submission{
+		Language: gcc,
+		Code:     []byte("int main() {}"),
+		Limits:   submissions.Limits{CpuTimeLimit: 1.5, WallTimeLimit: 2.5, MemoryLimit: 65536, MaxProcesses: 8, MaxOutputSize: 1, CompileTimeLimit: 10},
+	}
+	if _, failed, ok := pool.ExecuteAll(context.Background(), submission, []string{"a", "b"}); !ok {
+		t.Fatalf("ExecuteAll failed: %+v", failed)
+	}
+
+	_, scripts, _ := pods.state()
+	if len(scripts) != 3 {
+		t.Fatalf("ran %d commands, want the compiler and two runs", len(scripts))
+	}
+	for i, want := range []string{
+		"ulimit -d 1048576 && exec timeout -s KILL 10 ",
+		"ulimit -S -t 2 && ulimit -H -t 3 && { ulimit -u 8 2>/dev/null || ulimit -p 8; } && ulimit -f 3 && exec timeout -s KILL 3 ",
+		"< .stdin.1",
+	} {
+		if !strings.Contains(scripts[i], want) {
+			t.Errorf("command %d does not contain %q:\n%s", i, want, scripts[i])
+		}
+	}
+	if strings.Contains(scripts[0], "%!") {
+		t.Errorf("script has unfilled verbs:\n%s", scripts[0])
+	}
+}
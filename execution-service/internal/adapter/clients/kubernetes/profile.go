@@ -0,0 +1,176 @@
+package kubernetes
+
+import (
+	"fmt"
+	"go-compiler/models/languages"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// volumeMounts are the only writable paths of an execution container
+var volumeMounts = []corev1.VolumeMount{
+	{
+		Name:      "code-volume",
+		MountPath: CodeDir,
+	},
+	{
+		Name:      "tmp-volume",
+		MountPath: "/tmp",
+	},
+}
+
+// homeEnv points HOME at /tmp, because toolchains keep their caches in HOME, which must be writable
+var homeEnv = corev1.EnvVar{Name: "HOME", Value: "/tmp"}
+
+// Profile is the resolved security profile of an execution Job's pod
+type Profile struct {
+	RunAsUser               int64
+	RunAsGroup              int64
+	ReadOnlyRootFilesystem  bool
+	TmpSize                 resource.Quantity
+	CpuLimit                resource.Quantity
+	AllowNetwork            bool
+	TtlSecondsAfterFinished int32
+}
+
+// DefaultProfile runs programs as nobody on a read-only root filesystem with a small /tmp and no
+// network, and lets Kubernetes remove finished Jobs the executor failed to delete
+var DefaultProfile = Profile{
+	RunAsUser:               65534,
+	RunAsGroup:              65534,
+	ReadOnlyRootFilesystem:  true,
+	TmpSize:                 resource.MustParse("64Mi"),
+	CpuLimit:                resource.MustParse("500m"),
+	AllowNetwork:            false,
+	TtlSecondsAfterFinished: 60,
+}
+
+// ResolveProfile applies the language's overrides to DefaultProfile
+func ResolveProfile(language languages.LanguageModel) (Profile, error) {
+	profile := DefaultProfile
+	overrides := language.Kubernetes
+	if overrides == nil {
+		return profile, nil
+	}
+	if overrides.RunAsUser != nil {
+		profile.RunAsUser = *overrides.RunAsUser
+	}
+	if overrides.RunAsGroup != nil {
+		profile.RunAsGroup = *overrides.RunAsGroup
+	}
+	if profile.RunAsUser == 0 || profile.RunAsGroup == 0 {
+		return profile, fmt.Errorf("%s must not run as root", language.Name)
+	}
+	if overrides.ReadOnlyRootFilesystem != nil {
+		profile.ReadOnlyRootFilesystem = *overrides.ReadOnlyRootFilesystem
+	}
+	if overrides.TmpSize != "" {
+		size, err := resource.ParseQuantity(overrides.TmpSize)
+		if err != nil {
+			return profile, fmt.Errorf("%s has invalid tmp_size %q: %v", language.Name, overrides.TmpSize, err)
+		}
+		profile.TmpSize = size
+	}
+	if overrides.CpuLimit != "" {
+		cpu, err := resource.ParseQuantity(overrides.CpuLimit)
+		if err != nil || cpu.Sign() <= 0 {
+			return profile, fmt.Errorf("%s has invalid cpu_limit %q", language.Name, overrides.CpuLimit)
+		}
+		profile.CpuLimit = cpu
+	}
+	if overrides.AllowNetwork != nil {
+		profile.AllowNetwork = *overrides.AllowNetwork
+	}
+	if overrides.TtlSecondsAfterFinished != nil {
+		profile.TtlSecondsAfterFinished = *overrides.TtlSecondsAfterFinished
+	}
+	return profile, nil
+}
+
+// containerResources limits an execution container to memoryLimit KB, or 256Mi when it is unset,
+// and to the profile's CPU quota
+func (p Profile) containerResources(memoryLimit int64) corev1.ResourceRequirements {
+	memoryRequest := resource.MustParse("128Mi")
+	memory := resource.MustParse("256Mi")
+	if memoryLimit > 0 {
+		memory = *resource.NewQuantity(memoryLimit*1024, resource.BinarySI)
+	}
+	cpuRequest := resource.MustParse("100m")
+	// The requests may never be above the limits
+	if memory.Cmp(memoryRequest) < 0 {
+		memoryRequest = memory
+	}
+	if p.CpuLimit.Cmp(cpuRequest) < 0 {
+		cpuRequest = p.CpuLimit
+	}
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    cpuRequest,
+			corev1.ResourceMemory: memoryRequest,
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    p.CpuLimit,
+			corev1.ResourceMemory: memory,
+		},
+	}
+}
+
+// containerSecurityContext drops every privilege; only /code and /tmp are writable
+func (p Profile) containerSecurityContext() *corev1.SecurityContext {
+	return &corev1.SecurityContext{
+		RunAsNonRoot:             ptr(true),
+		AllowPrivilegeEscalation: ptr(false),
+		ReadOnlyRootFilesystem:   ptr(p.ReadOnlyRootFilesystem),
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		},
+	}
+}
+
+// podLabels adds the label the isolation NetworkPolicy selects to labels
+func (p Profile) podLabels(labels map[string]string) map[string]string {
+	podLabels := make(map[string]string, len(labels)+1)
+	for key, value := range labels {
+		podLabels[key] = value
+	}
+	if !p.AllowNetwork {
+		podLabels[NetworkLabel] = NetworkIsolated
+	}
+	return podLabels
+}
+
+// podSpec runs the containers as the profile's user without a service account token, sharing the
+// /code and /tmp volumes
+func (p Profile) podSpec(initContainers []corev1.Container, containers []corev1.Container) corev1.PodSpec {
+	return corev1.PodSpec{
+		RestartPolicy:                corev1.RestartPolicyNever,
+		AutomountServiceAccountToken: ptr(false),
+		EnableServiceLinks:           ptr(false),
+		SecurityContext: &corev1.PodSecurityContext{
+			RunAsNonRoot: ptr(true),
+			RunAsUser:    ptr(p.RunAsUser),
+			RunAsGroup:   ptr(p.RunAsGroup),
+			FSGroup:      ptr(p.RunAsGroup),
+			SeccompProfile: &corev1.SeccompProfile{
+				Type: corev1.SeccompProfileTypeRuntimeDefault,
+			},
+		},
+		InitContainers: initContainers,
+		Containers:     containers,
+		Volumes: []corev1.Volume{
+			{
+				Name: "code-volume",
+				VolumeSource: corev1.VolumeSource{
+					EmptyDir: &corev1.EmptyDirVolumeSource{},
+				},
+			},
+			{
+				Name: "tmp-volume",
+				VolumeSource: corev1.VolumeSource{
+					EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: &p.TmpSize},
+				},
+			},
+		},
+	}
+}
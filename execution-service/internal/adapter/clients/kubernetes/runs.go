@@ -0,0 +1,181 @@
+package kubernetes
+
+import (
+	"fmt"
+	"go-compiler/models/submissions"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// runMarker starts the line the runner container logs ahead of each run's output
+	runMarker = "--- run"
+	// maxJobLogs bounds what one Job logs, below the kubelet's default 10Mi log rotation size past
+	// which the start of the logs would be lost
+	maxJobLogs = 8 << 20
+	// runFile holds the header podRunScript writes for the run in a warm Pod
+	runFile = ".run"
+)
+
+// measureFunctions are the shell functions runs are measured with, from /proc/uptime and the
+// container's cgroup: now is the time in hundredths of a second, cpu the CPU time in microseconds,
+// memory the peak memory in bytes and ooms the number of OOM kills. Scripts using them are fmt formats.
+const measureFunctions = `now() { read -r up _ < /proc/uptime; echo "${up%%.*}${up#*.}"; }
+cpu() {
+	if [ -r /sys/fs/cgroup/cpu.stat ]; then
+		while read -r key value; do [ "$key" = usage_usec ] && echo "$value" && return; done < /sys/fs/cgroup/cpu.stat
+	elif read -r value < /sys/fs/cgroup/cpuacct/cpuacct.usage; then
+		echo $((value / 1000)) && return
+	fi
+	echo 0
+}
+memory() {
+	read -r value < /sys/fs/cgroup/memory.peak || read -r value < /sys/fs/cgroup/memory/memory.max_usage_in_bytes || value=0
+	echo "$value"
+}
+ooms() {
+	for file in /sys/fs/cgroup/memory.events /sys/fs/cgroup/memory/memory.oom_control; do
+		[ -r "$file" ] && while read -r key value; do [ "$key" = oom_kill ] && echo "$value" && return; done < "$file"
+	done
+	echo 0
+}
+`
+
+// runScript is the runner container's shell script. It runs the program, given as its arguments,
+// once per stdin file and kills whatever each run left behind. After each run it logs a header,
+// then the run's stdout and stderr, each cut one byte past the output limit so crossing it shows.
+// The header holds the exit code, the wall time in hundredths of a second from /proc/uptime, the
+// CPU time in microseconds, the container's peak memory in bytes and OOM kills from its cgroup,
+// and the number of stdout and stderr bytes that follow. The cgroup is read-only inside the pod, so
+// the peak cannot be reset between runs: it is the peak of the run only when the run raised it, and
+// otherwise the peak of an earlier run. Once a run's output would take the logs
+// past maxJobLogs the script stops without logging it, and the remaining runs need another Job.
+const runScript = measureFunctions + `exec 2> /dev/null
+budget=%[1]d
+cap=%[2]d
+i=0
+while [ "$i" -lt %[3]d ]; do
+	start=$(now) used=$(cpu) killed=$(ooms)
+	( %[4]sexec %[5]s"$@" ) < %[6]s.$i > %[7]s 2> %[8]s
+	code=$?
+	end=$(now)
+	kill -9 -1
+	out=$(wc -c < %[7]s) err=$(wc -c < %[8]s)
+	if [ "$cap" -gt 0 ]; then
+		[ "$out" -gt "$cap" ] && out=$cap
+		[ "$err" -gt "$cap" ] && err=$cap
+	fi
+	[ "$i" -gt 0 ] && [ $((out + err)) -gt "$budget" ] && exit 0
+	budget=$((budget - out - err))
+	echo "%[9]s $code $((end - start)) $(($(cpu) - used)) $(memory) $(($(ooms) - killed)) $out $err"
+	head -c "$out" %[7]s
+	head -c "$err" %[8]s
+	i=$((i + 1))
+done`
+
+// runCommand returns the runner container's command, which runs the program once for each of the
+// first runs stdin files under the limits
+func runCommand(limits submissions.Limits, runs int, program []string) []string {
+	var outputCap int64
+	if limits.MaxOutputSize > 0 {
+		outputCap = limits.MaxOutputSize*1024 + 1
+	}
+	// The wall time limit is enforced by timeout, rounded up, and checked exactly against the run's time
+	var timeout string
+	if limits.WallTimeLimit > 0 {
+		timeout = fmt.Sprintf("timeout -s KILL %d ", int64(math.Ceil(limits.WallTimeLimit)))
+	}
+	script := fmt.Sprintf(runScript, maxJobLogs, outputCap, runs, limitCommands(limits), timeout,
+		stdinFile, stdoutFile, stderrFile, runMarker)
+	return append([]string{"sh", "-c", script, "sh"}, program...)
+}
+
+// podRunScript runs one command, given as its arguments, in a warm Pod from the code directory
+// with its stdout and stderr passed through. Afterwards it kills whatever the command left behind
+// and writes a runScript header for it, with no output following, to runFile, which a separate exec
+// reads so the header never mixes with the program's output.
+const podRunScript = measureFunctions + `exec 3>&2 2> /dev/null
+cd %[1]s || exit 1
+start=$(now) used=$(cpu) killed=$(ooms)
+( %[2]sexec %[3]s"$@" ) %[4]s 2>&3 3>&-
+code=$?
+end=$(now)
+kill -9 -1
+rm -f %[6]s
+echo "%[5]s $code $((end - start)) $(($(cpu) - used)) $(memory) $(($(ooms) - killed)) 0 0" > %[6]s`
+
+// podRunCommand returns the command that runs program in a warm Pod after the limit commands, killed
+// once it runs past wallTimeLimit rounded up, with its stdin redirected by redirect when it is set
+func podRunCommand(limitCommands string, wallTimeLimit float64, redirect string, program []string) []string {
+	var timeout string
+	if wallTimeLimit > 0 {
+		timeout = fmt.Sprintf("timeout -s KILL %d ", int64(math.Ceil(wallTimeLimit)))
+	}
+	script := fmt.Sprintf(podRunScript, CodeDir, limitCommands, timeout, redirect, runMarker, runFile)
+	return append([]string{"sh", "-c", script, "sh"}, program...)
+}
+
+// compileCommand kills the compiler once it runs past the compile time limit, rounded up, which the
+// Job's deadline no longer bounds closely
+func compileCommand(limits submissions.Limits, compile []string) []string {
+	if limits.CompileTimeLimit <= 0 {
+		return compile
+	}
+	timeout := strconv.FormatInt(int64(math.Ceil(limits.CompileTimeLimit)), 10)
+	return append([]string{"timeout", "-s", "KILL", timeout}, compile...)
+}
+
+// loggedRun is one run of the program as the runner container logged it
+type loggedRun struct {
+	exitCode  int
+	elapsed   time.Duration
+	cpuTime   time.Duration
+	memory    int64 // peak memory of the container up to the end of the run, so of every run before too, in KB
+	oomKilled bool
+	stdout    string
+	stderr    string
+	// outputExceeded is set when the output was cut at the limit before it was logged, as in warm Pods
+	outputExceeded bool
+}
+
+// parseRuns reads the runs runScript logged. Logs cut short, as when the container was killed,
+// return the runs before the cut together with an error.
+func parseRuns(logs string) ([]loggedRun, error) {
+	var runs []loggedRun
+	for logs != "" {
+		header, rest, found := strings.Cut(logs, "\n")
+		if !found || !strings.HasPrefix(header, runMarker+" ") {
+			return runs, fmt.Errorf("unexpected runner output %.40q", header)
+		}
+		fields := strings.Fields(strings.TrimPrefix(header, runMarker))
+		if len(fields) != 7 {
+			return runs, fmt.Errorf("malformed run header %q", header)
+		}
+		values := make([]int64, len(fields))
+		for i, field := range fields {
+			value, err := strconv.ParseInt(field, 10, 64)
+			if err != nil || (i > 0 && value < 0) {
+				return runs, fmt.Errorf("malformed run header %q", header)
+			}
+			values[i] = value
+		}
+		stdoutSize, stderrSize := values[5], values[6]
+		if int64(len(rest)) < stdoutSize+stderrSize {
+			return runs, fmt.Errorf("run %d was cut short", len(runs))
+		}
+
+		runs = append(runs, loggedRun{
+			exitCode:  int(values[0]),
+			elapsed:   time.Duration(values[1]) * 10 * time.Millisecond,
+			cpuTime:   time.Duration(values[2]) * time.Microsecond,
+			memory:    values[3] / 1024,
+			oomKilled: values[4] > 0,
+			stdout:    rest[:stdoutSize],
+			stderr:    rest[stdoutSize : stdoutSize+stderrSize],
+		})
+		logs = rest[stdoutSize+stderrSize:]
+	}
+	return runs, nil
+}
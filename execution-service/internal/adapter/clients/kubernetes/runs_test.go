@@ -0,0 +1,154 @@
+package kubernetes
+
+import (
+	"fmt"
+	"go-compiler/common/pkg/enums"
+	"go-compiler/models/results"
+	"go-compiler/models/submissions"
+	"strings"
+	"testing"
+	"time"
+)
+
+// logRun formats one run the way runScript logs it, with the wall time in hundredths of a second,
+// the CPU time in microseconds and the memory in bytes
+func logRun(exitCode int, wall int64, cpu int64, memory int64, ooms int, stdout string, stderr string) string {
+	return fmt.Sprintf("%s %d %d %d %d %d %d %d\n%s%s", runMarker, exitCode, wall, cpu, memory, ooms, len(stdout), len(stderr), stdout, stderr)
+}
+
+func TestParseRuns(t *testing.T) {
+	first := logRun(0, 12, 3500, 2048*1024, 0, "out\n", "")
+	second := logRun(1, 200, 1990000, 4096*1024, 1, "", "error\n")
+
+	tests := []struct {
+		name    string
+		logs    string
+		want    []loggedRun
+		wantErr string
+	}{
+		{name: "no runs", logs: ""},
+		{
+			name: "runs",
+			logs: first + second,
+			want: []loggedRun{
+				{exitCode: 0, elapsed: 120 * time.Millisecond, cpuTime: 3500 * time.Microsecond, memory: 2048, stdout: "out\n"},
+				{exitCode: 1, elapsed: 2 * time.Second, cpuTime: 1990 * time.Millisecond, memory: 4096, oomKilled: true, stderr: "error\n"},
+			},
+		},
+		{
+			name: "output holding a marker",
+			logs: logRun(0, 1, 1, 0, 0, runMarker+" 0 0 0 0 0 0 0\n", ""),
+			want: []loggedRun{{elapsed: 10 * time.Millisecond, cpuTime: time.Microsecond, stdout: runMarker + " 0 0 0 0 0 0 0\n"}},
+		},
+		{
+			name:    "cut inside the output",
+			logs:    first + second[:len(second)-2],
+			want:    []loggedRun{{elapsed: 120 * time.Millisecond, cpuTime: 3500 * time.Microsecond, memory: 2048, stdout: "out\n"}},
+			wantErr: "cut short",
+		},
+		{name: "cut inside the header", logs: first[:10], wantErr: "unexpected runner output"},
+		{name: "something else", logs: "exec format error\n", wantErr: "unexpected runner output"},
+		{name: "missing field", logs: runMarker + " 0 1 2 3 4 5\n", wantErr: "malformed run header"},
+		{name: "negative size", logs: runMarker + " 0 1 2 3 4 -5 0\n", wantErr: "malformed run header"},
+		{name: "not a number", logs: runMarker + " 0 1 2 3 4 x 0\n", wantErr: "malformed run header"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			runs, err := parseRuns(test.logs)
+			if test.wantErr == "" && err != nil {
+				t.Fatalf("parseRuns: %v", err)
+			}
+			if test.wantErr != "" && (err == nil || !strings.Contains(err.Error(), test.wantErr)) {
+				t.Fatalf("parseRuns error = %v, want one containing %q", err, test.wantErr)
+			}
+			if len(runs) != len(test.want) {
+				t.Fatalf("parseRuns = %d runs, want %d", len(runs), len(test.want))
+			}
+			for i := range runs {
+				if runs[i] != test.want[i] {
+					t.Errorf("run %d = %+v, want %+v", i, runs[i], test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoggedRunResult(t *testing.T) {
+	limits := submissions.Limits{CpuTimeLimit: 1, WallTimeLimit: 2, MaxOutputSize: 1}
+	tests := []struct {
+		name       string
+		run        loggedRun
+		want       enums.Status
+		wantSignal int
+		wantOutput int
+	}{
+		{name: "accepted", run: loggedRun{elapsed: time.Second, stdout: "ok"}, want: enums.Accepted, wantOutput: 2},
+		{name: "non-zero exit", run: loggedRun{exitCode: 3}, want: enums.RuntimeErrorNZEC},
+		{name: "segfault", run: loggedRun{exitCode: 139}, want: enums.RuntimeErrorSIGSEGV, wantSignal: 11},
+		{name: "killed at the wall time limit", run: loggedRun{exitCode: 137, elapsed: 2100 * time.Millisecond}, want: enums.TimeLimitExceeded, wantSignal: 9},
+		{name: "killed exactly at the wall time limit", run: loggedRun{exitCode: 137, elapsed: 2 * time.Second}, want: enums.TimeLimitExceeded, wantSignal: 9},
+		{name: "output cut before it was logged", run: loggedRun{exitCode: 137, stdout: "o", outputExceeded: true}, want: enums.RuntimeErrorSIGXFSZ, wantSignal: 9, wantOutput: 1},
+		{name: "over the cpu time limit", run: loggedRun{elapsed: time.Second, cpuTime: 1100 * time.Millisecond}, want: enums.TimeLimitExceeded},
+		{name: "sigxcpu", run: loggedRun{exitCode: 152}, want: enums.TimeLimitExceeded, wantSignal: 24},
+		{name: "oom killed", run: loggedRun{exitCode: 137, oomKilled: true}, want: enums.MemoryLimitExceeded, wantSignal: 9},
+		{
+			name:       "stdout and stderr over the output limit together",
+			run:        loggedRun{stdout: strings.Repeat("o", 600), stderr: strings.Repeat("e", 600)},
+			want:       enums.RuntimeErrorSIGXFSZ,
+			wantOutput: 1024,
+		},
+		{
+			name:       "stdout and stderr at the output limit",
+			run:        loggedRun{stdout: strings.Repeat("o", 512), stderr: strings.Repeat("e", 512)},
+			want:       enums.Accepted,
+			wantOutput: 1024,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := test.run.result(limits)
+			if result.Status != results.NewStatus(test.want) {
+				t.Errorf("status = %s, want %s", result.Status.Name, results.NewStatus(test.want).Name)
+			}
+			if result.Signal != test.wantSignal {
+				t.Errorf("signal = %d, want %d", result.Signal, test.wantSignal)
+			}
+			if output := len(result.Stdout) + len(result.Stderr); output != test.wantOutput {
+				t.Errorf("kept %d bytes of output, want %d", output, test.wantOutput)
+			}
+		})
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	command := runCommand(submissions.Limits{CpuTimeLimit: 1.5, WallTimeLimit: 2.5, MaxOutputSize: 4, MaxProcesses: 8}, 3, []string{"python3", "main.py"})
+	if len(command) != 6 || command[0] != "sh" || command[3] != "sh" || command[4] != "python3" || command[5] != "main.py" {
+		t.Fatalf("command = %q, want sh -c script sh followed by the program", command)
+	}
+	script := command[2]
+	for _, want := range []string{
+		"ulimit -S -t 2 && ulimit -H -t 3",
+		"ulimit -u 8",
+		"timeout -s KILL 3 ",
+		`while [ "$i" -lt 3 ]`,
+		fmt.Sprintf("cap=%d", 4*1024+1),
+		fmt.Sprintf("budget=%d", maxJobLogs),
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script does not contain %q:\n%s", want, script)
+		}
+	}
+	if strings.Contains(script, "%!") {
+		t.Errorf("script has unfilled verbs:\n%s", script)
+	}
+}
+
+func TestCompileCommand(t *testing.T) {
+	compile := []string{"gcc", "main.c"}
+	if command := compileCommand(submissions.Limits{CompileTimeLimit: 2.5}, compile); strings.Join(command, " ") != "timeout -s KILL 3 gcc main.c" {
+		t.Errorf("compileCommand = %q, want gcc killed after 3 seconds", command)
+	}
+	if command := compileCommand(submissions.Limits{}, compile); strings.Join(command, " ") != "gcc main.c" {
+		t.Errorf("compileCommand without a compile time limit = %q", command)
+	}
+}
@@ -0,0 +1,154 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	v1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// jobSelector matches every execution Job, so one watch serves all waiting submissions
+const jobSelector = "frontend=code-execution"
+
+// ErrJobDeleted is returned to submissions waiting for a Job that was deleted before it finished
+var ErrJobDeleted = errors.New("job was deleted before it finished")
+
+// jobTracker watches execution Jobs through a shared informer and hands each finished Job to the
+// submissions waiting for it, replacing a Get per submission every few seconds.
+type jobTracker struct {
+	informer  cache.SharedIndexInformer
+	namespace string
+
+	mu      sync.Mutex
+	waiters map[string][]chan jobOutcome
+}
+
+// jobOutcome is a finished Job, or why a waiter will not see it finish
+type jobOutcome struct {
+	job *v1.Job
+	err error
+}
+
+func newJobTracker(clientset kubernetes.Interface, namespace string) *jobTracker {
+	factory := informers.NewSharedInformerFactoryWithOptions(clientset, 0,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = jobSelector
+		}),
+	)
+	tracker := &jobTracker{
+		informer:  factory.Batch().V1().Jobs().Informer(),
+		namespace: namespace,
+		waiters:   map[string][]chan jobOutcome{},
+	}
+	tracker.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: tracker.notify,
+		UpdateFunc: func(_, job interface{}) {
+			tracker.notify(job)
+		},
+		DeleteFunc: tracker.deleted,
+	})
+	return tracker
+}
+
+// start runs the watch in the background until ctx is done
+func (t *jobTracker) start(ctx context.Context) {
+	go t.informer.Run(ctx.Done())
+}
+
+// synced waits until the watch has listed the Jobs, or fails once ctx is done
+func (t *jobTracker) synced(ctx context.Context) error {
+	if !cache.WaitForCacheSync(ctx.Done(), t.informer.HasSynced) {
+		return fmt.Errorf("job informer did not sync: %w", ctx.Err())
+	}
+	return nil
+}
+
+// wait blocks until the named Job has finished or ctx is done. It returns ErrJobDeleted when the Job
+// is deleted before it finished.
+func (t *jobTracker) wait(ctx context.Context, jobName string) (*v1.Job, error) {
+	finished := make(chan jobOutcome, 1)
+	t.mu.Lock()
+	t.waiters[jobName] = append(t.waiters[jobName], finished)
+	t.mu.Unlock()
+	defer t.remove(jobName, finished)
+
+	// The Job may have finished before the waiter was registered
+	if item, exists, err := t.informer.GetStore().GetByKey(t.namespace + "/" + jobName); err == nil && exists {
+		if job := item.(*v1.Job); jobFinished(job) {
+			return job, nil
+		}
+	}
+
+	select {
+	case outcome := <-finished:
+		return outcome.job, outcome.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// notify fans a finished Job out to everyone waiting for it
+func (t *jobTracker) notify(item interface{}) {
+	job, ok := item.(*v1.Job)
+	if !ok || !jobFinished(job) {
+		return
+	}
+	t.wake(job.Name, jobOutcome{job: job})
+}
+
+// deleted wakes everyone waiting for a Job that is gone, which would otherwise wait out their
+// timeout. A Job deleted once it finished is handed out as finished.
+func (t *jobTracker) deleted(item interface{}) {
+	if tombstone, ok := item.(cache.DeletedFinalStateUnknown); ok {
+		item = tombstone.Obj
+	}
+	job, ok := item.(*v1.Job)
+	if !ok {
+		return
+	}
+	if jobFinished(job) {
+		t.wake(job.Name, jobOutcome{job: job})
+		return
+	}
+	t.wake(job.Name, jobOutcome{err: ErrJobDeleted})
+}
+
+func (t *jobTracker) wake(jobName string, outcome jobOutcome) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, finished := range t.waiters[jobName] {
+		select {
+		case finished <- outcome:
+		default:
+		}
+	}
+}
+
+func (t *jobTracker) remove(jobName string, finished chan jobOutcome) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	waiters := t.waiters[jobName]
+	for i, waiter := range waiters {
+		if waiter == finished {
+			waiters = append(waiters[:i], waiters[i+1:]...)
+			break
+		}
+	}
+	if len(waiters) == 0 {
+		delete(t.waiters, jobName)
+	} else {
+		t.waiters[jobName] = waiters
+	}
+}
+
+// jobFinished reports whether the Job succeeded or has given up
+func jobFinished(job *v1.Job) bool {
+	return job.Status.Succeeded > 0 || job.Status.Failed > 0 || jobFailed(job)
+}
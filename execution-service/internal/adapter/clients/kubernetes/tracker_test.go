@@ -0,0 +1,101 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestWaitForJobCompletion(t *testing.T) {
+	finished := &v1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "done", Namespace: "test", Labels: map[string]string{"frontend": "code-execution"}},
+		Status:     v1.JobStatus{Succeeded: 1},
+	}
+	client := NewKubernetesClientForClientset(fake.NewSimpleClientset(finished), "test")
+	if err := client.WaitForJobCompletion(context.Background(), "done", 0); err == nil || !strings.Contains(err.Error(), "not started") {
+		t.Fatalf("WaitForJobCompletion before Start = %v, want an error", err)
+	}
+
+	lifetime, shutdown := context.WithCancel(context.Background())
+	defer shutdown()
+	client.Start(lifetime)
+	if err := client.WaitForJobCompletion(context.Background(), "done", 0); err != nil {
+		t.Fatalf("WaitForJobCompletion of a finished Job: %v", err)
+	}
+}
+
+func TestWaitForJobCompletionBeforeSync(t *testing.T) {
+	// The Jobs are never listed, so the watch never syncs
+	clientset := fake.NewSimpleClientset()
+	listed := make(chan struct{})
+	defer close(listed)
+	clientset.PrependReactor("list", "jobs", func(k8stesting.Action) (bool, runtime.Object, error) {
+		<-listed
+		return true, nil, context.Canceled
+	})
+	client := NewKubernetesClientForClientset(clientset, "test")
+	lifetime, shutdown := context.WithCancel(context.Background())
+	defer shutdown()
+	client.Start(lifetime)
+
+	// The caller's ctx bounds the wait for the watch, not the service's lifetime
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := client.WaitForJobCompletion(ctx, "done", 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitForJobCompletion before the watch synced = %v, want the caller's deadline", err)
+	}
+}
+
+func TestWaitForJobCompletionOfDeletedJob(t *testing.T) {
+	running := &v1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: "test", Labels: map[string]string{"frontend": "code-execution"}},
+	}
+	clientset := fake.NewSimpleClientset(running)
+	client := NewKubernetesClientForClientset(clientset, "test")
+	lifetime, shutdown := context.WithCancel(context.Background())
+	defer shutdown()
+	client.Start(lifetime)
+
+	waited := make(chan error, 1)
+	go func() {
+		waited <- client.WaitForJobCompletion(context.Background(), "running", 60)
+	}()
+	// Delete the Job once the submission waits for it, as someone cleaning up by hand would
+	deadline := time.Now().Add(2 * time.Second)
+	for !waiting(client.tracker, "running") {
+		if time.Now().After(deadline) {
+			t.Fatal("nobody waits for the Job")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := clientset.BatchV1().Jobs("test").Delete(context.Background(), "running", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	select {
+	case err := <-waited:
+		if !errors.Is(err, ErrJobDeleted) {
+			t.Errorf("WaitForJobCompletion of a deleted Job = %v, want ErrJobDeleted", err)
+		}
+		if errors.Is(err, ErrJobFailed) {
+			t.Errorf("deleted Job reported as a failure of the submission: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("waiter was not woken when the Job was deleted")
+	}
+}
+
+// waiting reports whether a submission waits for the named Job
+func waiting(tracker *jobTracker, jobName string) bool {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	return len(tracker.waiters[jobName]) > 0
+}
@@ -1,67 +1,29 @@
 package impl
 
 import (
-	"github.com/streadway/amqp"
+	"go-compiler/common/pkg/broker"
 	"log"
+
+	"github.com/streadway/amqp"
 )
 
 type QueueClient struct {
-	QueueName  string `json:"queue_name"`
-	Connection *amqp.Connection
-	Channel    *amqp.Channel
+	QueueName string `json:"queue_name"`
+	Client    *broker.Client
 }
 
+// NewQueueClient connects to RabbitMQ in the background; the client reconnects whenever the
+// connection drops, so the service starts even while RabbitMQ is down.
 func NewQueueClient(queueName string, rabbitMQURL string) (*QueueClient, error) {
-	// Dial the RabbitMQ server
-	conn, err := amqp.Dial(rabbitMQURL)
-	if err != nil {
-		log.Fatalf("Failed to connect to RabbitMQ: %v", err)
-		return nil, err
-	}
-
-	// Create a channel
-	ch, err := conn.Channel()
-	if err != nil {
-		log.Fatalf("Failed to open a channel: %v", err)
-		conn.Close()
-		return nil, err
-	}
-
-	// Declare a queue to ensure it exists
-	_, err = ch.QueueDeclare(
-		queueName, // name of the queue
-		true,      // durable
-		false,     // delete when unused
-		false,     // exclusive
-		false,     // no-wait
-		nil,       // arguments
-	)
-	if err != nil {
-		log.Fatalf("Failed to declare a queue: %v", err)
-		conn.Close()
-		ch.Close()
-		return nil, err
-	}
-
-	// Return the QueueClient with connection and channel established
 	return &QueueClient{
-		QueueName:  queueName,
-		Connection: conn,
-		Channel:    ch,
+		QueueName: queueName,
+		Client:    broker.NewClient(rabbitMQURL),
 	}, nil
 }
 
-// PublishMessage sends a message to the queue.
+// PublishMessage sends a message to the queue and waits until RabbitMQ confirms it.
 func (qc *QueueClient) PublishMessage(messageBody string) error {
-	err := qc.Channel.Publish(
-		"",           // exchange
-		qc.QueueName, // routing key (queue name)
-		false,        // mandatory
-		false,        // immediate
-		amqp.Publishing{
-			ContentType: "text/plain",
-			Body:        []byte(messageBody),
-		})
+	err := qc.Client.SendMessage(qc.QueueName, []byte(messageBody))
 	if err != nil {
 		log.Printf("Failed to publish a message: %v", err)
 		return err
@@ -70,16 +32,38 @@ func (qc *QueueClient) PublishMessage(messageBody string) error {
 	return nil
 }
 
-func (qc *QueueClient) ConsumeMessages(handleMessage func(string)) error {
-	msgs, err := qc.Channel.Consume(
-		qc.QueueName, // queue
-		"",           // consumer
-		true,         // auto-ack
-		false,        // exclusive
-		false,        // no-local
-		false,        // no-wait
-		nil,          // arguments
-	)
+// PublishEvent publishes an event to exchange and waits until RabbitMQ confirms it.
+func (qc *QueueClient) PublishEvent(exchange string, key string, body []byte) error {
+	err := qc.Client.PublishEvent(exchange, key, body)
+	if err != nil {
+		log.Printf("Failed to publish an event: %v", err)
+		return err
+	}
+	log.Printf("Event published to exchange %s with key %s", exchange, key)
+	return nil
+}
+
+// ConsumeTransient consumes a queue that only exists while it is consumed, such as the input of a
+// running submission.
+func (qc *QueueClient) ConsumeTransient(queue string, tag string, bindings ...broker.Binding) (<-chan amqp.Delivery, error) {
+	deliveries, err := qc.Client.ConsumeTransient(queue, tag, bindings...)
+	if err != nil {
+		log.Printf("Failed to register a consumer: %v", err)
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// Cancel stops the consumer with the given tag
+func (qc *QueueClient) Cancel(tag string) error {
+	return qc.Client.Cancel(tag)
+}
+
+// ConsumeMessages hands every message to handleMessage. A message is acked once handleMessage
+// returns nil, requeued with its retry counter incremented when it fails, and rejected when it
+// fails with a broker.Permanent error or after broker.DefaultMaxRetries retries.
+func (qc *QueueClient) ConsumeMessages(handleMessage func(string) error) error {
+	msgs, err := qc.Client.Consume(qc.QueueName, "", broker.DefaultPrefetch)
 	if err != nil {
 		log.Printf("Failed to register a consumer: %v", err)
 		return err
@@ -87,7 +71,10 @@ func (qc *QueueClient) ConsumeMessages(handleMessage func(string)) error {
 
 	go func() {
 		for d := range msgs {
-			handleMessage(string(d.Body))
+			err := broker.Settle(qc.Client, d, handleMessage(string(d.Body)), broker.DefaultMaxRetries)
+			if err != nil {
+				log.Printf("Failed to acknowledge message: %v", err)
+			}
 		}
 	}()
 
@@ -96,13 +83,7 @@ func (qc *QueueClient) ConsumeMessages(handleMessage func(string)) error {
 }
 
 func (qc *QueueClient) Close() error {
-	err := qc.Channel.Close()
-	if err != nil {
-		log.Printf("Failed to close channel: %v", err)
-		return err
-	}
-
-	err = qc.Connection.Close()
+	err := qc.Client.Close()
 	if err != nil {
 		log.Printf("Failed to close connection: %v", err)
 		return err
@@ -1,7 +1,18 @@
 package queue
 
+import (
+	"go-compiler/common/pkg/broker"
+
+	"github.com/streadway/amqp"
+)
+
 type IQueueClient interface {
 	Close() error
-	ConsumeMessages(handleMessage func(string)) error
+	ConsumeMessages(handleMessage func(string) error) error
 	PublishMessage(messageBody string) error
+	// PublishEvent publishes body to exchange with the routing key key
+	PublishEvent(exchange string, key string, body []byte) error
+	// ConsumeTransient consumes a queue that is deleted once the consumer with tag is cancelled
+	ConsumeTransient(queue string, tag string, bindings ...broker.Binding) (<-chan amqp.Delivery, error)
+	Cancel(tag string) error
 }
@@ -1,9 +1,21 @@
 package request
 
+import "go-compiler/models/submissions"
+
 type NewExecutionRequest struct {
-	Id         string `json:"id"`
-	Code       string `json:"code"`
-	LanguageId int64  `json:"language_id"`
-	RequestId  string `json:"request_id"`
-	StdIn      string `json:"stdin"`
+	Id              string                 `json:"id"`
+	Code            string                 `json:"code"`
+	LanguageId      int64                  `json:"language_id"`
+	RequestId       string                 `json:"request_id"`
+	ConnectionId    string                 `json:"connection_id,omitempty"`
+	Interactive     bool                   `json:"interactive,omitempty"`
+	StdIn           string                 `json:"stdin"`
+	ExpectedOutput  *string                `json:"expected_output,omitempty"`
+	CompareMode     string                 `json:"compare_mode,omitempty"`
+	FloatTolerance  float64                `json:"float_tolerance,omitempty"`
+	CompilerOptions []string               `json:"compiler_options,omitempty"`
+	TestCases       []submissions.TestCase `json:"test_cases,omitempty"`
+	Tenant          string                 `json:"tenant,omitempty"`
+	submissions.Limits
+	submissions.Sources
 }
@@ -1,20 +1,36 @@
 package factory
 
 import (
+	"context"
+	"go-compiler/common/pkg/config"
+	"go-compiler/common/pkg/registry"
 	"go-compiler/common/pkg/utils"
 	"go-compiler/execution-service/internal/adapter/factory"
 	"go-compiler/execution-service/internal/domain/services/impl"
 	"go-compiler/execution-service/internal/domain/services/interfaces"
+	"log"
 )
 
 type DomainFactory struct {
 	ExecutionService interfaces.IExecutionService
+	PoolService      interfaces.IPoolService
 }
 
-func NewDomainFactory() *DomainFactory {
-	adapters := factory.NewAdapterFactory()
-	cache := utils.NewCacheClient("localhost:6379", "", 0)
+func NewDomainFactory(ctx context.Context, settings *config.Config) *DomainFactory {
+	languageRegistry, err := registry.NewDefaultRegistry()
+	if err != nil {
+		log.Fatalf("Failed to load language registry: %v", err)
+	}
+	adapters := factory.NewAdapterFactory(ctx, settings, languageRegistry)
+	cache := utils.NewCacheClient(settings.Redis.Address, settings.Redis.Password, settings.Redis.DB)
+
+	// A nil *WarmPool must become a nil interface, so the service can tell the pool is disabled
+	var pool impl.PoolStatsProvider
+	if adapters.Pool != nil {
+		pool = adapters.Pool
+	}
 	return &DomainFactory{
-		ExecutionService: impl.NewExecutionRequestService(adapters.QueueClient, cache),
+		ExecutionService: impl.NewExecutionRequestService(adapters.QueueClient, cache, languageRegistry, adapters.Executor, adapters.Sandbox, settings.Interactive),
+		PoolService:      impl.NewPoolService(pool),
 	}
 }
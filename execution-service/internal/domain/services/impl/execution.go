@@ -3,25 +3,41 @@ package impl
 import (
 	"context"
 	"encoding/base64"
+	"fmt"
+	"go-compiler/common/pkg/broker"
+	"go-compiler/common/pkg/config"
+	"go-compiler/common/pkg/events"
+	"go-compiler/common/pkg/judge"
+	"go-compiler/common/pkg/limits"
+	"go-compiler/common/pkg/registry"
+	"go-compiler/common/pkg/runner"
 	"go-compiler/common/pkg/utils"
 	"go-compiler/common/pkg/utils/logger"
+	"go-compiler/common/pkg/workspace"
 	"go-compiler/execution-service/internal/adapter/clients/queue"
 	"go-compiler/execution-service/internal/domain/dto/request"
-	"go.mongodb.org/mongo-driver/bson"
-	"io"
-	"os/exec"
-	"time"
+	"go-compiler/models/languages"
+	"go-compiler/models/results"
+	"os"
 )
 
 type ExecutionRequestService struct {
-	QueueClient      queue.IQueueClient
-	cache            utils.ICacheClient
+	QueueClient queue.IQueueClient
+	reporter    *events.Reporter
+	languages   registry.ILanguageRegistry
+	executor    runner.Executor
+	sandbox     runner.Isolation
+	interactive config.Interactive
 }
 
-func NewExecutionRequestService(qc queue.IQueueClient,  c utils.ICacheClient) *ExecutionRequestService {
+func NewExecutionRequestService(qc queue.IQueueClient, c utils.ICacheClient, lr registry.ILanguageRegistry, ex runner.Executor, sb runner.Isolation, in config.Interactive) *ExecutionRequestService {
 	return &ExecutionRequestService{
-		QueueClient:      qc,
-		cache:            c,
+		QueueClient: qc,
+		reporter:    events.NewReporter(qc, c),
+		languages:   lr,
+		executor:    ex,
+		sandbox:     sb,
+		interactive: in,
 	}
 }
 
@@ -30,196 +46,161 @@ func (s *ExecutionRequestService) HandleExecution(ctx context.Context, payload r
 	methodName := "HandleExecution"
 	log.Info("Entering", "methodName", methodName)
 
-	switch payload.LanguageId {
-	case 1:
-		err := s.ProcessPythonRequest(ctx, payload)
-		if err != nil {
-			log.Error("Error processing Python request", "error", err)
-			return err
-		}
-
-	case 2:
-		err := s.ProcessJavaScriptRequest(ctx, payload)
-		if err != nil {
-			log.Error("Error processing JavaScript request", "error", err)
-			return err
-		}
+	language, found := s.languages.Get(payload.LanguageId)
+	if !found {
+		err := broker.Permanent(fmt.Errorf("unsupported language id %d", payload.LanguageId))
+		log.Error("Error resolving language", "error", err)
+		s.reportFailure(ctx, payload, err)
+		return err
+	}
 
-	case 3:
-		err := s.ProcessBashRequest(ctx, payload)
-		if err != nil {
-			log.Error("Error processing Bash request", "error", err)
-			return err
+	err := s.ProcessRequest(ctx, language, payload)
+	if err != nil {
+		log.Error("Error processing request", "language", language.Name, "error", err)
+		if broker.IsPermanent(err) {
+			s.reportFailure(ctx, payload, err)
 		}
+		return err
 	}
 
 	return nil
 }
-func (e *ExecutionRequestService) ProcessPythonRequest(ctx context.Context, payload request.NewExecutionRequest) error {
-	log := logger.GetLogger(ctx)
-	methodName := "ProcessPythonRequest"
-	log.Info("Entering", "methodName", methodName)
 
-	// Decode the base64-encoded Python code
-	decodedCode, err := base64.StdEncoding.DecodeString(payload.Code)
+// reportFailure reports an internal error for a submission that will not be retried, so its client
+// is not left waiting. The submission is dead-lettered either way.
+func (s *ExecutionRequestService) reportFailure(ctx context.Context, payload request.NewExecutionRequest, cause error) {
+	log := logger.GetLogger(ctx)
+	err := s.reporter.Completed(results.CompletionEvent{
+		RequestId:    payload.RequestId,
+		ConnectionId: payload.ConnectionId,
+		Result:       runner.InternalError(cause),
+	})
 	if err != nil {
-		log.Error("Error decoding base64 string", "error", err)
-		return err
+		log.Error("Error reporting failed submission", "error", err)
 	}
+}
 
-	// Create the command to execute the decoded Python code
-	cmd := exec.Command("python3", "-c", string(decodedCode))
+// ProcessRequest runs the submission in a scratch directory and reports its structured result. Invalid
+// payloads are reported as permanent errors; other errors may succeed when retried.
+func (e *ExecutionRequestService) ProcessRequest(ctx context.Context, language languages.LanguageModel, payload request.NewExecutionRequest) error {
+	log := logger.GetLogger(ctx)
+	methodName := "ProcessRequest"
+	log.Info("Entering", "methodName", methodName, "language", language.Name)
 
-	// Get the stdin pipe for sending input to the Python script
-	stdin, err := cmd.StdinPipe()
-	if err != nil {
-		log.Error("Error creating stdin pipe", "error", err)
-		return err
-	}
+	// The executor reports compiling and running as it gets there
+	progress := e.reporter.Progress(payload.RequestId, payload.ConnectionId)
+	progress.Enter(results.Processing)
+	executor := progress.Executor(e.executor)
 
-	// Run the command asynchronously and provide the input from payload.Stdin
-	go func() {
-		defer stdin.Close()
-		if payload.StdIn != "" {
-			// Write the provided input from payload.StdIn to the stdin of the Python script
-			io.WriteString(stdin, payload.StdIn)
+	// Interactive programs read what their WebSocket client types
+	if payload.Interactive && payload.ConnectionId != "" {
+		input, err := events.OpenInput(e.QueueClient, payload.RequestId, payload.ConnectionId, e.interactive)
+		if err != nil {
+			log.Error("Error receiving input", "error", err)
+			return err
 		}
-	}()
-
-	// Capture the combined stdout and stderr output
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		log.Error("Error executing code", "error", err, "output", string(output))
-		return err
-	}
-
-	expiration := 1 * time.Hour
-	// Push the result to the cache
-	err = e.cache.Set(payload.RequestId, string(output), expiration)
-	if err != nil {
-		log.Error("Error setting cache", "error", err)
-		return err
+		defer input.Close()
+		executor = input.Executor(executor)
 	}
 
-	return nil
-}
-func (e *ExecutionRequestService) ProcessJavaScriptRequest(ctx context.Context, payload request.NewExecutionRequest) error {
-	log := logger.GetLogger(ctx)
-	methodName := "ProcessJavaScriptRequest"
-	log.Info("Entering", "methodName", methodName)
-
+	// Decode the base64-encoded source code
 	decodedCode, err := base64.StdEncoding.DecodeString(payload.Code)
 	if err != nil {
 		log.Error("Error decoding base64 string", "error", err)
-		return err
+		return broker.Permanent(err)
 	}
 
-	// Create the command to execute the JavaScript code
-	cmd := exec.Command("node", "-e", string(decodedCode))
+	// Decode the other files of multi-file submissions
+	files, err := workspace.Decode(payload.Sources)
+	if err != nil {
+		log.Error("Error decoding submission files", "error", err)
+		return broker.Permanent(err)
+	}
 
-	// Get the stdin pipe for sending input to the JavaScript script
-	stdin, err := cmd.StdinPipe()
+	workDir, err := os.MkdirTemp("", "execution-")
 	if err != nil {
-		log.Error("Error creating stdin pipe", "error", err)
+		log.Error("Error creating working directory", "error", err)
 		return err
 	}
+	defer os.RemoveAll(workDir)
 
-	// Run the command asynchronously and provide the input from payload.StdIn
-	go func() {
-		defer stdin.Close()
-		if payload.StdIn != "" {
-			// Write the provided input from payload.StdIn to the stdin of the JavaScript script
-			io.WriteString(stdin, payload.StdIn)
-		}
-	}()
-
-	// Capture the combined stdout and stderr
-	output, err := cmd.CombinedOutput()
+	mode, err := judge.ParseMode(payload.CompareMode)
 	if err != nil {
-		//log the error but save the error as output in cache
-		log.Error("Error executing code", "error", err, "output", string(output))
-		expiration := 1 * time.Hour
-		// Push the result to the cache
-		err = e.cache.Set(payload.RequestId, string(output), expiration)
-		if err != nil {
-			log.Error("Error setting cache", "error", err)
-			return err
-		}
-
+		log.Error("Error parsing compare mode", "error", err)
+		return broker.Permanent(err)
 	}
-	expiration := 1 * time.Hour
-	// Push the result to the cache
-	err = e.cache.Set(payload.RequestId, string(output), expiration)
+	err = judge.CheckTolerance(payload.FloatTolerance)
 	if err != nil {
-		log.Error("Error setting cache", "error", err)
-		return err
+		log.Error("Error checking float tolerance", "error", err)
+		return broker.Permanent(err)
 	}
 
-	return nil
-}
-
-func (e *ExecutionRequestService) ProcessBashRequest(ctx context.Context, payload request.NewExecutionRequest) error {
-	log := logger.GetLogger(ctx)
-	methodName := "ProcessBashRequest"
-	log.Info("Entering", "methodName", methodName)
-	decodedCode, err := base64.StdEncoding.DecodeString(payload.Code)
+	resolvedLimits, err := limits.Resolve(limits.ForLanguage(payload.Limits, language))
 	if err != nil {
-		log.Error("Error decoding base64 string", "error", err)
-		return err
+		log.Error("Error resolving limits", "error", err)
+		return broker.Permanent(err)
 	}
-	// Create the command to execute the Bash code
-	cmd := exec.Command("bash", "-c", string(decodedCode))
 
-	// Get the stdin pipe for sending input to the Bash script
-	stdin, err := cmd.StdinPipe()
-	if err != nil {
-		log.Error("Error creating stdin pipe", "error", err)
-		return err
+	submission := runner.Submission{
+		Language:        language,
+		Code:            decodedCode,
+		Files:           files,
+		CompilerOptions: payload.CompilerOptions,
+		StdIn:           payload.StdIn,
+		WorkDir:         workDir,
+		Limits:          resolvedLimits,
+
+		// Compiling is as untrusted as running, so both happen inside the sandbox
+		Isolation: e.sandbox,
+		RequestId: payload.RequestId,
+		Tenant:    payload.Tenant,
 	}
 
-	// Run the command asynchronously and provide the input from payload.StdIn
-	go func() {
-		defer stdin.Close()
-		if payload.StdIn != "" {
-			// Write the provided input from payload.StdIn to the stdin of the Bash script
-			io.WriteString(stdin, payload.StdIn)
+	var result results.ExecutionResult
+	if len(payload.TestCases) > 0 {
+		// Batch submissions compile once and are judged per test case
+		result = judge.RunTestCases(ctx, executor, submission, payload.TestCases, mode, payload.FloatTolerance)
+	} else {
+		// A WebSocket client sees the output of single runs as it is written
+		var output *events.OutputStream
+		if payload.ConnectionId != "" {
+			output = e.reporter.Output(payload.RequestId, payload.ConnectionId)
+			executor = output.Executor(executor)
 		}
-	}()
-
-	// Capture the combined stdout and stderr
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		log.Error("Error executing code", "error", err, "output", string(output))
-		expiration := 1 * time.Hour
-		// Push the result to the cache
-		err = e.cache.Set(payload.RequestId, string(output), expiration)
-		if err != nil {
-			log.Error("Error setting cache", "error", err)
-			return err
+		result = runner.RunWith(ctx, executor, submission)
+		// The output ends ahead of the completion event
+		if output != nil {
+			output.Close()
 		}
+		// Judge the output against the expected output when one was provided
+		judge.Apply(&result, payload.ExpectedOutput, mode, payload.FloatTolerance)
 	}
-	expiration := 1 * time.Hour
-	// Push the result to the cache
-	err = e.cache.Set(payload.RequestId, string(output), expiration)
+	log.Info("Execution finished", "status", result.Status.Name, "time", result.Time)
+
+	// Stored for polling clients and published for WebSocket clients
+	err = e.reporter.Completed(results.CompletionEvent{
+		RequestId:    payload.RequestId,
+		ConnectionId: payload.ConnectionId,
+		Result:       result,
+	})
 	if err != nil {
-		log.Error("Error setting cache", "error", err)
+		log.Error("Error reporting result", "error", err)
 		return err
 	}
-
 	return nil
 }
+
 func (e *ExecutionRequestService) GetExecution(ctx context.Context, requestId string) (interface{}, error) {
 	log := logger.GetLogger(ctx)
 	methodName := "GetExecution"
 	log.Info("Entering", "methodName", methodName)
 
-	// Get the result from the cache
-	result, err := e.cache.Get(requestId)
+	// Results and statuses of every executor are stored the same way; a submission without a result
+	// yet reports the status of its stage
+	submission, err := e.reporter.Submission(requestId)
 	if err != nil {
-		log.Error("Error getting cache", "error", err)
+		log.Error("Error getting submission", "error", err)
 		return nil, err
 	}
-	return bson.M{
-		"output": result,
-	}, nil
+	return submission, nil
 }
@@ -0,0 +1,34 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"go-compiler/execution-service/internal/adapter/clients/kubernetes"
+)
+
+// ErrPoolDisabled is returned when the service runs without a warm pool
+var ErrPoolDisabled = errors.New("warm pool is not enabled")
+
+// PoolStatsProvider reports the size and demand of the warm pool
+type PoolStatsProvider interface {
+	Stats() []kubernetes.PoolStats
+}
+
+type PoolService struct {
+	pool PoolStatsProvider
+}
+
+func NewPoolService(pool PoolStatsProvider) *PoolService {
+	return &PoolService{
+		pool: pool,
+	}
+}
+
+func (s *PoolService) GetPoolStats(ctx context.Context) (interface{}, error) {
+	if s.pool == nil {
+		return nil, ErrPoolDisabled
+	}
+	return struct {
+		Pools []kubernetes.PoolStats `json:"pools"`
+	}{s.pool.Stats()}, nil
+}
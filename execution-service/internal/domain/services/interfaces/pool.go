@@ -0,0 +1,9 @@
+package interfaces
+
+import (
+	"context"
+)
+
+type IPoolService interface {
+	GetPoolStats(ctx context.Context) (interface{}, error)
+}
@@ -1,7 +1,9 @@
 package controllers
 
 import (
+	"errors"
 	"go-compiler/common/pkg/utils/logger"
+	"go-compiler/execution-service/internal/domain/services/impl"
 	"go-compiler/execution-service/internal/domain/services/interfaces"
 
 	"github.com/gin-gonic/gin"
@@ -9,11 +11,13 @@ import (
 
 type RequestController struct {
 	ExecutionService interfaces.IExecutionService
+	PoolService      interfaces.IPoolService
 }
 
-func NewRequestController(es interfaces.IExecutionService) *RequestController {
+func NewRequestController(es interfaces.IExecutionService, ps interfaces.IPoolService) *RequestController {
 	return &RequestController{
 		ExecutionService: es,
+		PoolService:      ps,
 	}
 }
 
@@ -34,3 +38,24 @@ func (rc *RequestController) GetExecution() gin.HandlerFunc {
 		ctx.JSON(200, resp)
 	}
 }
+
+func (rc *RequestController) GetPoolStats() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		log := logger.GetLogger(ctx)
+		methodName := "GetPoolStats"
+		log.Info("Entering", "methodName", methodName)
+
+		resp, domainError := rc.PoolService.GetPoolStats(ctx)
+		if errors.Is(domainError, impl.ErrPoolDisabled) {
+			ctx.JSON(404, gin.H{"error": domainError.Error()})
+			return
+		}
+		if domainError != nil {
+			log.Error("Error in processing request", "error", domainError.Error())
+			ctx.JSON(500, gin.H{"error": domainError.Error()})
+			return
+		}
+
+		ctx.JSON(200, resp)
+	}
+}
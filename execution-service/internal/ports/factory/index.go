@@ -1,6 +1,8 @@
 package factory
 
 import (
+	"context"
+	"go-compiler/common/pkg/config"
 	"go-compiler/execution-service/internal/domain/factory"
 	"go-compiler/execution-service/internal/ports/controllers"
 	"go-compiler/execution-service/internal/ports/handlers"
@@ -11,10 +13,11 @@ type PortFactory struct {
 	ExecutionHandler  handlers.ExecutionHandler
 }
 
-func NewPortFactory() *PortFactory {
-	domains := factory.NewDomainFactory()
+// NewPortFactory builds the service on the configured backend, whose background work runs until ctx is done
+func NewPortFactory(ctx context.Context, settings *config.Config) *PortFactory {
+	domains := factory.NewDomainFactory(ctx, settings)
 	return &PortFactory{
-		RequestController: *controllers.NewRequestController(domains.ExecutionService),
+		RequestController: *controllers.NewRequestController(domains.ExecutionService, domains.PoolService),
 		ExecutionHandler:  *handlers.NewExecutionHandler(domains.ExecutionService),
 	}
 }
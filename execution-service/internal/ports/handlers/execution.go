@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"go-compiler/common/pkg/broker"
 	"go-compiler/common/pkg/utils/logger"
 	"go-compiler/execution-service/internal/domain/dto/request"
 	"go-compiler/execution-service/internal/domain/services/interfaces"
@@ -19,6 +20,8 @@ func NewExecutionHandler(es interfaces.IExecutionService) *ExecutionHandler {
 	}
 }
 
+// Handle runs one queued submission. It returns nil once the result is stored, and an error marked
+// with broker.Permanent when the payload can never be handled.
 func (handler *ExecutionHandler) Handle(payload string) error {
 	// Create a new context
 	ctx := context.Background()
@@ -35,7 +38,7 @@ func (handler *ExecutionHandler) Handle(payload string) error {
 	err := json.Unmarshal([]byte(payload), &RequestBody)
 	if err != nil {
 		log.Error("Unmarshalling Error", "error", err)
-		return nil
+		return broker.Permanent(err)
 	}
 	log.Info("Unmarshalling completed", "time_taken", time.Since(unmarshalStart))
 
@@ -44,7 +47,7 @@ func (handler *ExecutionHandler) Handle(payload string) error {
 	resp := handler.ExecutionService.HandleExecution(ctx, *RequestBody)
 	if resp != nil {
 		log.Error("Error in processing request", "error", resp.Error())
-		return nil
+		return resp
 	}
 	log.Info("ExecutionService.HandleExecution completed", "time_taken", time.Since(handleExecutionStart))
 
@@ -9,7 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func NewRouter() *gin.Engine {
+func NewRouter(portFactory *factory.PortFactory) *gin.Engine {
 	router := gin.New()
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
@@ -22,13 +22,12 @@ func NewRouter() *gin.Engine {
 		})
 	})
 
-	portFactory := factory.NewPortFactory()
-
 	api := router.Group("/api")
 	{
 		v1 := api.Group("/v1")
 		{
 			v1.GET("/submissions/:request_id", portFactory.RequestController.GetExecution())
+			v1.GET("/pool", portFactory.RequestController.GetPoolStats())
 		}
 	}
 
@@ -1,11 +1,12 @@
 package router
 
 import (
+	"go-compiler/execution-service/internal/ports/factory"
 	"go-compiler/execution-service/internal/ports/router"
 
 	"github.com/gin-gonic/gin"
 )
 
-func GetRouter() *gin.Engine {
-	return router.NewRouter()
+func GetRouter(ports *factory.PortFactory) *gin.Engine {
+	return router.NewRouter(ports)
 }
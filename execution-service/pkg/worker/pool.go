@@ -0,0 +1,228 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"go-compiler/common/pkg/config"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ConcurrencyEnv is how many submissions run at once, which is also each queue consumer's prefetch
+	ConcurrencyEnv = "WORKER_CONCURRENCY"
+	// LanguageConcurrencyEnv caps submissions per language as language_id=limit pairs, such as "4=2,5=2"
+	LanguageConcurrencyEnv = "WORKER_LANGUAGE_CONCURRENCY"
+	// DrainTimeoutEnv is how long shutdown waits for running submissions, as a duration such as "90s"
+	DrainTimeoutEnv = "WORKER_DRAIN_TIMEOUT"
+	// QueueWeightsEnv weighs the queue classes as class=weight pairs, such as "interactive=4,batch=1"
+	QueueWeightsEnv = "WORKER_QUEUE_WEIGHTS"
+
+	// drainMargin leaves time to report the result of a run that used up its limits
+	drainMargin = 10 * time.Second
+)
+
+// Config bounds how many submissions run at once
+type Config struct {
+	// Concurrency is how many submissions run at once across all languages
+	Concurrency int
+	// PerLanguage caps how many submissions of a language run at once. Languages without an entry
+	// are only bound by Concurrency.
+	PerLanguage map[int64]int
+	// DrainTimeout is how long Drain waits for running submissions
+	DrainTimeout time.Duration
+	// Weights are the queue classes' shares of the workers while each has submissions waiting
+	Weights Weights
+}
+
+// ConfigFromEnv reads the pool's configuration, running one submission per CPU by default and
+// draining for DefaultDrainTimeout of settings
+func ConfigFromEnv(settings *config.Config) (Config, error) {
+	config := Config{
+		Concurrency:  runtime.NumCPU(),
+		DrainTimeout: DefaultDrainTimeout(settings),
+		Weights:      DefaultWeights,
+	}
+	if value := os.Getenv(ConcurrencyEnv); value != "" {
+		concurrency, err := strconv.Atoi(value)
+		if err != nil || concurrency < 1 {
+			return config, fmt.Errorf("invalid %s %q, expected a positive number", ConcurrencyEnv, value)
+		}
+		config.Concurrency = concurrency
+	}
+	perLanguage, err := parseLanguageLimits(os.Getenv(LanguageConcurrencyEnv))
+	if err != nil {
+		return config, fmt.Errorf("invalid %s: %v", LanguageConcurrencyEnv, err)
+	}
+	config.PerLanguage = perLanguage
+	if value := os.Getenv(DrainTimeoutEnv); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < 0 {
+			return config, fmt.Errorf("invalid %s %q, expected a duration such as 90s", DrainTimeoutEnv, value)
+		}
+		config.DrainTimeout = timeout
+	}
+	if value := os.Getenv(QueueWeightsEnv); value != "" {
+		weights, err := ParseWeights(value)
+		if err != nil {
+			return config, fmt.Errorf("invalid %s: %v", QueueWeightsEnv, err)
+		}
+		config.Weights = weights
+	}
+	return config, nil
+}
+
+// DefaultDrainTimeout covers the longest single run settings allow: the maximum compile time limit
+// followed by the maximum wall time limit, or by the session limit of an interactive run when that
+// is longer. Batches of several test cases may take longer and are redelivered when cut off.
+func DefaultDrainTimeout(settings *config.Config) time.Duration {
+	run := settings.Limits.Maximum.WallTimeLimit
+	if settings.Interactive.SessionLimit > run {
+		run = settings.Interactive.SessionLimit
+	}
+	longest := settings.Limits.Maximum.CompileTimeLimit + run
+	return time.Duration(longest*float64(time.Second)) + drainMargin
+}
+
+// parseLanguageLimits parses language_id=limit pairs separated by commas
+func parseLanguageLimits(value string) (map[int64]int, error) {
+	limits := make(map[int64]int)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		id, limit, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid limit %q, expected language_id=limit", pair)
+		}
+		languageId, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid language id in limit %q: %v", pair, err)
+		}
+		count, err := strconv.Atoi(strings.TrimSpace(limit))
+		if err != nil || count < 1 {
+			return nil, fmt.Errorf("invalid limit %q, expected a positive number", pair)
+		}
+		limits[languageId] = count
+	}
+	return limits, nil
+}
+
+// Pool runs tasks on a bounded number of goroutines, with a smaller bound per language. A slot of
+// the pool is acquired before a task is picked, so the caller only takes work it can start. A task
+// whose language is at its bound gives that slot back while it waits, so tasks of other languages
+// can start meanwhile, and takes a slot again once its language has room.
+type Pool struct {
+	slots     chan struct{}
+	languages map[int64]chan struct{}
+
+	running   sync.WaitGroup
+	draining  chan struct{}
+	drainOnce sync.Once
+}
+
+func NewPool(config Config) *Pool {
+	pool := &Pool{
+		slots:     make(chan struct{}, max(config.Concurrency, 1)),
+		languages: make(map[int64]chan struct{}, len(config.PerLanguage)),
+		draining:  make(chan struct{}),
+	}
+	for languageId, limit := range config.PerLanguage {
+		pool.languages[languageId] = make(chan struct{}, limit)
+	}
+	return pool
+}
+
+// Acquire waits for a free slot of the pool. It returns false when stop is closed or the pool starts
+// draining first. Every acquired slot must be handed to Submit or returned with Release.
+func (p *Pool) Acquire(stop <-chan struct{}) bool {
+	return p.acquire(p.slots, stop)
+}
+
+// Release returns a slot acquired with Acquire that was not handed to Submit
+func (p *Pool) Release() {
+	<-p.slots
+}
+
+// Submit runs task in its own goroutine on a slot taken with Acquire, once a slot of languageId is
+// free too. While it waits for the language, the pool's slot is released and taken again afterwards.
+// When the pool starts draining before the task starts, skip is called instead, so the caller can
+// hand the work back. The slots are released when task or skip returns.
+func (p *Pool) Submit(languageId int64, task func(), skip func()) {
+	p.running.Add(1)
+	go func() {
+		defer p.running.Done()
+
+		if language, limited := p.languages[languageId]; limited {
+			if !p.acquireLanguage(language) {
+				skip()
+				return
+			}
+			defer func() { <-language }()
+		}
+		defer p.Release()
+
+		task()
+	}()
+}
+
+// acquireLanguage takes a slot of language for a task holding a slot of the pool. When language has
+// no room, the pool's slot is released while waiting and taken again once the language's slot is. It
+// returns false, holding neither slot, when the pool starts draining first.
+func (p *Pool) acquireLanguage(language chan struct{}) bool {
+	select {
+	case language <- struct{}{}:
+		return true
+	default:
+	}
+
+	p.Release()
+	if !p.acquire(language, nil) {
+		return false
+	}
+	if !p.acquire(p.slots, nil) {
+		<-language
+		return false
+	}
+	return true
+}
+
+// acquire takes a slot of slots, or returns false when stop is closed or the pool starts draining first
+func (p *Pool) acquire(slots chan struct{}, stop <-chan struct{}) bool {
+	select {
+	case <-p.draining:
+		return false
+	default:
+	}
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-stop:
+		return false
+	case <-p.draining:
+		return false
+	}
+}
+
+// Drain stops starting tasks and waits for the running ones to finish. It returns ctx's error when
+// ctx is done first; the remaining tasks keep running.
+func (p *Pool) Drain(ctx context.Context) error {
+	p.drainOnce.Do(func() { close(p.draining) })
+
+	done := make(chan struct{})
+	go func() {
+		p.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
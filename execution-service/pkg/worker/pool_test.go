@@ -0,0 +1,114 @@
+package worker
+
+import (
+	"context"
+	"go-compiler/common/pkg/config"
+	"testing"
+	"time"
+)
+
+func TestSubmitFreesItsSlotWhileItsLanguageIsBusy(t *testing.T) {
+	pool := NewPool(Config{Concurrency: 2, PerLanguage: map[int64]int{1: 1}})
+	stop := make(chan struct{})
+	defer close(stop)
+
+	// The first task of language 1 takes its only slot and blocks
+	busy, finish := make(chan struct{}), make(chan struct{})
+	pool.Acquire(stop)
+	pool.Submit(1, func() {
+		close(busy)
+		<-finish
+	}, func() { t.Error("first task was skipped") })
+	<-busy
+
+	// The second waits for language 1, giving the pool's slot back meanwhile
+	waited := make(chan struct{})
+	pool.Acquire(stop)
+	pool.Submit(1, func() { close(waited) }, func() { t.Error("second task was skipped") })
+
+	// So a task of another language still starts
+	other := make(chan struct{})
+	if !acquireWithin(pool, time.Second) {
+		t.Fatal("no slot was free while a task waited for its language")
+	}
+	pool.Submit(2, func() { close(other) }, func() { t.Error("other task was skipped") })
+	select {
+	case <-other:
+	case <-time.After(time.Second):
+		t.Fatal("task of another language did not run")
+	}
+
+	close(finish)
+	select {
+	case <-waited:
+	case <-time.After(time.Second):
+		t.Fatal("waiting task did not run once its language was free")
+	}
+	if err := pool.Drain(context.Background()); err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+	if len(pool.slots) != 0 || len(pool.languages[1]) != 0 {
+		t.Errorf("slots left taken: pool %d, language %d", len(pool.slots), len(pool.languages[1]))
+	}
+}
+
+func TestSubmitSkipsWhenDrainingWhileWaiting(t *testing.T) {
+	pool := NewPool(Config{Concurrency: 2, PerLanguage: map[int64]int{1: 1}})
+	finish := make(chan struct{})
+	busy := make(chan struct{})
+	pool.Acquire(nil)
+	pool.Submit(1, func() {
+		close(busy)
+		<-finish
+	}, func() {})
+	<-busy
+
+	skipped := make(chan struct{})
+	pool.Acquire(nil)
+	pool.Submit(1, func() { t.Error("task ran although the pool was draining") }, func() { close(skipped) })
+
+	drained := make(chan error)
+	go func() { drained <- pool.Drain(context.Background()) }()
+	select {
+	case <-skipped:
+	case <-time.After(time.Second):
+		t.Fatal("waiting task was not skipped when the pool started draining")
+	}
+	close(finish)
+	if err := <-drained; err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+	if len(pool.slots) != 0 || len(pool.languages[1]) != 0 {
+		t.Errorf("slots left taken: pool %d, language %d", len(pool.slots), len(pool.languages[1]))
+	}
+}
+
+// acquireWithin acquires a slot of pool, giving up after timeout
+func acquireWithin(pool *Pool, timeout time.Duration) bool {
+	stop := make(chan struct{})
+	timer := time.AfterFunc(timeout, func() { close(stop) })
+	defer timer.Stop()
+	return pool.Acquire(stop)
+}
+
+func TestDefaultDrainTimeout(t *testing.T) {
+	settings := &config.Config{
+		Limits:      config.Limits{Maximum: config.LimitValues{CompileTimeLimit: 30, WallTimeLimit: 30}},
+		Interactive: config.Interactive{SessionLimit: 300},
+	}
+	// An interactive run may go on for its whole session after compiling
+	if got, want := DefaultDrainTimeout(settings), 340*time.Second; got != want {
+		t.Errorf("DefaultDrainTimeout() = %v, want %v", got, want)
+	}
+
+	settings.Interactive.SessionLimit = 10
+	if got, want := DefaultDrainTimeout(settings), 70*time.Second; got != want {
+		t.Errorf("DefaultDrainTimeout() with a short session = %v, want %v", got, want)
+	}
+
+	t.Setenv(DrainTimeoutEnv, "5s")
+	workers, err := ConfigFromEnv(settings)
+	if err != nil || workers.DrainTimeout != 5*time.Second {
+		t.Errorf("ConfigFromEnv() = %v, %v; want the drain timeout from %s", workers.DrainTimeout, err, DrainTimeoutEnv)
+	}
+}
@@ -0,0 +1,141 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"go-compiler/models/submissions"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Weights maps each queue class to its share of the workers while every class has submissions
+// waiting. A class with nothing waiting leaves its share to the others.
+type Weights map[submissions.QueueClass]int
+
+// DefaultWeights start four interactive submissions for every batch one
+var DefaultWeights = Weights{
+	submissions.Interactive: 4,
+	submissions.Batch:       1,
+}
+
+// ParseWeights parses class=weight pairs separated by commas. Classes that are not listed keep their
+// default weight.
+func ParseWeights(value string) (Weights, error) {
+	weights := make(Weights, len(DefaultWeights))
+	for class, weight := range DefaultWeights {
+		weights[class] = weight
+	}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, weight, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid weight %q, expected class=weight", pair)
+		}
+		class, err := submissions.ParseQueueClass(strings.TrimSpace(name), "")
+		if err != nil || class == "" {
+			return nil, fmt.Errorf("invalid class in weight %q", pair)
+		}
+		count, err := strconv.Atoi(strings.TrimSpace(weight))
+		if err != nil || count < 1 {
+			return nil, fmt.Errorf("invalid weight %q, expected a positive number", pair)
+		}
+		weights[class] = count
+	}
+	return weights, nil
+}
+
+// Scheduler picks which queue class the next submission is taken from, by smooth weighted round
+// robin: with weights 4 and 1, every five picks take four interactive and one batch submission,
+// interleaved rather than in runs.
+type Scheduler struct {
+	weights Weights
+	total   int
+	// credit grows by a class's weight per pick and shrinks by the total when the class is picked
+	credit map[submissions.QueueClass]int
+}
+
+func NewScheduler(weights Weights) *Scheduler {
+	scheduler := &Scheduler{
+		weights: weights,
+		credit:  make(map[submissions.QueueClass]int, len(weights)),
+	}
+	for _, weight := range weights {
+		scheduler.total += weight
+	}
+	return scheduler
+}
+
+// Next receives the next delivery from sources, taking it from the class whose turn it is when that
+// class has one waiting and from any other class otherwise. Closed sources are removed from sources.
+// It returns false once ctx is done or every source is closed.
+func Next[T any](ctx context.Context, s *Scheduler, sources map[submissions.QueueClass]<-chan T) (submissions.QueueClass, T, bool) {
+	var zero T
+	open := make(map[submissions.QueueClass]bool, len(sources))
+	for class := range sources {
+		open[class] = true
+	}
+	order := s.order(open)
+
+	// Take whatever is waiting, in the order of the classes' turns
+	for _, class := range order {
+		select {
+		case delivery, ok := <-sources[class]:
+			if ok {
+				s.picked(class)
+				return class, delivery, true
+			}
+			delete(sources, class)
+		default:
+		}
+	}
+
+	// Nothing is waiting, so the first delivery of any class is taken
+	for len(sources) > 0 {
+		classes := make([]submissions.QueueClass, 0, len(sources))
+		cases := []reflect.SelectCase{{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}}
+		for class, source := range sources {
+			classes = append(classes, class)
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(source)})
+		}
+		chosen, value, ok := reflect.Select(cases)
+		if chosen == 0 {
+			return "", zero, false
+		}
+		class := classes[chosen-1]
+		if !ok {
+			delete(sources, class)
+			continue
+		}
+		s.picked(class)
+		return class, value.Interface().(T), true
+	}
+	return "", zero, false
+}
+
+// order returns the open classes by whose turn it is, most due first
+func (s *Scheduler) order(open map[submissions.QueueClass]bool) []submissions.QueueClass {
+	var order []submissions.QueueClass
+	for _, class := range submissions.QueueClasses {
+		if open[class] {
+			order = append(order, class)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return s.credit[order[i]]+s.weights[order[i]] > s.credit[order[j]]+s.weights[order[j]]
+	})
+	return order
+}
+
+// picked records that a submission of class was taken
+func (s *Scheduler) picked(class submissions.QueueClass) {
+	for other, weight := range s.weights {
+		// Credit is capped, so a class that had nothing waiting for a while does not take a long run
+		s.credit[other] = min(s.credit[other]+weight, s.total)
+	}
+	s.credit[class] = max(s.credit[class]-s.total, -s.total)
+}
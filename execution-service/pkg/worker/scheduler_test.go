@@ -0,0 +1,124 @@
+package worker
+
+import (
+	"context"
+	"go-compiler/models/submissions"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseWeights(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Weights
+		wantErr bool
+	}{
+		{"", DefaultWeights, false},
+		{"batch=2", Weights{submissions.Interactive: 4, submissions.Batch: 2}, false},
+		{" interactive = 1 , batch = 3 ", Weights{submissions.Interactive: 1, submissions.Batch: 3}, false},
+		{"batch", nil, true},
+		{"bulk=1", nil, true},
+		{"batch=0", nil, true},
+		{"batch=x", nil, true},
+	}
+	for _, test := range tests {
+		got, err := ParseWeights(test.value)
+		if (err != nil) != test.wantErr {
+			t.Errorf("ParseWeights(%q) error = %v, want error %v", test.value, err, test.wantErr)
+			continue
+		}
+		if test.wantErr {
+			continue
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("ParseWeights(%q) = %v, want %v", test.value, got, test.want)
+		}
+		for class, weight := range test.want {
+			if got[class] != weight {
+				t.Errorf("ParseWeights(%q)[%s] = %d, want %d", test.value, class, got[class], weight)
+			}
+		}
+	}
+}
+
+// filled returns a source holding count deliveries named after class
+func filled(class submissions.QueueClass, count int) chan string {
+	source := make(chan string, count)
+	for i := 0; i < count; i++ {
+		source <- string(class)
+	}
+	return source
+}
+
+func TestNextByWeight(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights Weights
+		want    string
+	}{
+		{"four to one", Weights{submissions.Interactive: 4, submissions.Batch: 1}, "IIBIIIIBIIIIBII"},
+		{"even", Weights{submissions.Interactive: 1, submissions.Batch: 1}, "IBIBIBIBIBIBIBI"},
+		{"batch favoured", Weights{submissions.Interactive: 1, submissions.Batch: 2}, "BIBBIBBIBBIBBIB"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scheduler := NewScheduler(test.weights)
+			sources := map[submissions.QueueClass]<-chan string{
+				submissions.Interactive: filled(submissions.Interactive, 20),
+				submissions.Batch:       filled(submissions.Batch, 20),
+			}
+			var picks strings.Builder
+			for i := 0; i < len(test.want); i++ {
+				class, delivery, ok := Next(context.Background(), scheduler, sources)
+				if !ok || string(class) != delivery {
+					t.Fatalf("Next = %s, %q, %v", class, delivery, ok)
+				}
+				picks.WriteString(strings.ToUpper(string(class[0])))
+			}
+			if picks.String() != test.want {
+				t.Errorf("picks = %s, want %s", picks.String(), test.want)
+			}
+		})
+	}
+}
+
+func TestNextTakesWhatIsWaiting(t *testing.T) {
+	scheduler := NewScheduler(DefaultWeights)
+	batch := filled(submissions.Batch, 3)
+	sources := map[submissions.QueueClass]<-chan string{
+		submissions.Interactive: make(chan string),
+		submissions.Batch:       batch,
+	}
+	// An idle class leaves its share to the others
+	for i := 0; i < 3; i++ {
+		class, _, ok := Next(context.Background(), scheduler, sources)
+		if !ok || class != submissions.Batch {
+			t.Fatalf("Next = %s, %v; want the waiting batch submission", class, ok)
+		}
+	}
+
+	// Closed sources are dropped, and Next reports when none are left
+	close(batch)
+	closed := make(chan string)
+	close(closed)
+	sources[submissions.Interactive] = closed
+	if _, _, ok := Next(context.Background(), scheduler, sources); ok {
+		t.Fatal("Next returned a delivery from closed sources")
+	}
+	if len(sources) != 0 {
+		t.Errorf("closed sources were kept: %v", sources)
+	}
+}
+
+func TestNextStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	sources := map[submissions.QueueClass]<-chan string{
+		submissions.Interactive: make(chan string),
+		submissions.Batch:       make(chan string),
+	}
+	if _, _, ok := Next(ctx, NewScheduler(DefaultWeights), sources); ok {
+		t.Fatal("Next returned a delivery although nothing was sent")
+	}
+}
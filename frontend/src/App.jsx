@@ -167,12 +167,17 @@ const App = () => {
         const executionResponse = await getExecution({ request_id });
         console.log("Received response:", executionResponse);
   
-        if (executionResponse && executionResponse.output) {
-          console.log("Execution result received:", executionResponse.output);
-          setOutput(executionResponse.output);
+        // Unfinished submissions report their stage with an In Queue or Processing status
+        if (executionResponse && executionResponse.status && executionResponse.stage === 'finished') {
+          console.log("Execution result received:", executionResponse.status.name);
+          setOutput(
+            executionResponse.compile_output ||
+              executionResponse.stdout + executionResponse.stderr ||
+              executionResponse.status.name
+          );
           resultReceived = true;
         } else {
-          console.log("No output yet, polling again in 2 seconds...");
+          console.log(`No result yet (${executionResponse?.stage ?? 'unknown stage'}), polling again in 2 seconds...`);
           // Delay for the next polling request
           await new Promise((resolve) => setTimeout(resolve, pollInterval));
         }
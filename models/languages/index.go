@@ -1,6 +1,80 @@
 package languages
 
+import (
+	"fmt"
+	"slices"
+)
+
+// OptionsPlaceholder marks where the compiler options chosen for a submission go in CompileCmd.
+// Without it the options are appended to the end of the command.
+const OptionsPlaceholder = "{options}"
+
 type LanguageModel struct {
-	LanguageId int64  `json:"language_id"`
-	Name       string `json:"name"`
+	LanguageId int64    `json:"language_id"`
+	Name       string   `json:"name"`
+	Version    string   `json:"version"`
+	SourceFile string   `json:"source_file"`
+	CompileCmd []string `json:"compile_cmd,omitempty"`
+	RunCmd     []string `json:"run_cmd"`
+
+	// CompilerOptions lists the flags a submission may add to the compile command
+	CompilerOptions []string `json:"compiler_options,omitempty"`
+	// CompileTimeLimit, in seconds, replaces the default compile time limit for slow toolchains
+	CompileTimeLimit float64 `json:"compile_time_limit,omitempty"`
+
+	// Image is the sandbox image the Kubernetes backend compiles and runs the language in
+	Image string `json:"image,omitempty"`
+	// Kubernetes overrides the backend's default pod profile for the language
+	Kubernetes *KubernetesProfile `json:"kubernetes,omitempty"`
+}
+
+// KubernetesProfile tunes the pods the Kubernetes backend runs a language in. Unset fields keep the
+// backend's hardened defaults.
+type KubernetesProfile struct {
+	RunAsUser               *int64 `json:"run_as_user,omitempty"`
+	RunAsGroup              *int64 `json:"run_as_group,omitempty"`
+	ReadOnlyRootFilesystem  *bool  `json:"read_only_root_filesystem,omitempty"`
+	TmpSize                 string `json:"tmp_size,omitempty"`  // size of the writable /tmp, such as "64Mi"
+	CpuLimit                string `json:"cpu_limit,omitempty"` // CPU quota of each container, such as "500m"
+	AllowNetwork            *bool  `json:"allow_network,omitempty"`
+	TtlSecondsAfterFinished *int32 `json:"ttl_seconds_after_finished,omitempty"`
+}
+
+// IsCompiled reports whether the language needs a compile step before it can be run
+func (l LanguageModel) IsCompiled() bool {
+	return len(l.CompileCmd) > 0
+}
+
+// ValidateOptions rejects compiler options the language does not allow
+func (l LanguageModel) ValidateOptions(options []string) error {
+	if len(options) > 0 && !l.IsCompiled() {
+		return fmt.Errorf("%s is not compiled and takes no compiler options", l.Name)
+	}
+	for _, option := range options {
+		if !slices.Contains(l.CompilerOptions, option) {
+			return fmt.Errorf("compiler option %q is not allowed for %s", option, l.Name)
+		}
+	}
+	return nil
+}
+
+// CompileCommand returns the compile command with options in place of OptionsPlaceholder
+func (l LanguageModel) CompileCommand(options []string) ([]string, error) {
+	if err := l.ValidateOptions(options); err != nil {
+		return nil, err
+	}
+	command := make([]string, 0, len(l.CompileCmd)+len(options))
+	placed := false
+	for _, arg := range l.CompileCmd {
+		if arg == OptionsPlaceholder {
+			command = append(command, options...)
+			placed = true
+			continue
+		}
+		command = append(command, arg)
+	}
+	if !placed {
+		command = append(command, options...)
+	}
+	return command, nil
 }
@@ -0,0 +1,70 @@
+package results
+
+import "go-compiler/common/pkg/enums"
+
+type StatusModel struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type ExecutionResult struct {
+	RequestId     string           `json:"request_id"`
+	Status        StatusModel      `json:"status"`
+	Stdout        string           `json:"stdout"`
+	Stderr        string           `json:"stderr"`
+	CompileOutput string           `json:"compile_output"`
+	ExitCode      int              `json:"exit_code"`
+	Signal        int              `json:"signal,omitempty"`
+	Time          float64          `json:"time"`     // wall time in seconds
+	CpuTime       float64          `json:"cpu_time"` // user and system CPU time in seconds
+	Memory        int64            `json:"memory"`   // peak resident memory in KB
+	Message       string           `json:"message,omitempty"`
+	TestCases     []TestCaseResult `json:"test_cases,omitempty"`
+}
+
+// CompletionEvent announces a finished submission. Executors publish it once the result is stored,
+// and notification-service forwards it to the WebSocket client of ConnectionId.
+type CompletionEvent struct {
+	Event        string          `json:"event"`
+	RequestId    string          `json:"request_id"`
+	ConnectionId string          `json:"connection_id,omitempty"`
+	Result       ExecutionResult `json:"result"`
+}
+
+type TestCaseResult struct {
+	Index    int         `json:"index"`
+	Status   StatusModel `json:"status"`
+	Stdout   string      `json:"stdout"`
+	Stderr   string      `json:"stderr"`
+	ExitCode int         `json:"exit_code"`
+	Signal   int         `json:"signal,omitempty"`
+	Time     float64     `json:"time"`
+	CpuTime  float64     `json:"cpu_time"`
+	Memory   int64       `json:"memory"`
+	Message  string      `json:"message,omitempty"`
+}
+
+// NewStatus converts a verdict into the id/name pair returned to clients
+func NewStatus(status enums.Status) StatusModel {
+	info, _ := enums.GetStatusInfo(status)
+	return StatusModel{
+		Id:   info.ID,
+		Name: info.Name,
+	}
+}
+
+// NewTestCaseResult keeps the per-run fields of a result for the test case at index
+func NewTestCaseResult(index int, result ExecutionResult) TestCaseResult {
+	return TestCaseResult{
+		Index:    index,
+		Status:   result.Status,
+		Stdout:   result.Stdout,
+		Stderr:   result.Stderr,
+		ExitCode: result.ExitCode,
+		Signal:   result.Signal,
+		Time:     result.Time,
+		CpuTime:  result.CpuTime,
+		Memory:   result.Memory,
+		Message:  result.Message,
+	}
+}
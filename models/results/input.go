@@ -0,0 +1,17 @@
+package results
+
+// Names of the input events a WebSocket client sends, as their event field
+const (
+	InputEventName    = "input"
+	InputEndEventName = "input_end"
+)
+
+// InputEvent carries input a WebSocket client typed for its running interactive submission. An
+// event named InputEndEventName closes the program's stdin instead.
+type InputEvent struct {
+	Event     string `json:"event"`
+	RequestId string `json:"request_id"`
+	// ConnectionId is set by notification-service to the connection the input came from
+	ConnectionId string `json:"connection_id,omitempty"`
+	Data         string `json:"data,omitempty"`
+}
@@ -0,0 +1,72 @@
+package results
+
+import (
+	"go-compiler/common/pkg/enums"
+	"time"
+)
+
+// Stage is where a submission is in its lifecycle
+type Stage string
+
+const (
+	// Queued submissions wait in a queue for an executor
+	Queued Stage = "queued"
+	// Processing submissions were taken by an executor, which prepares them
+	Processing Stage = "processing"
+	// Compiling and Running submissions are in that step. Executors that compile as part of every
+	// run, such as Kubernetes Jobs, report compiling only briefly.
+	Compiling Stage = "compiling"
+	Running   Stage = "running"
+	// Finished submissions have a result
+	Finished Stage = "finished"
+)
+
+// Names of the events published about a submission, sent as their event field
+const (
+	StatusEventName    = "status"
+	CompletedEventName = "completed"
+)
+
+// NewStageStatus returns the status reported while a submission is in stage, which is In Queue
+// before an executor takes it and Processing until it finished
+func NewStageStatus(stage Stage) StatusModel {
+	if stage == Queued {
+		return NewStatus(enums.Queue)
+	}
+	return NewStatus(enums.Process)
+}
+
+// Transition records when a submission entered a stage
+type Transition struct {
+	Stage Stage     `json:"stage"`
+	At    time.Time `json:"at"`
+}
+
+// SubmissionStatus is the current stage of a submission and the stages it went through, oldest first
+type SubmissionStatus struct {
+	RequestId string       `json:"request_id"`
+	Stage     Stage        `json:"stage"`
+	Status    StatusModel  `json:"status"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	History   []Transition `json:"history"`
+}
+
+// StatusEvent announces that a submission entered a stage other than Finished, which is announced
+// by its CompletionEvent
+type StatusEvent struct {
+	Event        string      `json:"event"`
+	RequestId    string      `json:"request_id"`
+	ConnectionId string      `json:"connection_id,omitempty"`
+	Stage        Stage       `json:"stage"`
+	Status       StatusModel `json:"status"`
+	At           time.Time   `json:"at"`
+}
+
+// Submission is a submission as clients poll it: its result once finished, otherwise the status of
+// its stage, together with its lifecycle
+type Submission struct {
+	ExecutionResult
+	Stage     Stage        `json:"stage"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	History   []Transition `json:"history,omitempty"`
+}
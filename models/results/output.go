@@ -0,0 +1,32 @@
+package results
+
+// Stream names the output stream a chunk of output was written to
+type Stream string
+
+const (
+	StdoutStream Stream = "stdout"
+	StderrStream Stream = "stderr"
+)
+
+// Names of the output events, sent as their event field
+const (
+	OutputEventName    = "output"
+	OutputEndEventName = "output_end"
+)
+
+// OutputEvent carries a chunk of a running program's output. Once the program ended, an event named
+// OutputEndEventName summarises the output instead, ahead of the submission's CompletionEvent.
+type OutputEvent struct {
+	Event        string `json:"event"`
+	RequestId    string `json:"request_id"`
+	ConnectionId string `json:"connection_id,omitempty"`
+	// Seq numbers a submission's output events from 1, so clients can order them and notice gaps
+	Seq    int    `json:"seq"`
+	Stream Stream `json:"stream,omitempty"`
+	Data   string `json:"data,omitempty"`
+
+	// Chunks, StdoutBytes and StderrBytes count what was streamed, and are only set on the summary
+	Chunks      int   `json:"chunks,omitempty"`
+	StdoutBytes int64 `json:"stdout_bytes,omitempty"`
+	StderrBytes int64 `json:"stderr_bytes,omitempty"`
+}
@@ -0,0 +1,61 @@
+package submissions
+
+import "fmt"
+
+// TestCase is one input of a batch submission. A test case without an expected output is run but not judged.
+type TestCase struct {
+	StdIn          string  `json:"stdin"`
+	ExpectedOutput *string `json:"expected_output,omitempty"`
+}
+
+// Limits bounds the resources a single run of a submission may use. Zero means "use the server default".
+type Limits struct {
+	CpuTimeLimit  float64 `json:"cpu_time_limit,omitempty"`  // seconds
+	WallTimeLimit float64 `json:"wall_time_limit,omitempty"` // seconds
+	MemoryLimit   int64   `json:"memory_limit,omitempty"`    // KB
+	MaxProcesses  int     `json:"max_processes,omitempty"`
+	MaxOutputSize int64   `json:"max_output_size,omitempty"` // KB, stdout and stderr combined
+
+	// CompileTimeLimit bounds the wall time of the compile step, which the other limits do not apply to
+	CompileTimeLimit float64 `json:"compile_time_limit,omitempty"` // seconds
+}
+
+// Sources are the additional files of a multi-file submission, unpacked into its workspace next to
+// the entry file. Files maps a relative path to base64 encoded content; Archive is a base64 encoded zip.
+type Sources struct {
+	Files   map[string]string `json:"files,omitempty"`
+	Archive string            `json:"archive,omitempty"`
+}
+
+// QueueClass selects the queue a submission waits in. Executors take interactive submissions ahead of
+// batch ones by weight, so a large grading batch does not hold up single runs.
+type QueueClass string
+
+const (
+	Interactive QueueClass = "interactive"
+	Batch       QueueClass = "batch"
+)
+
+// QueueClasses lists every queue class, most urgent first
+var QueueClasses = []QueueClass{Interactive, Batch}
+
+// ParseQueueClass validates a queue class, returning fallback when value is empty
+func ParseQueueClass(value string, fallback QueueClass) (QueueClass, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	for _, class := range QueueClasses {
+		if QueueClass(value) == class {
+			return class, nil
+		}
+	}
+	return "", fmt.Errorf("unknown queue class %q, expected %s or %s", value, Interactive, Batch)
+}
+
+// Queue returns the name of the RabbitMQ queue holding the class's submissions
+func (c QueueClass) Queue() string {
+	if c == Batch {
+		return "batch-submissions"
+	}
+	return "submissions"
+}
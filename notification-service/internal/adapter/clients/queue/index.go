@@ -2,6 +2,6 @@ package queue
 
 type IQueueClient interface {
 	Close() error
-	ConsumeMessages(handleMessage func(string)) error
+	ConsumeMessages(handleMessage func(string) error) error
 	PublishMessage(messageBody string) error
 }
@@ -0,0 +1,201 @@
+package router
+
+import (
+	"encoding/json"
+	"go-compiler/common/pkg/events"
+	"go-compiler/models/results"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/streadway/amqp"
+)
+
+// recorder records what is published instead of sending it to RabbitMQ
+type recorder struct {
+	mu        sync.Mutex
+	published []published
+}
+
+type published struct {
+	exchange string
+	key      string
+	body     []byte
+}
+
+func (r *recorder) PublishEvent(exchange string, key string, body []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.published = append(r.published, published{exchange: exchange, key: key, body: body})
+	return nil
+}
+
+// dial opens a WebSocket to a test server running handleWebSocket and reads its greeting
+func dial(t *testing.T, publisher *recorder, connectionID string) *websocket.Conn {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleWebSocket(w, r, publisher)
+	}))
+	t.Cleanup(server.Close)
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?connection_id=" + connectionID
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	var greeting MessageBody
+	if err := conn.ReadJSON(&greeting); err != nil || greeting.Status != "connected" {
+		t.Fatalf("greeting = %+v, %v; want connected", greeting, err)
+	}
+	// The connection is registered right after the greeting was written
+	deadline := time.Now().Add(2 * time.Second)
+	for !registered(connectionID) {
+		if time.Now().After(deadline) {
+			t.Fatal("connection was not registered")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return conn
+}
+
+func registered(connectionID string) bool {
+	connMutex.Lock()
+	defer connMutex.Unlock()
+	_, exists := connections[connectionID]
+	return exists
+}
+
+func TestSendMessageToClientSerializesWrites(t *testing.T) {
+	conn := dial(t, &recorder{}, "burst")
+
+	// A burst of output events is forwarded from several goroutines at once
+	const sent = 50
+	var wg sync.WaitGroup
+	for i := 1; i <= sent; i++ {
+		wg.Add(1)
+		go func(seq int) {
+			defer wg.Done()
+			SendMessageToClient("burst", results.OutputEvent{Event: results.OutputEventName, Seq: seq})
+		}(i)
+	}
+
+	seen := make(map[int]bool)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for len(seen) < sent {
+		var event results.OutputEvent
+		if err := conn.ReadJSON(&event); err != nil {
+			t.Fatalf("reading event %d: %v", len(seen)+1, err)
+		}
+		seen[event.Seq] = true
+	}
+	wg.Wait()
+}
+
+// forwarded waits until the recorder holds n publishes and returns them
+func (r *recorder) forwarded(t *testing.T, n int) []published {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		r.mu.Lock()
+		got := append([]published(nil), r.published...)
+		r.mu.Unlock()
+		if len(got) >= n {
+			return got
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d inputs forwarded, want %d", len(got), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestHandleWebSocketForwardsInput(t *testing.T) {
+	publisher := &recorder{}
+	conn := dial(t, publisher, "typist")
+
+	// Input without a request_id and other messages are dropped; the connection ID the client
+	// claims is replaced by the socket's own
+	messages := []results.InputEvent{
+		{Event: results.InputEventName, Data: "lost\n"},
+		{Event: "ping", RequestId: "request"},
+		{Event: results.InputEventName, RequestId: "request", ConnectionId: "someone-else", Data: "42\n"},
+		{Event: results.InputEndEventName, RequestId: "request"},
+	}
+	for _, message := range messages {
+		if err := conn.WriteJSON(message); err != nil {
+			t.Fatalf("WriteJSON() error = %v", err)
+		}
+	}
+
+	want := []results.InputEvent{
+		{Event: results.InputEventName, RequestId: "request", ConnectionId: "typist", Data: "42\n"},
+		{Event: results.InputEndEventName, RequestId: "request", ConnectionId: "typist"},
+	}
+	got := publisher.forwarded(t, len(want))
+	if len(got) != len(want) {
+		t.Fatalf("forwarded %d inputs, want %d", len(got), len(want))
+	}
+	for i, publish := range got {
+		if publish.exchange != events.Exchange || publish.key != events.InputKey("request") {
+			t.Errorf("input %d published to %s with key %s, want %s with %s", i+1, publish.exchange, publish.key, events.Exchange, events.InputKey("request"))
+		}
+		var event results.InputEvent
+		if err := json.Unmarshal(publish.body, &event); err != nil {
+			t.Fatalf("failed to decode input %d: %v", i+1, err)
+		}
+		if event != want[i] {
+			t.Errorf("input %d = %+v, want %+v", i+1, event, want[i])
+		}
+	}
+}
+
+func TestDecodeEvent(t *testing.T) {
+	tests := []struct {
+		key  string
+		body string
+		want interface{}
+	}{
+		{
+			key:  events.StatusKey,
+			body: `{"event":"status","connection_id":"c","stage":"running"}`,
+			want: results.StatusEvent{},
+		},
+		{
+			key:  events.OutputKey,
+			body: `{"event":"output","connection_id":"c","seq":1,"data":"hi"}`,
+			want: results.OutputEvent{},
+		},
+		{
+			key:  events.CompletedKey,
+			body: `{"event":"completed","connection_id":"c"}`,
+			want: results.CompletionEvent{},
+		},
+		{
+			// Published to the queue directly, as older executors do
+			key:  events.ResultsQueue,
+			body: `{"connection_id":"c"}`,
+			want: results.CompletionEvent{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			connectionID, event, err := decodeEvent(amqp.Delivery{RoutingKey: test.key, Body: []byte(test.body)})
+			if err != nil {
+				t.Fatalf("decodeEvent() error = %v", err)
+			}
+			if connectionID != "c" {
+				t.Errorf("connection ID = %q, want %q", connectionID, "c")
+			}
+			if reflect.TypeOf(event) != reflect.TypeOf(test.want) {
+				t.Errorf("decoded a %T, want a %T", event, test.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"go-compiler/common/pkg/config"
+	"go-compiler/common/pkg/constants"
 	"go-compiler/common/pkg/utils/logger"
 	"go-compiler/notification-service/pkg"
 	"net/http"
@@ -8,15 +10,18 @@ import (
 
 func main() {
 
-	//init logger
+	settings, err := config.Load(constants.NOTIFICATION_SERVICE)
+	if err != nil {
+		logger.GetLogger().Fatal("Failed to load configuration: ", err)
+	}
 
-	StartService()
+	StartService(settings)
 }
 
-func StartService() {
+func StartService(settings *config.Config) {
 	log := logger.GetLogger()
-	r := pkg.GetRouter()
-	port := ":8080"
+	r := pkg.GetRouter(settings)
+	port := settings.Server.HTTPAddr()
 
 	httpServer := &http.Server{
 		Addr:    port,
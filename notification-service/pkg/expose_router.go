@@ -1,12 +1,13 @@
 package pkg
 
 import (
+	"go-compiler/common/pkg/config"
 	"go-compiler/notification-service/internal/port/router"
 
 	"github.com/gin-gonic/gin"
 )
 
-func GetRouter() *gin.Engine {
-	engine := router.NewRouter()
+func GetRouter(settings *config.Config) *gin.Engine {
+	engine := router.NewRouter(settings)
 	return engine
 }
@@ -21,6 +21,8 @@ const _ = grpc.SupportPackageIsVersion9
 const (
 	RequestService_Ping_FullMethodName          = "/example.RequestService/Ping"
 	RequestService_SubmitRequest_FullMethodName = "/example.RequestService/SubmitRequest"
+	RequestService_SubmitBatch_FullMethodName   = "/example.RequestService/SubmitBatch"
+	RequestService_GetLanguages_FullMethodName  = "/example.RequestService/GetLanguages"
 )
 
 // RequestServiceClient is the client API for RequestService service.
@@ -29,6 +31,8 @@ const (
 type RequestServiceClient interface {
 	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
 	SubmitRequest(ctx context.Context, in *SubmissionRequest, opts ...grpc.CallOption) (*SubmissionResponse, error)
+	SubmitBatch(ctx context.Context, in *BatchSubmissionRequest, opts ...grpc.CallOption) (*SubmissionResponse, error)
+	GetLanguages(ctx context.Context, in *LanguagesRequest, opts ...grpc.CallOption) (*LanguagesResponse, error)
 }
 
 type requestServiceClient struct {
@@ -59,12 +63,34 @@ func (c *requestServiceClient) SubmitRequest(ctx context.Context, in *Submission
 	return out, nil
 }
 
+func (c *requestServiceClient) SubmitBatch(ctx context.Context, in *BatchSubmissionRequest, opts ...grpc.CallOption) (*SubmissionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubmissionResponse)
+	err := c.cc.Invoke(ctx, RequestService_SubmitBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *requestServiceClient) GetLanguages(ctx context.Context, in *LanguagesRequest, opts ...grpc.CallOption) (*LanguagesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LanguagesResponse)
+	err := c.cc.Invoke(ctx, RequestService_GetLanguages_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // RequestServiceServer is the server API for RequestService service.
 // All implementations must embed UnimplementedRequestServiceServer
 // for forward compatibility.
 type RequestServiceServer interface {
 	Ping(context.Context, *PingRequest) (*PingResponse, error)
 	SubmitRequest(context.Context, *SubmissionRequest) (*SubmissionResponse, error)
+	SubmitBatch(context.Context, *BatchSubmissionRequest) (*SubmissionResponse, error)
+	GetLanguages(context.Context, *LanguagesRequest) (*LanguagesResponse, error)
 	mustEmbedUnimplementedRequestServiceServer()
 }
 
@@ -81,6 +107,12 @@ func (UnimplementedRequestServiceServer) Ping(context.Context, *PingRequest) (*P
 func (UnimplementedRequestServiceServer) SubmitRequest(context.Context, *SubmissionRequest) (*SubmissionResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SubmitRequest not implemented")
 }
+func (UnimplementedRequestServiceServer) SubmitBatch(context.Context, *BatchSubmissionRequest) (*SubmissionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitBatch not implemented")
+}
+func (UnimplementedRequestServiceServer) GetLanguages(context.Context, *LanguagesRequest) (*LanguagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLanguages not implemented")
+}
 func (UnimplementedRequestServiceServer) mustEmbedUnimplementedRequestServiceServer() {}
 func (UnimplementedRequestServiceServer) testEmbeddedByValue()                        {}
 
@@ -138,6 +170,42 @@ func _RequestService_SubmitRequest_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _RequestService_SubmitBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchSubmissionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RequestServiceServer).SubmitBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RequestService_SubmitBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RequestServiceServer).SubmitBatch(ctx, req.(*BatchSubmissionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RequestService_GetLanguages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LanguagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RequestServiceServer).GetLanguages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RequestService_GetLanguages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RequestServiceServer).GetLanguages(ctx, req.(*LanguagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // RequestService_ServiceDesc is the grpc.ServiceDesc for RequestService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -153,6 +221,14 @@ var RequestService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SubmitRequest",
 			Handler:    _RequestService_SubmitRequest_Handler,
 		},
+		{
+			MethodName: "SubmitBatch",
+			Handler:    _RequestService_SubmitBatch_Handler,
+		},
+		{
+			MethodName: "GetLanguages",
+			Handler:    _RequestService_GetLanguages_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "index.proto",
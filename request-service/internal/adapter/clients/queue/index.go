@@ -1,6 +1,8 @@
 package queue
 
 import (
+	"go-compiler/common/pkg/broker"
+
 	"github.com/streadway/amqp"
 )
 
@@ -8,13 +10,16 @@ import (
 type IQueueClient interface {
 	Connect(url string) error
 	SendMessage(queueName string, message []byte) error
+	PublishEvent(exchange string, key string, body []byte) error
 	ReceiveMessage(queueName string) (<-chan amqp.Delivery, error)
+	ListDeadLetters(queueName string, limit int) ([]broker.DeadLetter, error)
+	GetDeadLetter(queueName string, id string) (broker.DeadLetter, bool, error)
+	ReplayDeadLetter(queueName string, id string) (bool, error)
 }
 
-// QueueClient implements the IQueueClient interface for RabbitMQ
+// QueueClient implements the IQueueClient interface for RabbitMQ on the shared reconnecting client
 type QueueClient struct {
-	connection *amqp.Connection
-	channel    *amqp.Channel
+	client *broker.Client
 }
 
 // NewQueueClient creates a new instance of QueueClient
@@ -22,84 +27,47 @@ func NewQueueClient() *QueueClient {
 	return &QueueClient{}
 }
 
-// Connect establishes a connection to the RabbitMQ server
+// Connect starts connecting to the RabbitMQ server. The client keeps reconnecting in the
+// background, so an unavailable server delays sending instead of failing it.
 func (qc *QueueClient) Connect(url string) error {
-	var err error
-	qc.connection, err = amqp.Dial(url)
-	if err != nil {
-		return err
-	}
-
-	qc.channel, err = qc.connection.Channel()
-	if err != nil {
-		return err
-	}
-
+	qc.client = broker.NewClient(url)
 	return nil
 }
 
-// SendMessage sends a message to the specified queue
+// SendMessage sends a message to the specified queue and waits until RabbitMQ confirms it
 func (qc *QueueClient) SendMessage(queueName string, message []byte) error {
-	_, err := qc.channel.QueueDeclare(
-		queueName,
-		true,  // durable
-		false, // delete when unused
-		false, // exclusive
-		false, // no-wait
-		nil,   // arguments
-	)
-	if err != nil {
-		return err
-	}
+	return qc.client.SendMessage(queueName, message)
+}
 
-	err = qc.channel.Publish(
-		"",        // exchange
-		queueName, // routing key
-		false,     // mandatory
-		false,     // immediate
-		amqp.Publishing{
-			ContentType: "text/plain",
-			Body:        message,
-		})
-	return err
+// PublishEvent publishes an event to the exchange and waits until RabbitMQ confirms it
+func (qc *QueueClient) PublishEvent(exchange string, key string, body []byte) error {
+	return qc.client.PublishEvent(exchange, key, body)
 }
 
-// ReceiveMessage receives messages from the specified queue
+// ReceiveMessage receives messages from the specified queue. Deliveries are not acked
+// automatically; the caller acknowledges each one, for example with broker.Settle.
 func (qc *QueueClient) ReceiveMessage(queueName string) (<-chan amqp.Delivery, error) {
-	_, err := qc.channel.QueueDeclare(
-		queueName,
-		true,  // durable
-		false, // delete when unused
-		false, // exclusive
-		false, // no-wait
-		nil,   // arguments
-	)
-	if err != nil {
-		return nil, err
-	}
+	return qc.client.Consume(queueName, "", broker.DefaultPrefetch)
+}
 
-	msgs, err := qc.channel.Consume(
-		queueName,
-		"",    // consumer
-		true,  // auto-ack
-		false, // exclusive
-		false, // no-local
-		false, // no-wait
-		nil,   // args
-	)
-	if err != nil {
-		return nil, err
-	}
+// ListDeadLetters returns up to limit messages rejected from the queue, leaving them in place
+func (qc *QueueClient) ListDeadLetters(queueName string, limit int) ([]broker.DeadLetter, error) {
+	return qc.client.ListDeadLetters(queueName, limit)
+}
 
-	return msgs, nil
+// GetDeadLetter finds a message rejected from the queue by its ID
+func (qc *QueueClient) GetDeadLetter(queueName string, id string) (broker.DeadLetter, bool, error) {
+	return qc.client.GetDeadLetter(queueName, id)
 }
 
-// Close closes the connection and channel
+// ReplayDeadLetter moves a rejected message back to the queue
+func (qc *QueueClient) ReplayDeadLetter(queueName string, id string) (bool, error) {
+	return qc.client.ReplayDeadLetter(queueName, id)
+}
+
+// Close closes the connection
 func (qc *QueueClient) Close() {
-	if qc.channel != nil {
-		qc.channel.Close()
-	}
-	if qc.connection != nil {
-		qc.connection.Close()
+	if qc.client != nil {
+		qc.client.Close()
 	}
 }
@@ -1,9 +1,141 @@
 package request
 
+import (
+	"fmt"
+	"go-compiler/common/pkg/judge"
+	"go-compiler/common/pkg/limits"
+	"go-compiler/common/pkg/workspace"
+	"go-compiler/models/submissions"
+)
+
+const (
+	// MaxTestCases bounds how many test cases one batch submission may carry
+	MaxTestCases = 100
+	// MaxTenantLength bounds the optional tenant a submission is recorded under
+	MaxTenantLength = 128
+)
+
 type NewExecutionRequest struct {
-	Id         string `json:"id"`
-	Code       string `json:"code"`
-	LanguageId int64  `json:"language_id"`
-	RequestId  string `json:"request_id"`
-	StdIn      string `json:"stdin"`
+	Id              string                 `json:"id"`
+	Code            string                 `json:"code"`
+	LanguageId      int64                  `json:"language_id"`
+	RequestId       string                 `json:"request_id"`
+	ConnectionId    string                 `json:"connection_id,omitempty"`
+	Interactive     bool                   `json:"interactive,omitempty"`
+	StdIn           string                 `json:"stdin"`
+	ExpectedOutput  *string                `json:"expected_output,omitempty"`
+	CompareMode     string                 `json:"compare_mode,omitempty"`
+	FloatTolerance  float64                `json:"float_tolerance,omitempty"`
+	CompilerOptions []string               `json:"compiler_options,omitempty"`
+	TestCases       []submissions.TestCase `json:"test_cases,omitempty"`
+	Tenant          string                 `json:"tenant,omitempty"`
+	// QueueClass is "interactive" (the default) or "batch"; submissions with test cases default to "batch"
+	QueueClass string `json:"queue_class,omitempty"`
+	submissions.Limits
+	submissions.Sources
+}
+
+type NewBatchExecutionRequest struct {
+	Code            string                 `json:"code"`
+	LanguageId      int64                  `json:"language_id"`
+	RequestId       string                 `json:"request_id"`
+	ConnectionId    string                 `json:"connection_id,omitempty"`
+	CompareMode     string                 `json:"compare_mode,omitempty"`
+	FloatTolerance  float64                `json:"float_tolerance,omitempty"`
+	TestCases       []submissions.TestCase `json:"test_cases"`
+	CompilerOptions []string               `json:"compiler_options,omitempty"`
+	Tenant          string                 `json:"tenant,omitempty"`
+	// QueueClass is "batch" (the default) or "interactive"
+	QueueClass string `json:"queue_class,omitempty"`
+	submissions.Limits
+	submissions.Sources
+}
+
+// Validate checks the fields the executors cannot recover from
+func (r NewExecutionRequest) Validate() error {
+	_, err := judge.ParseMode(r.CompareMode)
+	if err != nil {
+		return err
+	}
+	err = judge.CheckTolerance(r.FloatTolerance)
+	if err != nil {
+		return err
+	}
+	_, err = limits.Resolve(r.Limits)
+	if err != nil {
+		return err
+	}
+	files, err := workspace.Decode(r.Sources)
+	if err != nil {
+		return err
+	}
+	if r.Code == "" && len(files) == 0 {
+		return fmt.Errorf("code or files are required")
+	}
+	if len(r.Tenant) > MaxTenantLength {
+		return fmt.Errorf("tenant is longer than %d characters", MaxTenantLength)
+	}
+	if len(r.TestCases) > MaxTestCases {
+		return fmt.Errorf("too many test cases: %d, maximum is %d", len(r.TestCases), MaxTestCases)
+	}
+	_, err = submissions.ParseQueueClass(r.QueueClass, r.defaultClass())
+	if err != nil {
+		return err
+	}
+	// Input reaches the program through the WebSocket the output goes to
+	if r.Interactive && r.ConnectionId == "" {
+		return fmt.Errorf("interactive submissions require a connection_id")
+	}
+	if r.Interactive && len(r.TestCases) > 0 {
+		return fmt.Errorf("interactive submissions cannot have test cases")
+	}
+	return nil
+}
+
+// Class returns the queue class the submission is queued in
+func (r NewExecutionRequest) Class() submissions.QueueClass {
+	class, err := submissions.ParseQueueClass(r.QueueClass, r.defaultClass())
+	if err != nil {
+		return r.defaultClass()
+	}
+	return class
+}
+
+// defaultClass queues submissions carrying test cases as batch submissions, whichever endpoint they came through
+func (r NewExecutionRequest) defaultClass() submissions.QueueClass {
+	if len(r.TestCases) > 0 {
+		return submissions.Batch
+	}
+	return submissions.Interactive
+}
+
+// Validate checks the compare mode and the number of test cases
+func (r NewBatchExecutionRequest) Validate() error {
+	if len(r.TestCases) == 0 {
+		return fmt.Errorf("at least one test case is required")
+	}
+	return r.ToExecutionRequest().Validate()
+}
+
+// ToExecutionRequest converts the batch into the payload queued for the executors. Batches are
+// queued as batch submissions unless they ask otherwise.
+func (r NewBatchExecutionRequest) ToExecutionRequest() NewExecutionRequest {
+	queueClass := r.QueueClass
+	if queueClass == "" {
+		queueClass = string(submissions.Batch)
+	}
+	return NewExecutionRequest{
+		Code:            r.Code,
+		LanguageId:      r.LanguageId,
+		RequestId:       r.RequestId,
+		ConnectionId:    r.ConnectionId,
+		CompareMode:     r.CompareMode,
+		FloatTolerance:  r.FloatTolerance,
+		TestCases:       r.TestCases,
+		CompilerOptions: r.CompilerOptions,
+		Tenant:          r.Tenant,
+		QueueClass:      queueClass,
+		Limits:          r.Limits,
+		Sources:         r.Sources,
+	}
 }
@@ -0,0 +1,72 @@
+package request
+
+import (
+	"go-compiler/models/submissions"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(r *NewExecutionRequest)
+		wantErr string
+	}{
+		{name: "valid", change: func(r *NewExecutionRequest) {}},
+		{name: "float tolerance", change: func(r *NewExecutionRequest) { r.CompareMode, r.FloatTolerance = "float_tolerance", 1e-3 }},
+		{name: "unknown compare mode", change: func(r *NewExecutionRequest) { r.CompareMode = "fuzzy" }, wantErr: "compare mode"},
+		{name: "negative float tolerance", change: func(r *NewExecutionRequest) { r.FloatTolerance = -1e-3 }, wantErr: "float_tolerance"},
+		{name: "limit above the maximum", change: func(r *NewExecutionRequest) { r.WallTimeLimit = 1e6 }, wantErr: "wall_time_limit"},
+		{name: "no code", change: func(r *NewExecutionRequest) { r.Code = "" }, wantErr: "code or files"},
+		{name: "files without code", change: func(r *NewExecutionRequest) { r.Code, r.Files = "", map[string]string{"main.py": "cHJpbnQoMSk="} }},
+		{name: "file outside the workspace", change: func(r *NewExecutionRequest) { r.Files = map[string]string{"../x": ""} }, wantErr: "outside the workspace"},
+		{name: "long tenant", change: func(r *NewExecutionRequest) { r.Tenant = strings.Repeat("t", MaxTenantLength+1) }, wantErr: "tenant"},
+		{name: "too many test cases", change: func(r *NewExecutionRequest) { r.TestCases = make([]submissions.TestCase, MaxTestCases+1) }, wantErr: "too many test cases"},
+		{name: "unknown queue class", change: func(r *NewExecutionRequest) { r.QueueClass = "bulk" }, wantErr: "queue class"},
+		{name: "interactive without connection", change: func(r *NewExecutionRequest) { r.Interactive = true }, wantErr: "connection_id"},
+		{
+			name: "interactive with test cases",
+			change: func(r *NewExecutionRequest) {
+				r.Interactive, r.ConnectionId, r.TestCases = true, "connection", make([]submissions.TestCase, 1)
+			},
+			wantErr: "test cases",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := NewExecutionRequest{Code: "cHJpbnQoMSk=", LanguageId: 1, RequestId: "request"}
+			test.change(&request)
+			err := request.Validate()
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("Validate error = %v, want one containing %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestBatchValidate(t *testing.T) {
+	batch := NewBatchExecutionRequest{Code: "cHJpbnQoMSk=", LanguageId: 1, RequestId: "request"}
+	if err := batch.Validate(); err == nil {
+		t.Error("Validate accepted a batch without test cases")
+	}
+
+	batch.TestCases = make([]submissions.TestCase, 2)
+	batch.FloatTolerance = -1
+	if err := batch.Validate(); err == nil || !strings.Contains(err.Error(), "float_tolerance") {
+		t.Errorf("Validate error = %v, want the negative float_tolerance rejected", err)
+	}
+
+	batch.FloatTolerance = 0
+	if err := batch.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if class := batch.ToExecutionRequest().Class(); class != submissions.Batch {
+		t.Errorf("batch queued as %s, want %s", class, submissions.Batch)
+	}
+}
@@ -0,0 +1,22 @@
+package response
+
+import "time"
+
+type LanguageResponse struct {
+	LanguageId      int64    `json:"language_id"`
+	Name            string   `json:"name"`
+	Version         string   `json:"version"`
+	IsCompiled      bool     `json:"is_compiled"`
+	CompilerOptions []string `json:"compiler_options,omitempty"`
+}
+
+type DeadLetterResponse struct {
+	Id        string    `json:"id"`
+	Queue     string    `json:"queue"`
+	RequestId string    `json:"request_id,omitempty"`
+	Reason    string    `json:"reason"`
+	FailedAt  time.Time `json:"failed_at"`
+	Retries   int       `json:"retries"`
+	// Payload is the message body, only returned when a single dead letter is inspected
+	Payload string `json:"payload,omitempty"`
+}
@@ -0,0 +1,102 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"go-compiler/common/pkg/broker"
+	"go-compiler/common/pkg/utils/logger"
+	"go-compiler/models/submissions"
+	"go-compiler/request-service/internal/adapter/clients/queue"
+	"go-compiler/request-service/internal/domain/dto/response"
+)
+
+const (
+	DefaultDeadLetterLimit = 50
+	MaxDeadLetterLimit     = 500
+)
+
+type DeadLetterService struct {
+	QueueClient queue.IQueueClient
+}
+
+func NewDeadLetterService(qc queue.IQueueClient) *DeadLetterService {
+	return &DeadLetterService{
+		QueueClient: qc,
+	}
+}
+
+// ListDeadLetters returns the oldest dead-lettered submissions of the class, without their payloads
+func (s *DeadLetterService) ListDeadLetters(ctx context.Context, class submissions.QueueClass, limit int) ([]response.DeadLetterResponse, error) {
+	log := logger.GetLogger(ctx)
+	methodName := "ListDeadLetters"
+	log.Info("Entering", "methodName", methodName)
+
+	if limit <= 0 {
+		limit = DefaultDeadLetterLimit
+	}
+	limit = min(limit, MaxDeadLetterLimit)
+
+	deadLetters, err := s.QueueClient.ListDeadLetters(class.Queue(), limit)
+	if err != nil {
+		log.Error("Error listing dead letters", "error", err.Error())
+		return nil, err
+	}
+	resp := make([]response.DeadLetterResponse, 0, len(deadLetters))
+	for _, deadLetter := range deadLetters {
+		resp = append(resp, toDeadLetterResponse(deadLetter))
+	}
+	return resp, nil
+}
+
+// GetDeadLetter returns a dead-lettered submission with its payload, or nil when there is none with the ID
+func (s *DeadLetterService) GetDeadLetter(ctx context.Context, class submissions.QueueClass, id string) (*response.DeadLetterResponse, error) {
+	log := logger.GetLogger(ctx)
+	methodName := "GetDeadLetter"
+	log.Info("Entering", "methodName", methodName)
+
+	deadLetter, found, err := s.QueueClient.GetDeadLetter(class.Queue(), id)
+	if err != nil {
+		log.Error("Error getting dead letter", "error", err.Error())
+		return nil, err
+	}
+	if !found {
+		return nil, nil
+	}
+	resp := toDeadLetterResponse(deadLetter)
+	resp.Payload = string(deadLetter.Body)
+	return &resp, nil
+}
+
+// ReplayDeadLetter queues a dead-lettered submission again and reports whether it was found
+func (s *DeadLetterService) ReplayDeadLetter(ctx context.Context, class submissions.QueueClass, id string) (bool, error) {
+	log := logger.GetLogger(ctx)
+	methodName := "ReplayDeadLetter"
+	log.Info("Entering", "methodName", methodName)
+
+	replayed, err := s.QueueClient.ReplayDeadLetter(class.Queue(), id)
+	if err != nil {
+		log.Error("Error replaying dead letter", "error", err.Error())
+		return false, err
+	}
+	if replayed {
+		log.Info("Replayed dead letter", "id", id)
+	}
+	return replayed, nil
+}
+
+func toDeadLetterResponse(deadLetter broker.DeadLetter) response.DeadLetterResponse {
+	// Payloads that fail to decode are dead-lettered too, so the request ID is best effort
+	var payload struct {
+		RequestId string `json:"request_id"`
+	}
+	_ = json.Unmarshal(deadLetter.Body, &payload)
+
+	return response.DeadLetterResponse{
+		Id:        deadLetter.Id,
+		Queue:     deadLetter.Queue,
+		RequestId: payload.RequestId,
+		Reason:    deadLetter.Reason,
+		FailedAt:  deadLetter.FailedAt,
+		Retries:   deadLetter.Retries,
+	}
+}
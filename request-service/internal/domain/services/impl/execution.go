@@ -3,16 +3,20 @@ package impl
 import (
 	"context"
 	"encoding/json"
+	"go-compiler/common/pkg/events"
+	"go-compiler/common/pkg/utils"
 	"go-compiler/common/pkg/utils/logger"
+	"go-compiler/models/results"
 	"go-compiler/request-service/internal/adapter/clients/queue"
 	"go-compiler/request-service/internal/domain/dto/request"
 )
 
 type ExecutionRequestService struct {
 	QueueClient queue.IQueueClient
+	reporter    *events.Reporter
 }
 
-func NewExecutionRequestService(qc queue.IQueueClient, url string) *ExecutionRequestService {
+func NewExecutionRequestService(qc queue.IQueueClient, url string, cache utils.ICacheClient) *ExecutionRequestService {
 	er := qc.Connect(url)
 	log := logger.GetLogger(context.Background())
 	if er != nil {
@@ -21,6 +25,7 @@ func NewExecutionRequestService(qc queue.IQueueClient, url string) *ExecutionReq
 	}
 	return &ExecutionRequestService{
 		QueueClient: qc,
+		reporter:    events.NewReporter(qc, cache),
 	}
 }
 
@@ -35,7 +40,15 @@ func (s *ExecutionRequestService) ProcessRequest(ctx context.Context, payload re
 		return err
 	}
 
-	err = s.QueueClient.SendMessage("submissions", payloadBytes)
+	// Recorded before queueing, so an executor taking the submission at once cannot be overtaken.
+	// The submission is queued even when its status cannot be recorded
+	err = s.reporter.Status(payload.RequestId, payload.ConnectionId, results.Queued)
+	if err != nil {
+		log.Error("Error reporting queued status", "error", err.Error())
+	}
+
+	// Interactive and batch submissions wait in separate queues, so executors can favour interactive ones
+	err = s.QueueClient.SendMessage(payload.Class().Queue(), payloadBytes)
 	if err != nil {
 		log.Error("Error sending message to queue", "error", err.Error())
 		return err
@@ -0,0 +1,48 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"go-compiler/common/pkg/registry"
+	"go-compiler/common/pkg/utils/logger"
+	"go-compiler/request-service/internal/domain/dto/response"
+)
+
+type LanguageService struct {
+	languages registry.ILanguageRegistry
+}
+
+func NewLanguageService(lr registry.ILanguageRegistry) *LanguageService {
+	return &LanguageService{
+		languages: lr,
+	}
+}
+
+// GetLanguages lists every language the executors can run
+func (s *LanguageService) GetLanguages(ctx context.Context) []response.LanguageResponse {
+	log := logger.GetLogger(ctx)
+	methodName := "GetLanguages"
+	log.Info("Entering", "methodName", methodName)
+
+	languages := s.languages.List()
+	result := make([]response.LanguageResponse, 0, len(languages))
+	for _, language := range languages {
+		result = append(result, response.LanguageResponse{
+			LanguageId:      language.LanguageId,
+			Name:            language.Name,
+			Version:         language.Version,
+			IsCompiled:      language.IsCompiled(),
+			CompilerOptions: language.CompilerOptions,
+		})
+	}
+	return result
+}
+
+// ValidateLanguage rejects unknown languages and compiler options the language does not allow
+func (s *LanguageService) ValidateLanguage(ctx context.Context, languageId int64, compilerOptions []string) error {
+	language, found := s.languages.Get(languageId)
+	if !found {
+		return fmt.Errorf("unsupported language id %d", languageId)
+	}
+	return language.ValidateOptions(compilerOptions)
+}
@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"context"
+	"go-compiler/models/submissions"
+	"go-compiler/request-service/internal/domain/dto/response"
+)
+
+type IDeadLetterService interface {
+	ListDeadLetters(ctx context.Context, class submissions.QueueClass, limit int) ([]response.DeadLetterResponse, error)
+	GetDeadLetter(ctx context.Context, class submissions.QueueClass, id string) (*response.DeadLetterResponse, error)
+	ReplayDeadLetter(ctx context.Context, class submissions.QueueClass, id string) (bool, error)
+}
@@ -0,0 +1,11 @@
+package interfaces
+
+import (
+	"context"
+	"go-compiler/request-service/internal/domain/dto/response"
+)
+
+type ILanguageService interface {
+	GetLanguages(ctx context.Context) []response.LanguageResponse
+	ValidateLanguage(ctx context.Context, languageId int64, compilerOptions []string) error
+}
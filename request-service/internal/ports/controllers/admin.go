@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"go-compiler/common/pkg/utils/logger"
+	"go-compiler/models/submissions"
+	"go-compiler/request-service/internal/domain/services/interfaces"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminController struct {
+	DeadLetterService interfaces.IDeadLetterService
+}
+
+func NewAdminController(ds interfaces.IDeadLetterService) *AdminController {
+	return &AdminController{
+		DeadLetterService: ds,
+	}
+}
+
+func (ac *AdminController) ListDeadLetters() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		log := logger.GetLogger(ctx)
+		methodName := "ListDeadLetters"
+		log.Info("Entering", "methodName", methodName)
+
+		class, err := submissions.ParseQueueClass(ctx.Query("queue_class"), submissions.Interactive)
+		if err != nil {
+			ctx.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		limit := 0
+		if value := ctx.Query("limit"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed <= 0 {
+				ctx.JSON(400, gin.H{"error": "limit must be a positive number"})
+				return
+			}
+			limit = parsed
+		}
+
+		deadLetters, domainError := ac.DeadLetterService.ListDeadLetters(ctx, class, limit)
+		if domainError != nil {
+			log.Error("Error in processing request", "error", domainError.Error())
+			ctx.JSON(500, gin.H{"error": domainError.Error()})
+			return
+		}
+
+		ctx.JSON(200, gin.H{"dead_letters": deadLetters})
+	}
+}
+
+func (ac *AdminController) GetDeadLetter() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		log := logger.GetLogger(ctx)
+		methodName := "GetDeadLetter"
+		log.Info("Entering", "methodName", methodName)
+
+		class, err := submissions.ParseQueueClass(ctx.Query("queue_class"), submissions.Interactive)
+		if err != nil {
+			ctx.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		deadLetter, domainError := ac.DeadLetterService.GetDeadLetter(ctx, class, ctx.Param("id"))
+		if domainError != nil {
+			log.Error("Error in processing request", "error", domainError.Error())
+			ctx.JSON(500, gin.H{"error": domainError.Error()})
+			return
+		}
+		if deadLetter == nil {
+			ctx.JSON(404, gin.H{"error": "dead letter not found"})
+			return
+		}
+
+		ctx.JSON(200, deadLetter)
+	}
+}
+
+func (ac *AdminController) ReplayDeadLetter() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		log := logger.GetLogger(ctx)
+		methodName := "ReplayDeadLetter"
+		log.Info("Entering", "methodName", methodName)
+
+		class, err := submissions.ParseQueueClass(ctx.Query("queue_class"), submissions.Interactive)
+		if err != nil {
+			ctx.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		replayed, domainError := ac.DeadLetterService.ReplayDeadLetter(ctx, class, ctx.Param("id"))
+		if domainError != nil {
+			log.Error("Error in processing request", "error", domainError.Error())
+			ctx.JSON(500, gin.H{"error": domainError.Error()})
+			return
+		}
+		if !replayed {
+			ctx.JSON(404, gin.H{"error": "dead letter not found"})
+			return
+		}
+
+		ctx.JSON(200, gin.H{"message": "Dead letter replayed successfully"})
+	}
+}
@@ -3,21 +3,26 @@ package controllers
 import (
 	"context"
 	"go-compiler/common/pkg/utils/logger"
+	"go-compiler/models/submissions"
 	pb "go-compiler/request-service/generated/go-compiler/generated/requestpb"
 	"go-compiler/request-service/internal/domain/dto/request"
 	"go-compiler/request-service/internal/domain/services/interfaces"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type RequestController struct {
 	ExecutionService interfaces.IExecutionService
+	LanguageService  interfaces.ILanguageService
 }
 
-func NewRequestController(es interfaces.IExecutionService) *RequestController {
+func NewRequestController(es interfaces.IExecutionService, ls interfaces.ILanguageService) *RequestController {
 	return &RequestController{
 		ExecutionService: es,
+		LanguageService:  ls,
 	}
 }
 
@@ -37,6 +42,16 @@ func (rc *RequestController) GetRequest() gin.HandlerFunc {
 			return
 		}
 
+		e = Payload.Validate()
+		if e == nil {
+			e = rc.LanguageService.ValidateLanguage(ctx, Payload.LanguageId, Payload.CompilerOptions)
+		}
+		if e != nil {
+			log.Error("Error in validating request", "error", e.Error())
+			ctx.JSON(400, gin.H{"error": e.Error()})
+			return
+		}
+
 		domainError := rc.ExecutionService.ProcessRequest(ctx, Payload)
 		if domainError != nil {
 			log.Error("Error in processing request", "error", domainError.Error())
@@ -46,7 +61,7 @@ func (rc *RequestController) GetRequest() gin.HandlerFunc {
 
 		log.Info("Request submitted to RabbitMQ at: %v", time.Since(start))
 
-		ctx.JSON(200, gin.H{"message": "Request processed successfully"})
+		ctx.JSON(200, gin.H{"message": "Request processed successfully", "queue_class": Payload.Class()})
 	}
 }
 
@@ -59,10 +74,29 @@ func (rc *RequestController) SubmitRequest(ctx context.Context, req *pb.Submissi
 
 	// Convert the gRPC request to the internal DTO
 	Payload := request.NewExecutionRequest{
-		Code:       req.Code,
-		StdIn:      req.Stdin,
-		RequestId:  req.RequestId,
-		LanguageId: req.LanguageId,
+		Code:            req.Code,
+		StdIn:           req.Stdin,
+		RequestId:       req.RequestId,
+		ConnectionId:    req.ConnectionId,
+		Interactive:     req.Interactive,
+		LanguageId:      req.LanguageId,
+		ExpectedOutput:  req.ExpectedOutput,
+		CompareMode:     req.CompareMode,
+		FloatTolerance:  req.FloatTolerance,
+		Limits:          toLimits(req.Limits),
+		Sources:         toSources(req.Sources),
+		CompilerOptions: req.CompilerOptions,
+		Tenant:          req.Tenant,
+		QueueClass:      req.QueueClass,
+	}
+
+	err := Payload.Validate()
+	if err == nil {
+		err = rc.LanguageService.ValidateLanguage(ctx, Payload.LanguageId, Payload.CompilerOptions)
+	}
+	if err != nil {
+		log.Error("Error in validating request", "error", err.Error())
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
 	// Process the request
@@ -73,5 +107,118 @@ func (rc *RequestController) SubmitRequest(ctx context.Context, req *pb.Submissi
 	}
 
 	log.Info("Request submitted to RabbitMQ at: %v", time.Since(start))
-	return &pb.SubmissionResponse{Result: "Request processed successfully"}, nil
+	return &pb.SubmissionResponse{Result: "Request processed successfully", QueueClass: string(Payload.Class())}, nil
+}
+
+func (rc *RequestController) GetBatchRequest() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		log := logger.GetLogger(ctx)
+		methodName := "GetBatchRequest"
+		log.Info("Entering", "methodName", methodName)
+		start := time.Now()
+
+		var Payload request.NewBatchExecutionRequest
+		e := ctx.BindJSON(&Payload)
+		if e != nil {
+			log.Error("Error in binding request", "error", e.Error())
+			ctx.JSON(400, gin.H{"error": e.Error()})
+			return
+		}
+
+		e = Payload.Validate()
+		if e == nil {
+			e = rc.LanguageService.ValidateLanguage(ctx, Payload.LanguageId, Payload.CompilerOptions)
+		}
+		if e != nil {
+			log.Error("Error in validating request", "error", e.Error())
+			ctx.JSON(400, gin.H{"error": e.Error()})
+			return
+		}
+
+		executionRequest := Payload.ToExecutionRequest()
+		domainError := rc.ExecutionService.ProcessRequest(ctx, executionRequest)
+		if domainError != nil {
+			log.Error("Error in processing request", "error", domainError.Error())
+			ctx.JSON(500, gin.H{"error": domainError.Error()})
+			return
+		}
+
+		log.Info("Batch request submitted to RabbitMQ", "test_cases", len(Payload.TestCases), "time_taken", time.Since(start))
+
+		ctx.JSON(200, gin.H{"message": "Request processed successfully", "queue_class": executionRequest.Class()})
+	}
+}
+
+func (rc *RequestController) SubmitBatch(ctx context.Context, req *pb.BatchSubmissionRequest) (*pb.SubmissionResponse, error) {
+	log := logger.GetLogger(ctx)
+	methodName := "SubmitBatch"
+	log.Info("Entering", "methodName", methodName)
+	start := time.Now()
+
+	// Convert the gRPC request to the internal DTO
+	Payload := request.NewBatchExecutionRequest{
+		Code:            req.Code,
+		LanguageId:      req.LanguageId,
+		RequestId:       req.RequestId,
+		ConnectionId:    req.ConnectionId,
+		CompareMode:     req.CompareMode,
+		FloatTolerance:  req.FloatTolerance,
+		TestCases:       make([]submissions.TestCase, 0, len(req.TestCases)),
+		Limits:          toLimits(req.Limits),
+		Sources:         toSources(req.Sources),
+		CompilerOptions: req.CompilerOptions,
+		Tenant:          req.Tenant,
+		QueueClass:      req.QueueClass,
+	}
+	for _, testCase := range req.TestCases {
+		Payload.TestCases = append(Payload.TestCases, submissions.TestCase{
+			StdIn:          testCase.Stdin,
+			ExpectedOutput: testCase.ExpectedOutput,
+		})
+	}
+
+	err := Payload.Validate()
+	if err == nil {
+		err = rc.LanguageService.ValidateLanguage(ctx, Payload.LanguageId, Payload.CompilerOptions)
+	}
+	if err != nil {
+		log.Error("Error in validating request", "error", err.Error())
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	executionRequest := Payload.ToExecutionRequest()
+	domainError := rc.ExecutionService.ProcessRequest(ctx, executionRequest)
+	if domainError != nil {
+		log.Error("Error in processing request", "error", domainError.Error())
+		return nil, domainError
+	}
+
+	log.Info("Batch request submitted to RabbitMQ", "test_cases", len(Payload.TestCases), "time_taken", time.Since(start))
+	return &pb.SubmissionResponse{Result: "Request processed successfully", QueueClass: string(executionRequest.Class())}, nil
+}
+
+// toLimits converts the optional gRPC limits message to the internal model
+func toLimits(limits *pb.Limits) submissions.Limits {
+	if limits == nil {
+		return submissions.Limits{}
+	}
+	return submissions.Limits{
+		CpuTimeLimit:     limits.CpuTimeLimit,
+		WallTimeLimit:    limits.WallTimeLimit,
+		MemoryLimit:      limits.MemoryLimit,
+		MaxProcesses:     int(limits.MaxProcesses),
+		MaxOutputSize:    limits.MaxOutputSize,
+		CompileTimeLimit: limits.CompileTimeLimit,
+	}
+}
+
+// toSources converts the optional gRPC sources message to the internal model
+func toSources(sources *pb.Sources) submissions.Sources {
+	if sources == nil {
+		return submissions.Sources{}
+	}
+	return submissions.Sources{
+		Files:   sources.Files,
+		Archive: sources.Archive,
+	}
 }
@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"context"
+	"go-compiler/models/submissions"
+	pb "go-compiler/request-service/generated/go-compiler/generated/requestpb"
+	"go-compiler/request-service/internal/domain/dto/request"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// queue records the requests that would be sent to RabbitMQ
+type queue struct {
+	processed []request.NewExecutionRequest
+}
+
+func (q *queue) ProcessRequest(ctx context.Context, payload request.NewExecutionRequest) error {
+	q.processed = append(q.processed, payload)
+	return nil
+}
+
+func expected(output string) *string {
+	return &output
+}
+
+func TestSubmitBatch(t *testing.T) {
+	valid := func() *pb.BatchSubmissionRequest {
+		return &pb.BatchSubmissionRequest{
+			Code:       "cHJpbnQoMSk=",
+			LanguageId: 1,
+			RequestId:  "request",
+			TestCases: []*pb.TestCase{
+				{Stdin: "1", ExpectedOutput: expected("1")},
+				{Stdin: "2"},
+			},
+		}
+	}
+	tests := []struct {
+		name    string
+		change  func(r *pb.BatchSubmissionRequest)
+		wantErr string
+	}{
+		{name: "valid", change: func(r *pb.BatchSubmissionRequest) {}},
+		{name: "no test cases", change: func(r *pb.BatchSubmissionRequest) { r.TestCases = nil }, wantErr: "test case"},
+		{name: "unknown language", change: func(r *pb.BatchSubmissionRequest) { r.LanguageId = 99 }, wantErr: "unsupported language"},
+		{name: "negative float tolerance", change: func(r *pb.BatchSubmissionRequest) { r.FloatTolerance = -1 }, wantErr: "float_tolerance"},
+		{name: "unknown compare mode", change: func(r *pb.BatchSubmissionRequest) { r.CompareMode = "fuzzy" }, wantErr: "compare mode"},
+		{name: "limit above the maximum", change: func(r *pb.BatchSubmissionRequest) { r.Limits = &pb.Limits{WallTimeLimit: 1e6} }, wantErr: "wall_time_limit"},
+		{name: "compiler options for Python", change: func(r *pb.BatchSubmissionRequest) { r.CompilerOptions = []string{"-O2"} }, wantErr: "compiler options"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			executions := &queue{}
+			controller := NewRequestController(executions, newLanguageService(t))
+			req := valid()
+			test.change(req)
+
+			resp, err := controller.SubmitBatch(context.Background(), req)
+			if test.wantErr != "" {
+				if status.Code(err) != codes.InvalidArgument || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("SubmitBatch() error = %v, want an invalid argument containing %q", err, test.wantErr)
+				}
+				if len(executions.processed) != 0 {
+					t.Error("a rejected batch was queued")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SubmitBatch() error = %v", err)
+			}
+			if resp.QueueClass != string(submissions.Batch) || len(executions.processed) != 1 {
+				t.Fatalf("SubmitBatch() = %v with %d queued, want one batch queued", resp, len(executions.processed))
+			}
+			queued := executions.processed[0]
+			if len(queued.TestCases) != 2 || queued.TestCases[0].StdIn != "1" || *queued.TestCases[0].ExpectedOutput != "1" || queued.TestCases[1].ExpectedOutput != nil {
+				t.Errorf("queued test cases = %+v", queued.TestCases)
+			}
+		})
+	}
+}
+
+func TestGetBatchRequestRejectsBadBatches(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	executions := &queue{}
+	engine := gin.New()
+	engine.POST("/submission/batch", NewRequestController(executions, newLanguageService(t)).GetBatchRequest())
+
+	bodies := map[string]string{
+		"malformed":        `{"code":`,
+		"no test cases":    `{"code":"cHJpbnQoMSk=","language_id":1,"request_id":"request","test_cases":[]}`,
+		"unknown language": `{"code":"cHJpbnQoMSk=","language_id":99,"request_id":"request","test_cases":[{"stdin":"1"}]}`,
+	}
+	for name, body := range bodies {
+		t.Run(name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/submission/batch", strings.NewReader(body)))
+			if recorder.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", recorder.Code, http.StatusBadRequest)
+			}
+		})
+	}
+	if len(executions.processed) != 0 {
+		t.Errorf("%d rejected batches were queued", len(executions.processed))
+	}
+}
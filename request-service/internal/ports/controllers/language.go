@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"context"
+	"go-compiler/common/pkg/utils/logger"
+	pb "go-compiler/request-service/generated/go-compiler/generated/requestpb"
+	"go-compiler/request-service/internal/domain/services/interfaces"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LanguageController struct {
+	LanguageService interfaces.ILanguageService
+}
+
+func NewLanguageController(ls interfaces.ILanguageService) *LanguageController {
+	return &LanguageController{
+		LanguageService: ls,
+	}
+}
+
+func (lc *LanguageController) GetLanguages() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		log := logger.GetLogger(ctx)
+		methodName := "GetLanguages"
+		log.Info("Entering", "methodName", methodName)
+
+		ctx.JSON(200, lc.LanguageService.GetLanguages(ctx))
+	}
+}
+
+func (lc *LanguageController) ListLanguages(ctx context.Context, req *pb.LanguagesRequest) (*pb.LanguagesResponse, error) {
+	log := logger.GetLogger(ctx)
+	methodName := "ListLanguages"
+	log.Info("Entering", "methodName", methodName)
+
+	languages := lc.LanguageService.GetLanguages(ctx)
+	resp := &pb.LanguagesResponse{
+		Languages: make([]*pb.Language, 0, len(languages)),
+	}
+	for _, language := range languages {
+		resp.Languages = append(resp.Languages, &pb.Language{
+			LanguageId:      language.LanguageId,
+			Name:            language.Name,
+			Version:         language.Version,
+			IsCompiled:      language.IsCompiled,
+			CompilerOptions: language.CompilerOptions,
+		})
+	}
+	return resp, nil
+}
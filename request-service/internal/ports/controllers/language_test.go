@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"go-compiler/common/pkg/registry"
+	pb "go-compiler/request-service/generated/go-compiler/generated/requestpb"
+	"go-compiler/request-service/internal/domain/dto/response"
+	"go-compiler/request-service/internal/domain/services/impl"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// wantLanguages are the languages of the embedded registry, by ID
+var wantLanguages = []string{"Python", "JavaScript", "Bash", "Java", "Go", "C", "C++", "Rust"}
+
+// newLanguageService returns the language service over the embedded registry
+func newLanguageService(t *testing.T) *impl.LanguageService {
+	t.Helper()
+	t.Setenv(registry.ConfigPathEnv, "")
+	languages, err := registry.NewDefaultRegistry()
+	if err != nil {
+		t.Fatalf("NewDefaultRegistry() error = %v", err)
+	}
+	return impl.NewLanguageService(languages)
+}
+
+func TestGetLanguages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/languages", NewLanguageController(newLanguageService(t)).GetLanguages())
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/languages", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusOK)
+	}
+	var languages []response.LanguageResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &languages); err != nil {
+		t.Fatalf("failed to decode languages: %v", err)
+	}
+	if len(languages) != len(wantLanguages) {
+		t.Fatalf("listed %d languages, want %d", len(languages), len(wantLanguages))
+	}
+	for i, language := range languages {
+		if language.LanguageId != int64(i+1) || language.Name != wantLanguages[i] || language.Version == "" {
+			t.Errorf("language %d = %+v, want %s", i+1, language, wantLanguages[i])
+		}
+	}
+	// Compiled languages list the options they accept
+	if c := languages[5]; !c.IsCompiled || len(c.CompilerOptions) == 0 {
+		t.Errorf("C = %+v, want it compiled with compiler options", c)
+	}
+	if python := languages[0]; python.IsCompiled {
+		t.Errorf("Python = %+v, want it interpreted", python)
+	}
+}
+
+func TestListLanguages(t *testing.T) {
+	resp, err := NewLanguageController(newLanguageService(t)).ListLanguages(context.Background(), &pb.LanguagesRequest{})
+	if err != nil {
+		t.Fatalf("ListLanguages() error = %v", err)
+	}
+	if len(resp.Languages) != len(wantLanguages) {
+		t.Fatalf("listed %d languages, want %d", len(resp.Languages), len(wantLanguages))
+	}
+	for i, language := range resp.Languages {
+		if language.LanguageId != int64(i+1) || language.Name != wantLanguages[i] {
+			t.Errorf("language %d = %v, want %s", i+1, language, wantLanguages[i])
+		}
+	}
+}
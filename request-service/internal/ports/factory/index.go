@@ -1,17 +1,22 @@
 package factory
 
 import (
+	"go-compiler/common/pkg/config"
 	"go-compiler/request-service/internal/domain/factory"
 	"go-compiler/request-service/internal/ports/controllers"
 )
 
 type PortFactory struct {
-	RequestController controllers.RequestController
+	RequestController  controllers.RequestController
+	LanguageController controllers.LanguageController
+	AdminController    controllers.AdminController
 }
 
-func NewPortFactory() *PortFactory {
-	domains := factory.NewDomainFactory()
+func NewPortFactory(settings *config.Config) *PortFactory {
+	domains := factory.NewDomainFactory(settings)
 	return &PortFactory{
-		RequestController: *controllers.NewRequestController(domains.ExecutionService),
+		RequestController:  *controllers.NewRequestController(domains.ExecutionService, domains.LanguageService),
+		LanguageController: *controllers.NewLanguageController(domains.LanguageService),
+		AdminController:    *controllers.NewAdminController(domains.DeadLetterService),
 	}
 }
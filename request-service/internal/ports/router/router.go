@@ -1,15 +1,21 @@
 package router
 
 import (
+	"crypto/subtle"
 	"go-compiler/common/pkg/utils"
 	"go-compiler/request-service/internal/ports/factory"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func NewRouter() *gin.Engine {
+// AdminTokenEnv holds the bearer token of the admin endpoints, which are disabled when it is unset
+const AdminTokenEnv = "ADMIN_TOKEN"
+
+func NewRouter(portFactory *factory.PortFactory) *gin.Engine {
 	router := gin.New()
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
@@ -22,13 +28,20 @@ func NewRouter() *gin.Engine {
 		})
 	})
 
-	portFactory := factory.NewPortFactory()
-
 	api := router.Group("/api")
 	{
 		v1 := api.Group("/v1")
 		{
 			v1.POST("/submission", portFactory.RequestController.GetRequest())
+			v1.POST("/submission/batch", portFactory.RequestController.GetBatchRequest())
+			v1.GET("/languages", portFactory.LanguageController.GetLanguages())
+
+			if token := os.Getenv(AdminTokenEnv); token != "" {
+				admin := v1.Group("/admin", AdminAuthMiddleware(token))
+				admin.GET("/dead-letters", portFactory.AdminController.ListDeadLetters())
+				admin.GET("/dead-letters/:id", portFactory.AdminController.GetDeadLetter())
+				admin.POST("/dead-letters/:id/replay", portFactory.AdminController.ReplayDeadLetter())
+			}
 		}
 	}
 
@@ -48,6 +61,20 @@ func RateLimiterMiddleware(maxRequests int, window time.Duration) gin.HandlerFun
 	}
 }
 
+// AdminAuthMiddleware only lets requests carrying "Authorization: Bearer <token>" through
+func AdminAuthMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Unauthorized",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
 func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 
@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"fmt"
+	"go-compiler/common/pkg/config"
+	"go-compiler/common/pkg/constants"
 	pb "go-compiler/request-service/generated/go-compiler/generated/requestpb"
 	"go-compiler/request-service/internal/ports/factory"
 	"go-compiler/request-service/pkg/router"
@@ -21,9 +23,9 @@ type server struct {
 	portFactory *factory.PortFactory
 }
 
-func NewGrpcServer() *server {
+func NewGrpcServer(portFactory *factory.PortFactory) *server {
 	return &server{
-		portFactory: factory.NewPortFactory(),
+		portFactory: portFactory,
 	}
 }
 
@@ -39,10 +41,28 @@ func (s *server) SubmitRequest(ctx context.Context, req *pb.SubmissionRequest) (
 
 }
 
+// Implement the SubmitBatch gRPC method
+func (s *server) SubmitBatch(ctx context.Context, req *pb.BatchSubmissionRequest) (*pb.SubmissionResponse, error) {
+	return s.portFactory.RequestController.SubmitBatch(ctx, req)
+}
+
+// Implement the GetLanguages gRPC method
+func (s *server) GetLanguages(ctx context.Context, req *pb.LanguagesRequest) (*pb.LanguagesResponse, error) {
+	return s.portFactory.LanguageController.ListLanguages(ctx, req)
+}
+
 func main() {
+	settings, err := config.Load(constants.REQUEST_SERVICE)
+	if err != nil {
+		panic(fmt.Sprintf("failed to load configuration: %v", err))
+	}
+
+	// The HTTP and gRPC servers share one set of ports, so there is a single broker connection
+	portFactory := factory.NewPortFactory(settings)
+
 	// HTTP server setup
-	appRouter := router.GetRouter()
-	httpPort := ":8080"
+	appRouter := router.GetRouter(portFactory)
+	httpPort := settings.Server.HTTPAddr()
 
 	httpServer := &http.Server{
 		Addr:    httpPort,
@@ -50,14 +70,14 @@ func main() {
 	}
 
 	// gRPC server setup
-	grpcPort := ":50051"
+	grpcPort := settings.Server.GRPCAddr()
 	lis, err := net.Listen("tcp", grpcPort)
 	if err != nil {
 		panic(fmt.Sprintf("failed to listen on port %s: %v", grpcPort, err))
 	}
 
 	grpcServer := grpc.NewServer()
-	pb.RegisterRequestServiceServer(grpcServer, NewGrpcServer())
+	pb.RegisterRequestServiceServer(grpcServer, NewGrpcServer(portFactory))
 	reflection.Register(grpcServer)
 
 	// Run HTTP and gRPC servers concurrently
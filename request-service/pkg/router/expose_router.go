@@ -1,11 +1,12 @@
 package router
 
 import (
+	"go-compiler/request-service/internal/ports/factory"
 	"go-compiler/request-service/internal/ports/router"
 
 	"github.com/gin-gonic/gin"
 )
 
-func GetRouter() *gin.Engine {
-	return router.NewRouter()
+func GetRouter(portFactory *factory.PortFactory) *gin.Engine {
+	return router.NewRouter(portFactory)
 }
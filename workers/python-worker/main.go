@@ -1,191 +1,284 @@
 package main
 
 import (
-	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"go-compiler/common/pkg/broker"
+	"go-compiler/common/pkg/config"
+	"go-compiler/common/pkg/events"
+	"go-compiler/common/pkg/judge"
+	"go-compiler/common/pkg/limits"
+	"go-compiler/common/pkg/registry"
+	"go-compiler/common/pkg/runner"
+	"go-compiler/common/pkg/sandbox"
+	"go-compiler/common/pkg/utils"
+	"go-compiler/common/pkg/workspace"
+	"go-compiler/models/results"
+	"go-compiler/models/submissions"
 	"log"
 	"os"
-	"os/exec"
 	"time"
 
 	"github.com/streadway/amqp"
 )
 
 type NewExecutionRequest struct {
-	Code         string `json:"code"`
-	StdIn        string `json:"stdin"`
-	ConnectionId string `json:"connection_id"`
-	LanguageId   int    `json:"language_id"`
-}
-
-type ExecutionResult struct {
-	ConnectionId string `json:"connection_id"`
-	Output       string `json:"output"`
+	Code            string                 `json:"code"`
+	StdIn           string                 `json:"stdin"`
+	RequestId       string                 `json:"request_id"`
+	ConnectionId    string                 `json:"connection_id"`
+	Interactive     bool                   `json:"interactive,omitempty"`
+	LanguageId      int64                  `json:"language_id"`
+	ExpectedOutput  *string                `json:"expected_output,omitempty"`
+	CompareMode     string                 `json:"compare_mode,omitempty"`
+	FloatTolerance  float64                `json:"float_tolerance,omitempty"`
+	CompilerOptions []string               `json:"compiler_options,omitempty"`
+	TestCases       []submissions.TestCase `json:"test_cases,omitempty"`
+	submissions.Limits
+	submissions.Sources
 }
 
 func main() {
-	// Fetch RabbitMQ URL from the environment variable
-	rabbitMQURL := os.Getenv("RABBITMQ_URL")
-	if rabbitMQURL == "" {
-		log.Fatal("RABBITMQ_URL environment variable not set")
+	// Apply submission limits when re-executed by the runner
+	runner.Init()
+
+	// The broker URL comes from config.yaml, BROKER_URL or RABBITMQ_URL; the limits are applied too
+	settings, err := config.Load("python-worker")
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
 	}
+	rabbitMQURL := settings.Broker.URL
 
 	// Log the RabbitMQ URL to verify it during troubleshooting
 	log.Printf("Connecting to RabbitMQ at: %s", rabbitMQURL)
 
-	// Connect to RabbitMQ using the URL from the environment variable
-	conn, err := amqp.Dial(rabbitMQURL)
-	if err != nil {
-		log.Fatalf("Failed to connect to RabbitMQ: %v", err)
-	}
-	defer conn.Close()
+	// Connect to RabbitMQ using the URL from the environment variable; the client reconnects
+	// whenever the connection drops
+	client := broker.NewClient(rabbitMQURL)
+	defer client.Close()
 
-	ch, err := conn.Channel()
+	// Load the languages shared with execution-service
+	languageRegistry, err := registry.NewDefaultRegistry()
 	if err != nil {
-		log.Fatalf("Failed to open a channel: %v", err)
+		log.Fatalf("Failed to load language registry: %v", err)
 	}
-	defer ch.Close()
+
+	// Submissions come from the same queues as execution-service's, so they run in the same sandbox
+	isolation := newSandbox()
+
+	// Results are stored in Redis for polling clients and published for WebSocket clients, the same
+	// way execution-service reports them
+	reporter := events.NewReporter(client, utils.NewCacheClient(settings.Redis.Address, settings.Redis.Password, settings.Redis.DB))
 
 	// Start the worker to listen to the submissions queue
-	go listenForTasks(ch)
+	go listenForTasks(client, reporter, languageRegistry, isolation, settings.Interactive)
 
 	// Keep the worker running
 	select {}
 }
 
-func listenForTasks(ch *amqp.Channel) {
-	// Declare the queue to ensure it exists
-	queue, err := ch.QueueDeclare(
-		"submissions", // name of the queue
-		true,          // durable
-		false,         // delete when unused
-		false,         // exclusive
-		false,         // no-wait
-		nil,           // arguments
-	)
+func listenForTasks(client *broker.Client, reporter *events.Reporter, languageRegistry registry.ILanguageRegistry, isolation runner.Isolation, interactiveSettings config.Interactive) {
+	// Declare the queues, and where submissions that cannot be handled go, then consume them holding
+	// one unacknowledged submission per queue; the rest stay in the queues for other workers.
+	// Submissions are acked once their result is reported
+	interactive, err := client.Consume(submissions.Interactive.Queue(), "", broker.DefaultPrefetch)
 	if err != nil {
-		log.Fatalf("Failed to declare a queue: %v", err)
+		log.Fatalf("Failed to register a consumer: %v", err)
 	}
-
-	log.Printf("Declared queue: %s", queue.Name)
-
-	// Now consume from the queue
-	msgs, err := ch.Consume(
-		"submissions", // queue
-		"",            // consumer
-		true,          // auto-ack
-		false,         // exclusive
-		false,         // no-local
-		false,         // no-wait
-		nil,           // args
-	)
+	batch, err := client.Consume(submissions.Batch.Queue(), "", broker.DefaultPrefetch)
 	if err != nil {
 		log.Fatalf("Failed to register a consumer: %v", err)
 	}
 
-	for msg := range msgs {
+	for {
+		msg, ok := nextTask(interactive, batch)
+		if !ok {
+			return
+		}
+
 		start := time.Now()
 		var req NewExecutionRequest
 		if err := json.Unmarshal(msg.Body, &req); err != nil {
 			log.Printf("Error decoding RabbitMQ message: %v", err)
+			settle(client, msg, broker.Permanent(err))
 			continue
 		}
 
-		// Process the execution request
-		output := processExecution(req)
-
-		// Send the result back to the executions queue
-		sendResult(ch, req.ConnectionId, output)
+		// Process the execution request, reporting each stage it enters
+		progress := reporter.Progress(req.RequestId, req.ConnectionId)
+		progress.Enter(results.Processing)
+		executor := progress.Executor(runner.Local{})
 
-		log.Printf("Execution completed in: %v", time.Since(start))
-	}
-}
+		// Interactive programs read what their WebSocket client types
+		var input *events.InputStream
+		if req.Interactive && req.ConnectionId != "" {
+			input, err = events.OpenInput(client, req.RequestId, req.ConnectionId, interactiveSettings)
+			if err != nil {
+				log.Printf("Failed to receive input: %v", err)
+				settle(client, msg, err)
+				continue
+			}
+			executor = input.Executor(executor)
+		}
 
-func processExecution(req NewExecutionRequest) string {
-	// Decode the base64-encoded code
-	decodedCode, err := base64.StdEncoding.DecodeString(req.Code)
-	if err != nil {
-		return fmt.Sprintf("Error decoding base64 code: %v", err)
-	}
-
-	// Initialize command based on the LanguageId
-	var cmd *exec.Cmd
-	switch req.LanguageId {
-	case 1: // Python
-		cmd = exec.Command("python3", "-c", string(decodedCode))
-	case 2: // JavaScript (Node.js)
-		cmd = exec.Command("node", "-e", string(decodedCode))
-	case 3: // Go
-		tempFile := "/tmp/temp.go"
-		err := os.WriteFile(tempFile, decodedCode, 0644)
-		if err != nil {
-			return fmt.Sprintf("Error writing Go code to file: %v", err)
+		// A WebSocket client sees the output of single runs as it is written
+		var output *events.OutputStream
+		if req.ConnectionId != "" && len(req.TestCases) == 0 {
+			output = reporter.Output(req.RequestId, req.ConnectionId)
+			executor = output.Executor(executor)
 		}
-		cmd = exec.Command("go", "run", tempFile)
-	case 4: // Java
-		tempFile := "/tmp/Main.java"
-		err := os.WriteFile(tempFile, decodedCode, 0644)
-		if err != nil {
-			return fmt.Sprintf("Error writing Java code to file: %v", err)
+		result, err := processExecution(languageRegistry, executor, isolation, req)
+		// The output ends ahead of the completion event
+		if output != nil {
+			output.Close()
+		}
+		if input != nil {
+			input.Close()
 		}
-		// Compile Java code
-		compileCmd := exec.Command("javac", tempFile)
-		_, err = compileCmd.CombinedOutput()
 		if err != nil {
-			return fmt.Sprintf("Error compiling Java code: %v", err)
+			log.Printf("Error processing submission: %v", err)
+			if broker.IsPermanent(err) {
+				reportFailure(reporter, req, err)
+			}
+			settle(client, msg, err)
+			continue
 		}
-		cmd = exec.Command("java", "-cp", "/tmp", "Main")
-	case 9: // Bash
-		// Write the Bash code to a temporary file
-		tempFile := "/tmp/temp.sh"
-		err := os.WriteFile(tempFile, decodedCode, 0755) // 0755 permissions to execute
+
+		// Report the result to polling and WebSocket clients
+		err = reporter.Completed(results.CompletionEvent{
+			RequestId:    req.RequestId,
+			ConnectionId: req.ConnectionId,
+			Result:       result,
+		})
 		if err != nil {
-			return fmt.Sprintf("Error writing Bash code to file: %v", err)
+			log.Printf("Failed to report execution result: %v", err)
 		}
-		// Use bash to execute the script
-		cmd = exec.Command("bash", tempFile)
+		settle(client, msg, err)
+
+		log.Printf("Execution completed in: %v", time.Since(start))
+	}
+}
+
+// nextTask takes a waiting interactive submission before any batch one. It returns false once the
+// client was closed.
+func nextTask(interactive, batch <-chan amqp.Delivery) (amqp.Delivery, bool) {
+	select {
+	case msg, ok := <-interactive:
+		return msg, ok
 	default:
-		return "Unsupported language"
+	}
+	select {
+	case msg, ok := <-interactive:
+		return msg, ok
+	case msg, ok := <-batch:
+		return msg, ok
+	}
+}
+
+// processExecution runs the submission in its own workspace. Invalid submissions are reported as
+// broker.Permanent errors so they are dead-lettered; other errors may succeed when retried.
+func processExecution(languageRegistry registry.ILanguageRegistry, executor runner.Executor, isolation runner.Isolation, req NewExecutionRequest) (results.ExecutionResult, error) {
+	// Decode the base64-encoded code
+	decodedCode, err := base64.StdEncoding.DecodeString(req.Code)
+	if err != nil {
+		return results.ExecutionResult{}, broker.Permanent(fmt.Errorf("error decoding base64 code: %w", err))
+	}
+
+	// Decode the other files of multi-file submissions
+	files, err := workspace.Decode(req.Sources)
+	if err != nil {
+		return results.ExecutionResult{}, broker.Permanent(err)
+	}
+
+	// Resolve the language from the shared registry
+	language, found := languageRegistry.Get(req.LanguageId)
+	if !found {
+		return results.ExecutionResult{}, broker.Permanent(fmt.Errorf("unsupported language id %d", req.LanguageId))
 	}
 
-	// Set up stdin for the command if provided
-	if req.StdIn != "" {
-		cmd.Stdin = bytes.NewBufferString(req.StdIn)
+	mode, err := judge.ParseMode(req.CompareMode)
+	if err != nil {
+		return results.ExecutionResult{}, broker.Permanent(err)
+	}
+	if err := judge.CheckTolerance(req.FloatTolerance); err != nil {
+		return results.ExecutionResult{}, broker.Permanent(err)
 	}
 
-	// Execute the code
-	output, err := cmd.CombinedOutput()
+	resolvedLimits, err := limits.Resolve(limits.ForLanguage(req.Limits, language))
 	if err != nil {
-		return fmt.Sprintf("Error executing code: %v\nOutput: %s", err, string(output))
+		return results.ExecutionResult{}, broker.Permanent(err)
 	}
 
-	return string(output)
+	// Every submission gets its own workspace so nothing leaks between executions
+	workDir, err := os.MkdirTemp("", "submission-")
+	if err != nil {
+		return results.ExecutionResult{}, fmt.Errorf("error creating workspace: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	submission := runner.Submission{
+		Language:        language,
+		Code:            decodedCode,
+		Files:           files,
+		CompilerOptions: req.CompilerOptions,
+		StdIn:           req.StdIn,
+		WorkDir:         workDir,
+		Limits:          resolvedLimits,
+
+		// Compiling is as untrusted as running, so both happen inside the sandbox
+		Isolation: isolation,
+		RequestId: req.RequestId,
+	}
+
+	// Batch submissions compile once and are judged per test case
+	if len(req.TestCases) > 0 {
+		return judge.RunTestCases(context.Background(), executor, submission, req.TestCases, mode, req.FloatTolerance), nil
+	}
+
+	// Compile when needed and execute the code
+	result := runner.RunWith(context.Background(), executor, submission)
+
+	// Judge the output against the expected output when one was provided
+	judge.Apply(&result, req.ExpectedOutput, mode, req.FloatTolerance)
+	return result, nil
 }
-func sendResult(ch *amqp.Channel, connectionId, output string) {
-	result := ExecutionResult{
-		ConnectionId: connectionId,
-		Output:       output,
+
+// reportFailure reports an internal error for a submission that will not be retried, so its client is
+// not left waiting. The submission is dead-lettered either way.
+func reportFailure(reporter *events.Reporter, req NewExecutionRequest, cause error) {
+	err := reporter.Completed(results.CompletionEvent{
+		RequestId:    req.RequestId,
+		ConnectionId: req.ConnectionId,
+		Result:       runner.InternalError(cause),
+	})
+	if err != nil {
+		log.Printf("Failed to report failed submission: %v", err)
 	}
+}
 
-	body, err := json.Marshal(result)
+// newSandbox returns the sandbox submissions run in. The worker refuses to start without one unless
+// SANDBOX_ENABLED is false, which is only meant for local development.
+func newSandbox() runner.Isolation {
+	if !sandbox.Enabled() {
+		log.Printf("Sandbox disabled, submissions run unconfined")
+		return nil
+	}
+	config, err := sandbox.ConfigFromEnv()
 	if err != nil {
-		log.Printf("Error marshalling execution result: %v", err)
-		return
-	}
-
-	err = ch.Publish(
-		"",           // exchange
-		"executions", // routing key
-		false,        // mandatory
-		false,        // immediate
-		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
-		},
-	)
+		log.Fatalf("Failed to configure sandbox: %v", err)
+	}
+	newSandbox, err := sandbox.NewSandbox(config)
 	if err != nil {
-		log.Printf("Failed to publish execution result: %v", err)
+		log.Fatalf("Failed to create sandbox: %v (set %s=false to run unconfined in development)", err, sandbox.EnabledEnv)
+	}
+	return newSandbox
+}
+
+// settle acks the submission, or retries or rejects it when it could not be handled
+func settle(client *broker.Client, msg amqp.Delivery, handlingError error) {
+	if err := broker.Settle(client, msg, handlingError, broker.DefaultMaxRetries); err != nil {
+		log.Printf("Failed to acknowledge message: %v", err)
 	}
 }